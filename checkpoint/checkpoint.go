@@ -0,0 +1,108 @@
+// Package checkpoint periodically saves the crawl frontier and download
+// queue to a file, so an interrupted crawl can resume where it stopped
+// instead of restarting the traversal.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeb/url_crawler/crawler"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// version is bumped when State changes incompatibly
+const version = 1
+
+// State is the content of a checkpoint file
+type State struct {
+	Version    int                       `json:"version"`
+	StartURL   string                    `json:"start_url"`
+	SavedAt    time.Time                 `json:"saved_at"`
+	Visited    []string                  `json:"visited"`
+	Frontier   []crawler.FrontierEntry   `json:"frontier"`
+	Downloaded []string                  `json:"downloaded"`
+	Downloads  []downloader.DownloadTask `json:"downloads"`
+}
+
+// Capture snapshots the crawler and download manager
+func Capture(startURL string, c *crawler.CrawlerTwoTier, m *downloader.Manager) *State {
+	visited, frontier := c.Frontier()
+	return &State{
+		Version:    version,
+		StartURL:   startURL,
+		SavedAt:    time.Now(),
+		Visited:    visited,
+		Frontier:   frontier,
+		Downloaded: m.DownloadedURLs(),
+		Downloads:  m.PendingTasks(),
+	}
+}
+
+// Save writes s to path, replacing the previous checkpoint atomically
+func (s *State) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads a checkpoint written by Save
+func Load(path string) (*State, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s State
+	if err := json.NewDecoder(f).Decode(&s); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %w", path, err)
+	}
+	if s.Version != version {
+		return nil, fmt.Errorf("checkpoint %s has version %d, want %d", path, s.Version, version)
+	}
+	return &s, nil
+}
+
+// Restore loads s into a crawler that has not started yet and a manager
+// whose workers are running
+func (s *State) Restore(c *crawler.CrawlerTwoTier, m *downloader.Manager) {
+	c.RestoreFrontier(s.Visited, s.Frontier)
+	m.RestoreDownloads(s.Downloaded, s.Downloads)
+}
+
+// Run saves a checkpoint to path every interval until shutdown is closed
+func Run(path string, interval time.Duration, startURL string, c *crawler.CrawlerTwoTier, m *downloader.Manager, shutdown <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+			s := Capture(startURL, c, m)
+			if err := s.Save(path); err != nil {
+				logger.Errorf("❌ Failed to save checkpoint: %v\n", err)
+				continue
+			}
+			logger.Infof("💾 Checkpoint saved: %d visited, %d pending pages, %d queued downloads\n",
+				len(s.Visited), len(s.Frontier), len(s.Downloads))
+		}
+	}
+}
@@ -12,6 +12,11 @@ const (
 	// Colly v1.2.0 appears to have ~10-20 item response queue
 	ConcurrentWorkers = 20 // Ultra-safe limit for colly v1.2.0
 
+	MaxURLLength        = 2048               // Longer discovered URLs are dropped
+	MaxPageSize         = 5 * 1024 * 1024    // Pages are cut off at 5MB
+	MaxRefetchPageSize  = 64 * 1024 * 1024   // Cap when refetching truncated pages in full
+	MaxStreamedPageSize = 1024 * 1024 * 1024 // Cap on pages streamed through the fast path
+
 	PoliteDelay = 30 * time.Millisecond // Aggressive crawling
 	UserAgent   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0 Safari/537.36"
 
@@ -22,19 +27,88 @@ const (
 	ScaleCheckInterval     = 500 * time.Millisecond // Check twice per second
 	ScaleUpAmount          = 300                    // Add 300 workers at a time
 	MaxQueueSize           = 50000                  // 50K item queue
+	HostQueueShards        = 64                     // Queues in host-sharded mode
+	WorkerStartupJitter    = 2 * time.Second        // Max random start delay for scaled-up workers
+	InterfaceRatePerWorker = 50                     // Default downloads/sec per NIC, per configured worker
+	WorkerIdleJitter       = 1 * time.Millisecond   // Max extra random sleep when a worker finds no work
+
+	// Adaptive worker ceiling: the scaler backs off while hosts push back
+	ErrorCeilingWindow     = 5 * time.Second // How often the error rate is evaluated
+	ErrorCeilingMinSamples = 50              // Responses needed in a window to act
+	ErrorCeiling429Rate    = 0.05            // Lower the ceiling above 5% 429s...
+	ErrorCeilingErrorRate  = 0.25            // ...or above 25% 429/5xx/network errors
+	ErrorCeilingBackoff    = 0.5             // Multiply the ceiling by this when lowering
+	ErrorCeilingFloor      = 100             // Never lower the ceiling below this
 
 	// Multi-NIC network beast mode
 	MaxConnectionsTotal   = 12000             // 12K total connections across all NICs
 	MaxConnectionsPerHost = 1200              // 1.2K per host
 	ConnectionTimeout     = 3 * time.Second   // Ultra-fast connection establishment
 	KeepAliveTimeout      = 300 * time.Second // 5-minute keep-alive
+	TLSHandshakeTimeout   = 5 * time.Second   // TLS negotiation after connecting
+	ResponseHeaderTimeout = 15 * time.Second  // Time to first byte after sending the request
 
 	// Hardware-optimized settings
-	DownloadBufferSize = 32 * 1024 * 1024       // 32MB buffer for 10GbE
-	MaxRetries         = 3                      // Fewer retries for speed
-	RetryBackoff       = 300 * time.Millisecond // Very fast retry
+	DownloadBufferSize = 32 * 1024 * 1024        // 32MB buffer for 10GbE
+	MaxRetries         = 3                       // Fewer retries for speed
+	RetryBackoff       = 300 * time.Millisecond  // Base retry delay, doubled per attempt
+	RetryBackoffCap    = 30 * time.Second        // Longest delay before a retry
+	MaxDownloadSize    = 50 * 1024 * 1024 * 1024 // Per-file cap without -max-size, so an endless response can't fill the disk
+
+	// Unwritable target directory handling
+	StorageFailureThreshold = 5                // Consecutive write failures before pausing downloads
+	StorageProbeInterval    = 10 * time.Second // How often a paused manager retries writing
+
+	// Per-host circuit breakers (off unless a window is given)
+	BreakerWindow      = 20               // Recent downloads per host the failure rate is taken over
+	BreakerFailureRate = 0.5              // Open the breaker when half of them failed
+	BreakerCooldown    = 30 * time.Second // Hold the host's tasks back this long before probing
+
+	// Bearer-token authentication (off unless a token command is given)
+	BearerTokenTTL = 5 * time.Minute // Reuse a fetched token this long
+
+	// Per-download latency percentiles
+	TimingSamples = 10000 // Most recent downloads kept for TTFB/transfer percentiles
+
+	// Following links found in downloaded PDFs (off unless enabled)
+	PDFLinkMaxBytes    = 200 * 1024 * 1024 // Larger PDFs aren't scanned
+	PDFLinkMaxPages    = 2000              // Pages scanned per PDF
+	PDFLinkMaxLinks    = 5000              // Links taken per PDF
+	PDFOutlineMaxItems = 10000             // Outline (TOC) entries walked per PDF
+
+	// Links taken from one HTML page; the rest are dropped, so a link bomb
+	// can't flood the frontier
+	MaxLinksPerPage = 10000
+
+	// Post-download processing pool
+	PostProcessWorkers   = 8                      // Concurrent post-processor goroutines
+	PostProcessQueueSize = 10000                  // Completed downloads waiting for processing
+	IdlePollInterval     = 200 * time.Millisecond // How often WaitIdle checks the downloads
+
+	// HEAD probing of extension-less links (off unless enabled)
+	HeadProbeWorkers   = 4               // Concurrent HEAD requests
+	HeadProbeRate      = 10              // HEAD requests per second
+	HeadProbeQueueSize = 1000            // Pending probes before new ones are dropped
+	HeadProbeTimeout   = 5 * time.Second // Per-request timeout
+
+	// -check-egress reflector requests
+	EgressCheckTimeout = 10 * time.Second
+
+	// SQLite crawl statistics
+	StatsDBQueueSize     = 10000           // Records waiting for the writer
+	StatsDBBatchSize     = 1000            // Records per transaction
+	StatsDBFlushInterval = 1 * time.Second // Flush partial batches this often
+
+	// WARC archiving
+	WARCMaxFileSize = 1024 * 1024 * 1024 // Rotate .warc.gz files at 1GB
 
 	// Memory settings
 	TargetMemoryUsageGB = 50  // Use up to 50GB of RAM
 	GCTargetPercent     = 100 // Less frequent GC
+	URLMapWarnGB        = 8   // Warn when the URL maps are estimated above this
+
+	// Goroutine leak warning: more than GoroutinesPerWorker per active
+	// download worker plus GoroutineWarnMargin is treated as a likely leak
+	GoroutinesPerWorker = 4    // Worker, HTTP connection reader/writer, retry timer
+	GoroutineWarnMargin = 5000 // Crawler, logging, monitors and idle connections
 )
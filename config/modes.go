@@ -0,0 +1,44 @@
+package config
+
+import "fmt"
+
+// Mode bundles the settings for one kind of crawl job, picked with -mode.
+// Where the matching flag is also given, the flag wins.
+type Mode struct {
+	Name         string
+	MaxDepth     int  // Page hops from the seeds
+	HostPages    int  // Pages crawled per host (0 = unlimited)
+	FastPathDocs bool // Detect documents on fast-path pages too
+	HeadProbe    bool // HEAD-probe extension-less links for documents
+}
+
+// Document-hunt defaults: a shallow, bounded crawl that looks for
+// documents on every page
+const (
+	DocumentModeMaxDepth  = 5
+	DocumentModeHostPages = 2000
+)
+
+// modes are the presets accepted by LookupMode. crawl is the usual
+// breadth crawl; documents traverses HTML only to reach documents.
+var modes = map[string]Mode{
+	"crawl": {
+		MaxDepth: MaxDepth,
+	},
+	"documents": {
+		MaxDepth:     DocumentModeMaxDepth,
+		HostPages:    DocumentModeHostPages,
+		FastPathDocs: true,
+		HeadProbe:    true,
+	},
+}
+
+// LookupMode returns the preset called name: crawl or documents
+func LookupMode(name string) (Mode, error) {
+	m, ok := modes[name]
+	if !ok {
+		return Mode{}, fmt.Errorf("unknown mode %q (use crawl or documents)", name)
+	}
+	m.Name = name
+	return m, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Profile bundles the settings that decide how hard a crawl pushes, so they
+// can be picked together with -profile instead of tuned one by one
+type Profile struct {
+	Name             string
+	CrawlParallelism int           // Concurrent page requests
+	CrawlDelay       time.Duration // Delay between page requests to a host
+	InitialWorkers   int           // Download workers started up front
+	MaxWorkers       int           // Ceiling the worker scaler grows to
+	PerHostRate      float64       // Downloads per second per host (0 = unlimited)
+	Timeouts         string        // Timeout preset name, see network.TimeoutProfile
+}
+
+// profiles are the presets accepted by LookupProfile. beast is the
+// long-standing aggressive configuration; default and slow are kept as
+// aliases for it from when -profile only chose timeouts.
+var profiles = map[string]Profile{
+	"gentle": {
+		CrawlParallelism: 2,
+		CrawlDelay:       1 * time.Second,
+		InitialWorkers:   4,
+		MaxWorkers:       16,
+		PerHostRate:      1,
+		Timeouts:         "slow",
+	},
+	"balanced": {
+		CrawlParallelism: 8,
+		CrawlDelay:       250 * time.Millisecond,
+		InitialWorkers:   32,
+		MaxWorkers:       200,
+		PerHostRate:      5,
+		Timeouts:         "default",
+	},
+	"beast": {
+		CrawlParallelism: ConcurrentWorkers,
+		CrawlDelay:       PoliteDelay,
+		InitialWorkers:   InitialDownloadWorkers,
+		MaxWorkers:       MaxDownloadWorkers,
+		Timeouts:         "default",
+	},
+}
+
+// profileAliases map older -profile values onto presets
+var profileAliases = map[string]struct{ profile, timeouts string }{
+	"default": {"beast", "default"},
+	"slow":    {"beast", "slow"},
+}
+
+// LookupProfile returns the preset called name: gentle, balanced or beast
+func LookupProfile(name string) (Profile, error) {
+	timeouts := ""
+	if alias, ok := profileAliases[name]; ok {
+		name, timeouts = alias.profile, alias.timeouts
+	}
+
+	p, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q (use gentle, balanced or beast)", name)
+	}
+	p.Name = name
+	if timeouts != "" {
+		p.Timeouts = timeouts
+	}
+	return p, nil
+}
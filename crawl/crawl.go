@@ -0,0 +1,281 @@
+// Package crawl is the embeddable entry point to the crawler.
+// Crawl runs the same pipeline as the command-line tool — interface setup,
+// download workers, monitor and two-tier crawler — from explicit options,
+// without prompts and without touching process-wide settings such as
+// GOMAXPROCS, the GC target or file descriptor limits.
+package crawl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+
+	"github.com/jeb/url_crawler/crawler"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/monitor"
+	"github.com/jeb/url_crawler/network"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/tokenizer"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// Options configures a crawl
+type Options struct {
+	Seeds []string // Start URLs; at least one is required
+	// SeedPriorities ranks seeds by URL as given in Seeds: higher ones and
+	// the pages they link to are crawled first. Missing seeds get 0.
+	SeedPriorities map[string]int
+	OutputDir      string   // Where downloaded documents are written
+	LogDir         string   // Where the visited/download logs go; defaults to OutputDir
+	Interfaces     []string // Network interface names; empty means every active interface
+	// SourceIPs lists, per interface name, addresses on that NIC to rotate
+	// connections over; see network.SetSourceIPs
+	SourceIPs map[string][]string
+
+	MaxDepth           int                      // Zero means the default depth limit
+	MaxPages           int                      // Zero means unlimited
+	MaxPagesPerHost    int                      // Zero means unlimited
+	MaxCrawlRate       float64                  // Page requests per second across all hosts; zero means unlimited
+	MaxLinksPerPage    int                      // Zero means config.MaxLinksPerPage, negative unlimited
+	PaginationPriority int                      // Visit rel=next pages this far ahead of other links; see crawler.SetPaginationPriority
+	DocumentTypes      []string                 // File extensions downloaded as documents; nil means .pdf
+	FastPathDocuments  bool                     // Also detect documents on fast-path pages
+	SizeRules          []tokenizer.SizeRule     // Route pages by size band; nil means the fast/slow thresholds
+	FollowPDFLinks     bool                     // Follow links found in downloaded PDFs
+	RespectRobots      bool                     // Obey robots.txt, robots meta tags and X-Robots-Tag
+	StreamPagesOver    int64                    // Stream HTML pages at least this large; zero means never
+	Scope              crawler.Scope            // Which pages to crawl relative to the seeds
+	SchemeRewrite      crawler.SchemeRewrite    // Rewrite discovered links to https or the seed's scheme
+	Dedup              crawler.DedupRules       // Which URL variants and pages count as the same page
+	Schemes            []string                 // Link schemes to follow; nil means tokenizer.DefaultSchemes
+	VerifyDownloads    bool                     // Re-read and hash-check saved files; see downloader.SetVerifyDownloads
+	CaptureHeaders     bool                     // Save response headers as <file>.headers.json
+	Conditional        bool                     // Re-request saved files with If-Modified-Since; see downloader.SetConditionalRequests
+	CircuitBreaker     downloader.BreakerConfig // Defer a failing host's tasks; the zero value disables
+	FilenameFunc       downloader.FilenameFunc  // Names saved files; nil means the URL's file name
+
+	// Collector, if set, is used for page requests instead of the default
+	// one; see crawler.NewCrawlerTwoTierWithCollector
+	Collector *colly.Collector
+
+	// Transport, if set, carries every page and download request through a
+	// single "default" interface instead of the machine's NICs, so a crawl
+	// can run against an httptest.Server or a recording transport.
+	// Interfaces is ignored.
+	Transport http.RoundTripper
+}
+
+// Report summarizes a finished crawl
+type Report struct {
+	StartedAt        time.Time
+	Elapsed          time.Duration
+	DownloadAttempts int64
+	Downloaded       int64
+	NotModified      int64 // Saved files the server reported unchanged
+	Failed           int64
+	BytesDownloaded  int64
+	Interfaces       []string
+	Domains          map[string]downloader.DomainStat
+	StatusCodes      map[int]int64
+	ContentTypes     map[string]int64 // Responses per media type, pages and documents
+	DepthCounts      []int64          // Visited URLs per depth, from 0
+	DuplicatePages   int64            // Pages not followed as duplicates; see crawler.DedupStrategy
+	Timing           downloader.TimingStats
+	Failures         []downloader.FailedDownload // Documents that failed for good, with the last error
+	Panics           []panics.Report             // Panics recovered during the crawl, with stacks
+	VisitedLogPath   string
+	DownloadLogPath  string
+}
+
+// Crawl runs a complete crawl and blocks until it finishes
+func Crawl(opts Options) (*Report, error) {
+	if len(opts.Seeds) == 0 {
+		return nil, errors.New("crawl: no seeds given")
+	}
+	if opts.OutputDir == "" {
+		return nil, errors.New("crawl: no output directory given")
+	}
+
+	seeds := make([]string, 0, len(opts.Seeds))
+	priorities := make([]int, 0, len(opts.Seeds))
+	for _, raw := range opts.Seeds {
+		seed, err := utils.ValidateStartURL(raw)
+		if err != nil {
+			return nil, fmt.Errorf("crawl: %w", err)
+		}
+		seeds = append(seeds, seed)
+		priorities = append(priorities, opts.SeedPriorities[raw])
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("crawl: creating output directory: %w", err)
+	}
+	logDir := opts.LogDir
+	if logDir == "" {
+		logDir = opts.OutputDir
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, fmt.Errorf("crawl: creating log directory: %w", err)
+	}
+
+	var interfaces []network.NetworkInterface
+	if opts.Transport != nil {
+		interfaces = []network.NetworkInterface{
+			network.NewInterfaceWithTransport("default", opts.Transport, 8),
+		}
+	} else {
+		detected, err := selectInterfaces(opts.Interfaces)
+		if err != nil {
+			return nil, err
+		}
+		for name, ips := range opts.SourceIPs {
+			if !network.SetSourceIPs(detected, name, ips) {
+				return nil, fmt.Errorf("crawl: source IPs for %q: not a selected interface", name)
+			}
+		}
+		interfaces = detected
+	}
+	interfaces, err := network.InitializeMultiNICSystem(interfaces)
+	if err != nil {
+		return nil, fmt.Errorf("crawl: %w", err)
+	}
+
+	startedAt := time.Now()
+	timestamp := startedAt.Format("20060102_150405")
+	visitedLogPath := filepath.Join(logDir, fmt.Sprintf("visitedURLs_%s.txt", timestamp))
+	downloadLogPath := filepath.Join(logDir, fmt.Sprintf("downloads_%s.txt", timestamp))
+
+	downloadManager := downloader.NewManager(interfaces, opts.OutputDir, downloadLogPath)
+	downloadManager.SetVerifyDownloads(opts.VerifyDownloads)
+	downloadManager.SetCaptureHeaders(opts.CaptureHeaders)
+	downloadManager.SetConditionalRequests(opts.Conditional)
+	downloadManager.SetCircuitBreaker(opts.CircuitBreaker)
+	downloadManager.SetFilenameFunc(opts.FilenameFunc)
+	downloadManager.StartWorkers()
+
+	shutdownChan := make(chan struct{})
+	monitorSystem := monitor.NewMonitor(downloadManager, interfaces, shutdownChan)
+	monitorSystem.StartMonitoring(16)
+
+	var webCrawler *crawler.CrawlerTwoTier
+	if opts.Collector != nil {
+		webCrawler = crawler.NewCrawlerTwoTierWithCollector(opts.Collector, seeds[0], visitedLogPath, downloadManager)
+	} else {
+		webCrawler = crawler.NewCrawlerTwoTier(seeds[0], visitedLogPath, downloadManager)
+	}
+	for i, seed := range seeds {
+		if i > 0 || priorities[i] != 0 {
+			webCrawler.AddSeed(seed, priorities[i])
+		}
+	}
+	if opts.MaxDepth > 0 {
+		webCrawler.SetMaxDepth(opts.MaxDepth)
+	}
+	webCrawler.SetMaxPages(opts.MaxPages)
+	webCrawler.SetPerHostPageBudget(opts.MaxPagesPerHost)
+	webCrawler.SetGlobalCrawlRate(opts.MaxCrawlRate)
+	if opts.MaxLinksPerPage != 0 {
+		webCrawler.SetMaxLinksPerPage(opts.MaxLinksPerPage)
+	}
+	webCrawler.SetPaginationPriority(opts.PaginationPriority)
+	webCrawler.SetDocumentExtensions(opts.DocumentTypes)
+	webCrawler.SetFastPathDocuments(opts.FastPathDocuments)
+	webCrawler.SetSizeRules(opts.SizeRules)
+	webCrawler.SetFollowPDFLinks(opts.FollowPDFLinks)
+	webCrawler.SetRespectRobots(opts.RespectRobots)
+	webCrawler.SetStreamLargePages(opts.StreamPagesOver)
+	webCrawler.SetScope(opts.Scope)
+	webCrawler.SetSchemeRewrite(opts.SchemeRewrite)
+	webCrawler.SetDedupRules(opts.Dedup)
+	webCrawler.SetAllowedSchemes(opts.Schemes)
+	if opts.Transport != nil {
+		webCrawler.SetTransport(opts.Transport)
+	}
+	monitorSystem.TrackURLMaps(webCrawler)
+	monitorSystem.TrackCrawl(webCrawler)
+
+	crawlErr := webCrawler.Start()
+	if crawlErr == nil {
+		webCrawler.Wait()
+	}
+
+	close(shutdownChan)
+	monitorSystem.Wait()
+	downloadManager.Shutdown()
+
+	if crawlErr != nil {
+		return nil, fmt.Errorf("crawl: %w", crawlErr)
+	}
+
+	attempts, success, failed, bytes, _ := downloadManager.GetStats()
+	report := &Report{
+		StartedAt:        startedAt,
+		Elapsed:          time.Since(startedAt),
+		DownloadAttempts: attempts,
+		Downloaded:       success,
+		NotModified:      downloadManager.GetNotModifiedCount(),
+		Failed:           failed,
+		BytesDownloaded:  bytes,
+		Domains:          downloadManager.GetDomainStats(),
+		StatusCodes:      downloadManager.GetStatusDistribution(),
+		ContentTypes:     downloadManager.GetContentTypeDistribution(),
+		DepthCounts:      webCrawler.GetDepthDistribution(),
+		DuplicatePages:   webCrawler.GetDuplicatePageCount(),
+		Timing:           downloadManager.GetTimingStats(),
+		Failures:         downloadManager.GetFailedDownloads(),
+		Panics:           panics.Since(startedAt),
+		VisitedLogPath:   visitedLogPath,
+		DownloadLogPath:  downloadLogPath,
+	}
+	for _, iface := range interfaces {
+		report.Interfaces = append(report.Interfaces, iface.Name)
+	}
+
+	return report, nil
+}
+
+// selectInterfaces resolves interface names to configured interfaces
+func selectInterfaces(names []string) ([]network.NetworkInterface, error) {
+	detected, err := network.DetectNetworkInterfaces()
+	if err != nil {
+		return nil, fmt.Errorf("crawl: detecting network interfaces: %w", err)
+	}
+
+	var selected []int
+	if len(names) == 0 {
+		for i, iface := range detected {
+			if iface.IsActive && iface.IP != "" {
+				selected = append(selected, i)
+			}
+		}
+	} else {
+		for _, name := range names {
+			idx := -1
+			for i, iface := range detected {
+				if iface.Name == name {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				return nil, fmt.Errorf("crawl: network interface %q not found", name)
+			}
+			selected = append(selected, idx)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.New("crawl: no usable network interfaces")
+	}
+
+	interfaces, err := network.ConfigureSelectedInterfaces(detected, selected)
+	if err != nil {
+		return nil, fmt.Errorf("crawl: configuring interfaces: %w", err)
+	}
+	return interfaces, nil
+}
@@ -0,0 +1,107 @@
+package crawl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fixtureSite serves an index linking to two sub-pages and a PDF; one
+// sub-page links to a second PDF. It records the paths requested.
+func fixtureSite(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requested []string
+
+	pages := map[string]string{
+		"/":       `<a href="/a.html">A</a> <a href="/b.html">B</a> <a href="/docs/one.pdf">one</a>`,
+		"/a.html": `<a href="/docs/two.pdf">two</a>`,
+		"/b.html": `no links here`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+
+		if body, ok := pages[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><title>fixture</title></head><body>" + body + "</body></html>"))
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/docs/") {
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4 fixture " + r.URL.Path))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func TestCrawlThroughInjectedTransport(t *testing.T) {
+	srv, requested := fixtureSite(t)
+	outputDir := t.TempDir()
+
+	report, err := Crawl(Options{
+		Seeds:     []string{srv.URL + "/"},
+		OutputDir: outputDir,
+		LogDir:    t.TempDir(),
+		Transport: srv.Client().Transport,
+		// Pages this small take the fast path
+		FastPathDocuments: true,
+	})
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range requested() {
+		seen[p] = true
+	}
+	for _, page := range []string{"/", "/a.html", "/b.html"} {
+		if !seen[page] {
+			t.Errorf("page %s was not visited; requested %v", page, requested())
+		}
+	}
+
+	visited, err := os.ReadFile(report.VisitedLogPath)
+	if err != nil {
+		t.Fatalf("reading visited log: %v", err)
+	}
+	for _, page := range []string{"/a.html", "/b.html"} {
+		if !strings.Contains(string(visited), srv.URL+page) {
+			t.Errorf("visited log lacks %s:\n%s", page, visited)
+		}
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []string
+	for _, e := range entries {
+		saved = append(saved, e.Name())
+	}
+	sort.Strings(saved)
+	if want := []string{"one.pdf", "two.pdf"}; strings.Join(saved, ",") != strings.Join(want, ",") {
+		t.Fatalf("saved files = %v, want %v", saved, want)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "two.pdf"))
+	if err != nil || string(data) != "%PDF-1.4 fixture /docs/two.pdf" {
+		t.Errorf("two.pdf = %q, %v", data, err)
+	}
+	if report.Downloaded != 2 {
+		t.Errorf("report.Downloaded = %d, want 2", report.Downloaded)
+	}
+}
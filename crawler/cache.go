@@ -0,0 +1,39 @@
+package crawler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SetCache keeps colly's response cache in dir across runs instead of the
+// throwaway .colly_cache. Entries older than maxAge are refetched; zero
+// maxAge keeps cached pages forever.
+func (c *CrawlerTwoTier) SetCache(dir string, maxAge time.Duration) {
+	c.collector.CacheDir = dir
+	c.cacheMaxAge = maxAge
+}
+
+// expireCacheEntry deletes u's cache file if it is older than the max age,
+// so colly fetches the page again. The path mirrors colly's own layout:
+// <CacheDir>/<sha1[:2]>/<sha1 of the URL>.
+func (c *CrawlerTwoTier) expireCacheEntry(u *url.URL) {
+	if c.cacheMaxAge <= 0 || c.collector.CacheDir == "" {
+		return
+	}
+
+	sum := sha1.Sum([]byte(u.String()))
+	hash := hex.EncodeToString(sum[:])
+	filename := filepath.Join(c.collector.CacheDir, hash[:2], hash)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return
+	}
+	if time.Since(info.ModTime()) > c.cacheMaxAge {
+		os.Remove(filename)
+	}
+}
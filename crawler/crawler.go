@@ -2,7 +2,6 @@ package crawler
 
 import (
 	"fmt"
-	"log"
 	"net/url"
 	"os"
 	"runtime/debug"
@@ -12,6 +11,8 @@ import (
 	"github.com/gocolly/colly/v2/extensions"
 	"github.com/jeb/url_crawler/config"
 	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
 	"github.com/jeb/url_crawler/utils"
 )
 
@@ -66,7 +67,7 @@ func (c *Crawler) createCollector() *colly.Collector {
 	})
 
 	if err != nil {
-		fmt.Printf("❌ Failed to set crawl limits: %v\n", err)
+		logger.Errorf("❌ Failed to set crawl limits: %v\n", err)
 	}
 
 	cacheDir := ".colly_cache"
@@ -84,7 +85,7 @@ func (c *Crawler) setupCallbacks() {
 				ctx := colly.NewContext()
 				ctx.Put("depth", "0")
 				r.Ctx = ctx
-				fmt.Printf("🚀 [0] Multi-NIC crawl started: %s\n", r.URL)
+				logger.Infof("🚀 [0] Multi-NIC crawl started: %s\n", r.URL)
 			})
 		}
 	})
@@ -98,7 +99,7 @@ func (c *Crawler) setupCallbacks() {
 				fmt.Sscanf(d, "%d", &depth)
 			}
 			if depth <= 1 {
-				fmt.Printf("✅ [%d] Response %d: %s\n", depth, r.StatusCode, r.Request.URL)
+				logger.Infof("✅ [%d] Response %d: %s\n", depth, r.StatusCode, r.Request.URL)
 			}
 		}
 	})
@@ -106,7 +107,7 @@ func (c *Crawler) setupCallbacks() {
 	c.collector.OnError(func(r *colly.Response, err error) {
 		_, _, failed, _, _ := c.downloadManager.GetStats()
 		if failed < 20 {
-			fmt.Printf("❌ Crawl error: %v\n", err)
+			logger.Errorf("❌ Crawl error: %v\n", err)
 		}
 	})
 
@@ -124,22 +125,22 @@ func (c *Crawler) setupCallbacks() {
 				c.panicMutex.Unlock()
 
 				// Log panic with full details
-				log.Printf("🛑 PANIC #%d recovered in OnHTML handler\n", panicNum)
-				log.Printf("   URL: %s\n", e.Request.URL)
-				log.Printf("   Error: %v\n", r)
+				logger.Errorf("🛑 PANIC #%d recovered in OnHTML handler\n", panicNum)
+				logger.Infof("   URL: %s\n", e.Request.URL)
+				logger.Infof("   Error: %v\n", r)
 				if panicNum <= 3 {
 					// Only log stack trace for first few panics to avoid spam
-					log.Printf("   Stack trace:\n%s\n", debug.Stack())
+					logger.Errorf("   Stack trace:\n%s\n", debug.Stack())
 				}
 
 				// Save problematic URL to a separate log
-				go func() {
+				panics.Go("panic log", e.Request.URL.String(), func() {
 					f, err := os.OpenFile("panic_urls.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 					if err == nil {
 						defer f.Close()
 						f.WriteString(fmt.Sprintf("%s\n", e.Request.URL))
 					}
-				}()
+				})
 			}
 		}()
 
@@ -182,7 +183,7 @@ func (c *Crawler) setupCallbacks() {
 				// Try to enqueue the task
 				if !c.downloadManager.EnqueueTask(task) {
 					// Queue full - try persistent enqueue
-					go c.downloadManager.PersistentEnqueue(task)
+					panics.Go("enqueue", task.URL, func() { c.downloadManager.PersistentEnqueue(task) })
 				}
 			}
 		}
@@ -204,14 +205,14 @@ func (c *Crawler) saveVisitedURL(url string) {
 	c.mapMutex.Unlock()
 
 	// Async logging for performance
-	go func() {
+	panics.Go("visited log", url, func() {
 		f, err := os.OpenFile(c.logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			return
 		}
 		defer f.Close()
 		f.WriteString(url + "\n")
-	}()
+	})
 }
 
 // Start begins the crawling process
@@ -225,8 +226,8 @@ func (c *Crawler) Wait() {
 
 	// Report panic statistics
 	if c.panicCount > 0 {
-		log.Printf("\n⚠️  Total panics recovered: %d\n", c.panicCount)
-		log.Printf("   Check panic_urls.txt for problematic pages\n")
+		logger.Summaryf("\n⚠️  Total panics recovered: %d\n", c.panicCount)
+		logger.Summaryf("   Check panic_urls.txt for problematic pages\n")
 	}
 }
 
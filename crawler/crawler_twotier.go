@@ -1,37 +1,123 @@
 package crawler
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"log"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
-	"runtime/debug"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/extensions"
 	"github.com/jeb/url_crawler/config"
 	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/network"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/statsdb"
 	"github.com/jeb/url_crawler/tokenizer"
 	"github.com/jeb/url_crawler/utils"
+	"github.com/jeb/url_crawler/warc"
+	"golang.org/x/time/rate"
 )
 
 // CrawlerTwoTier manages web crawling with two-tier tokenization
 type CrawlerTwoTier struct {
-	collector        *colly.Collector
-	coordinator      *tokenizer.Coordinator
-	visitedURLsMap   map[string]bool
-	mapMutex         *sync.RWMutex
-	firstRequestOnce sync.Once
-	startURL         string
-	logFilePath      string
-	downloadManager  *downloader.Manager
-	panicCount       int
-	panicMutex       sync.Mutex
+	collector         *colly.Collector
+	coordinator       *tokenizer.Coordinator
+	visitedURLsMap    map[string]bool
+	mapMutex          *sync.RWMutex
+	firstRequestOnce  sync.Once
+	startURL          string
+	logFilePath       string
+	downloadManager   *downloader.Manager
+	panicCount        int
+	panicMutex        sync.Mutex
+	docExtensions     []string
+	seeds             []string       // extra start URLs beyond startURL
+	seedPriority      map[string]int // AddSeed priorities by seed URL
+	visits            *visitQueue    // nil unless a seed has a priority
+	maxDepth          int
+	depthCounts       []int64 // pages visited per depth, 0..maxDepth
+	maxPages          int64   // 0 means unlimited
+	pagesRequested    int64
+	warcWriter        *warc.Writer
+	headProbe         *headProbe // nil unless SetHeadProbe(true)
+	interfaceFor      func(docURL string) int
+	statsDB           *statsdb.Recorder
+	cacheMaxAge       time.Duration // 0 means cached pages never expire
+	visitedTSV        bool          // visited log includes depth and timestamp
+	local             *localCrawl   // non-nil when crawling a file:// tree
+	excludePatterns   []*regexp.Regexp
+	scope             Scope
+	truncatedPages    int64
+	maxURLLength      int // 0 means unlimited
+	dedup             DedupRules
+	contentSeen       *contentHashes // nil unless the dedup strategy is DedupContent
+	duplicatePages    int64
+	schemes           []string // link schemes kept, nil means tokenizer.DefaultSchemes
+	followPDFs        bool     // SetFollowPDFLinks
+	schemeRewrite     SchemeRewrite
+	schemeRewrites    int64
+	schemeFallbacks   int64
+	docFallbacks      sync.Map // rewritten document URL → URL as linked
+	docFallbackHooked bool
+	respectRobots     bool         // SetRespectRobots
+	hostBudget        *hostBudget  // nil unless SetPerHostPageBudget
+	streamThreshold   int64        // SetStreamLargePages; 0 when off
+	streamClient      *http.Client // nil unless SetStreamLargePages
+	streamedPages     int64
+	pagesFetched      int64
+	pagesFailed       int64
+	activeRequests    int64                     // See countRequests
+	transport         http.RoundTripper         // Page transport, see useTransport
+	requestTimeout    time.Duration             // Whole-request timeout for pageClient
+	crawlLimiter      *rate.Limiter             // nil unless SetGlobalCrawlRate
+	startedAt         atomic.Pointer[time.Time] // Set by Start
+	noFollowPages     int64
+	limitRule         *colly.LimitRule // nil with a caller-supplied collector
+	longURLs          int64
+	maxLinksPerPage   int // 0 means unlimited
+	paginationBoost   int // SetPaginationPriority; 0 when off
+	paginatedPages    int64
+	linkCappedPages   int64
+	refetchClient     *http.Client // nil unless SetRefetchTruncated(true)
+	frontier          *frontier
+	resumed           bool            // RestoreFrontier was called
+	resumePending     []FrontierEntry // requested by Start when resuming
 }
 
 // NewCrawlerTwoTier creates a new two-tier crawler instance
 func NewCrawlerTwoTier(startURL, logFilePath string, downloadManager *downloader.Manager) *CrawlerTwoTier {
+	c := newCrawlerTwoTier(startURL, logFilePath, downloadManager)
+	c.collector = c.createCollector()
+	c.setupCallbacks()
+
+	return c
+}
+
+// NewCrawlerTwoTierWithCollector creates a two-tier crawler on a collector
+// configured by the caller (proxies, limit rules, storage, debugger...).
+// Only the crawler's callbacks are attached; the collector's settings are
+// left alone, so it should be asynchronous and have a body size limit.
+// SetCache and SetTimeouts do change the collector if called.
+func NewCrawlerTwoTierWithCollector(collector *colly.Collector, startURL, logFilePath string, downloadManager *downloader.Manager) *CrawlerTwoTier {
+	c := newCrawlerTwoTier(startURL, logFilePath, downloadManager)
+	c.collector = collector
+	c.setupCallbacks()
+
+	return c
+}
+
+// newCrawlerTwoTier fills in everything but the collector
+func newCrawlerTwoTier(startURL, logFilePath string, downloadManager *downloader.Manager) *CrawlerTwoTier {
 	c := &CrawlerTwoTier{
 		coordinator:     tokenizer.NewCoordinator(),
 		visitedURLsMap:  make(map[string]bool),
@@ -40,11 +126,17 @@ func NewCrawlerTwoTier(startURL, logFilePath string, downloadManager *downloader
 		logFilePath:     logFilePath,
 		downloadManager: downloadManager,
 		panicCount:      0,
+		docExtensions:   []string{".pdf"},
+		maxDepth:        config.MaxDepth,
+		depthCounts:     make([]int64, config.MaxDepth+1),
+		excludePatterns: compileDefaultExcludes(),
+		frontier:        newFrontier(),
+		seedPriority:    make(map[string]int),
+		maxURLLength:    config.MaxURLLength,
+		requestTimeout:  config.RequestTimeout,
 	}
-
-	c.collector = c.createCollector()
-	c.setupCallbacks()
-
+	c.transport = c.pageTransport(http.DefaultTransport)
+	c.SetMaxLinksPerPage(config.MaxLinksPerPage)
 	return c
 }
 
@@ -54,22 +146,24 @@ func (c *CrawlerTwoTier) createCollector() *colly.Collector {
 		colly.UserAgent(config.UserAgent),
 		colly.Async(true),
 		colly.IgnoreRobotsTxt(),
-		colly.MaxBodySize(5*1024*1024), // 5 MB limit
+		colly.MaxBodySize(config.MaxPageSize),
 	)
 
+	collector.WithTransport(c.transport)
 	extensions.RandomUserAgent(collector)
 	extensions.Referer(collector)
 	collector.SetRequestTimeout(config.RequestTimeout)
 
-	err := collector.Limit(&colly.LimitRule{
+	c.limitRule = &colly.LimitRule{
 		DomainGlob:  "*",
 		Parallelism: config.ConcurrentWorkers,
 		Delay:       config.PoliteDelay,
 		RandomDelay: 5,
-	})
+	}
+	err := collector.Limit(c.limitRule)
 
 	if err != nil {
-		fmt.Printf("❌ Failed to set crawl limits: %v\n", err)
+		logger.Errorf("❌ Failed to set crawl limits: %v\n", err)
 	}
 
 	cacheDir := ".colly_cache"
@@ -81,15 +175,20 @@ func (c *CrawlerTwoTier) createCollector() *colly.Collector {
 
 // setupCallbacks configures TWO-TIER tokenization callbacks
 func (c *CrawlerTwoTier) setupCallbacks() {
-	docExtensions := []string{".pdf"}
-
 	c.collector.OnRequest(func(r *colly.Request) {
+		c.expireCacheEntry(r.URL)
+
+		// Track the page until it is fetched (redirects change r.URL later)
+		if r.Ctx.Get("frontier") == "" {
+			key := frontierKey(r.URL)
+			r.Ctx.Put("frontier", key)
+			c.frontier.add(key, FrontierEntry{URL: r.URL.String()})
+		}
+
 		if r.URL.String() == c.startURL {
 			c.firstRequestOnce.Do(func() {
-				ctx := colly.NewContext()
-				ctx.Put("depth", "0")
-				r.Ctx = ctx
-				fmt.Printf("🚀🚀 [0] TWO-TIER Multi-NIC crawl started: %s\n", r.URL)
+				r.Ctx.Put("depth", "0")
+				logger.Infof("🚀🚀 [0] TWO-TIER Multi-NIC crawl started: %s\n", r.URL)
 			})
 		}
 	})
@@ -100,124 +199,523 @@ func (c *CrawlerTwoTier) setupCallbacks() {
 			if rec := recover(); rec != nil {
 				c.panicMutex.Lock()
 				c.panicCount++
-				panicNum := c.panicCount
 				c.panicMutex.Unlock()
 
-				log.Printf("🛑 PANIC #%d in OnResponse: %v\n", panicNum, rec)
-				if panicNum <= 3 {
-					log.Printf("   Stack:\n%s\n", debug.Stack())
-				}
+				panics.Record("OnResponse", r.Request.URL.String(), rec)
 			}
 		}()
 
+		defer c.markFetched(r)
+
 		currentDepth := 0
 		if d := r.Ctx.Get("depth"); d != "" {
 			fmt.Sscanf(d, "%d", &currentDepth)
 		}
 
-		// COORDINATOR DECISION: Fast or Slow path?
-		decision := c.coordinator.Decide(r.Request.URL, len(r.Body))
+		atomic.AddInt64(&c.pagesFetched, 1)
+		c.downloadManager.RecordPageCrawled(r.Request.URL.String())
+		c.downloadManager.RecordStatus(r.StatusCode)
 
-		if decision == tokenizer.FastPath {
-			// FAST PATH: Lightweight byte scanning
-			result := c.coordinator.ProcessFastPath(r.Body, r.Request.URL)
+		if c.warcWriter != nil {
+			c.archiveResponse(r)
+		}
 
-			// Process extracted URLs
-			for _, urlStr := range result.URLs {
-				c.processDiscoveredURL(urlStr, currentDepth)
+		var contentType string
+		if r.Headers != nil {
+			contentType = r.Headers.Get("Content-Type")
+		}
+		c.downloadManager.RecordContentType(contentType)
+
+		body := c.fullBody(r)
+
+		robots := c.headerRobots(r.Headers)
+		decision, links, docs := c.processPage(r.Request.URL, body, contentType, currentDepth, pagePriority(r.Ctx), c.pageSubtree(r.Ctx), robots)
+		if c.statsDB != nil {
+			c.statsDB.RecordPage(statsdb.PageRecord{
+				URL:          r.Request.URL.String(),
+				Depth:        currentDepth,
+				Status:       r.StatusCode,
+				Size:         len(body),
+				PathDecision: decision.String(),
+				Links:        links,
+				Docs:         docs,
+			})
+		}
+
+		// Periodic stats logging
+		attempts, _, _, _, _ := c.downloadManager.GetStats()
+		if attempts > 0 && attempts%100 == 0 {
+			c.logTwoTierStats(logger.Infof)
+		}
+	})
+
+	c.collector.OnResponseHeaders(c.divertLargePage)
+
+	c.collector.OnError(func(r *colly.Response, err error) {
+		if errors.Is(err, colly.ErrAbortedAfterHeaders) && r.Ctx.Get("stream") != "" {
+			c.streamPage(r)
+			return
+		}
+		c.retryPageAsLinked(r)
+		c.markFetched(r)
+		atomic.AddInt64(&c.pagesFailed, 1)
+		c.downloadManager.RecordCrawlError(r.Request.URL.String())
+		c.downloadManager.RecordStatus(r.StatusCode)
+
+		_, _, failed, _, _ := c.downloadManager.GetStats()
+		if failed < 20 {
+			logger.Errorf("❌ Crawl error: %v\n", err)
+		}
+	})
+}
+
+// processPage routes a fetched page through the fast or slow tokenizer
+// and follows the links and documents it yields, as far as robots (the
+// response's X-Robots-Tag, merged with the page's own meta tag) allows.
+// priority is the page's visit priority, passed on to links from seeds,
+// and tree the subtree it belongs to, passed on to all its links.
+func (c *CrawlerTwoTier) processPage(pageURL *url.URL, body []byte, contentType string, currentDepth, priority int, tree *subtree, robots tokenizer.RobotsDirectives) (decision tokenizer.PathDecision, links, docs int) {
+	// COORDINATOR DECISION: Fast or Slow path?
+	decision = c.coordinator.DecideWithContentType(pageURL, len(body), contentType)
+
+	if decision == tokenizer.SkipPath || c.isDuplicatePage(pageURL, body) {
+		return decision, 0, 0
+	} else if decision == tokenizer.SitemapPath {
+		urls, children := c.coordinator.ProcessSitemap(body, pageURL)
+		followLinks, followDocs := c.robotsAllow(robots)
+
+		for _, urlStr := range urls {
+			if utils.IsDocumentURL(urlStr, c.docExtensions) {
+				if followDocs {
+					c.enqueueDocument(urlStr, currentDepth)
+					tree.found(tokenizer.DocumentInfo{URL: urlStr})
+				}
+				docs++
+			} else if followLinks {
+				c.processDiscoveredURL(urlStr, currentDepth, priority, tree)
 			}
+		}
 
-			// Log first few fast-path results
-			fastCount, _, _ := c.coordinator.GetRoutingStats()
-			if fastCount <= 10 {
-				fmt.Printf("⚡ FAST [%d] %s → %d links in %dμs\n",
-					currentDepth, r.Request.URL, result.LinkCount, result.ProcessingUs)
+		// A sitemap index does not add a level of depth
+		if followLinks {
+			for _, child := range children {
+				c.processDiscoveredURL(child, currentDepth-1, priority, tree)
 			}
+		}
+
+		logger.Infof("🗺️ SITEMAP [%d] %s → %d URLs, %d child sitemaps\n",
+			currentDepth, pageURL, len(urls), len(children))
+
+		return decision, len(urls) + len(children), docs
+	} else if decision == tokenizer.FastPath {
+		// FAST PATH: Lightweight byte scanning
+		result := c.coordinator.ProcessFastPath(body, pageURL, c.docExtensions)
+		if c.respectRobots {
+			robots = robots.Merge(tokenizer.RobotsMeta(body))
+		}
+		c.followFastPath(result, currentDepth, priority, tree, robots)
+		c.noteLinkCap(pageURL.String(), result.Capped)
+
+		// Log first few fast-path results
+		fastCount, _, _ := c.coordinator.GetRoutingStats()
+		if fastCount <= 10 {
+			logger.Infof("⚡ FAST [%d] %s → %d links in %dμs\n",
+				currentDepth, pageURL, result.LinkCount, result.ProcessingUs)
+		}
 
-		} else {
-			// SLOW PATH: Full DOM parsing + document detection
-			result := c.coordinator.ProcessSlowPath(r.Body, r.Request.URL, docExtensions)
+		return decision, result.LinkCount, len(result.Documents)
+	} else {
+		// SLOW PATH: Full DOM parsing + document detection
+		result := c.coordinator.ProcessSlowPath(body, pageURL, c.docExtensions)
+		followLinks, followDocs := c.robotsAllow(robots.Merge(result.PageMetadata.Robots))
+		c.noteLinkCap(pageURL.String(), result.Capped)
 
-			// Process extracted URLs
+		// Process extracted URLs, the next page of a listing first
+		if followLinks {
+			c.followPagination(result.PageMetadata, currentDepth, priority, tree)
 			for _, urlStr := range result.URLs {
-				c.processDiscoveredURL(urlStr, currentDepth)
+				c.processDiscoveredURL(urlStr, currentDepth, priority, tree)
 			}
+		}
 
-			// Process detected documents
+		// Process detected documents
+		if followDocs {
 			for _, doc := range result.Documents {
-				if !c.downloadManager.IsDownloadedOrPending(doc.URL) {
-					task := downloader.DownloadTask{
-						URL:      doc.URL,
-						Depth:    currentDepth,
-						Retry:    0,
-						Priority: false,
-					}
-
-					if !c.downloadManager.EnqueueTask(task) {
-						go c.downloadManager.PersistentEnqueue(task)
-					}
-				}
+				c.enqueueDocument(doc.URL, currentDepth)
+				tree.found(doc)
 			}
+		}
 
-			// Log slow-path results
-			_, slowCount, _ := c.coordinator.GetRoutingStats()
-			if slowCount <= 10 {
-				fmt.Printf("🐢 SLOW [%d] %s → %d links, %d docs in %dμs\n",
-					currentDepth, r.Request.URL, result.LinkCount, result.DocCount, result.ProcessingUs)
+		// Ask the server about links the extension check can't judge
+		if c.headProbe != nil && followLinks {
+			for _, urlStr := range result.URLs {
+				c.headProbe.consider(urlStr, currentDepth)
 			}
 		}
 
-		// Periodic stats logging
-		attempts, _, _, _, _ := c.downloadManager.GetStats()
-		if attempts > 0 && attempts%100 == 0 {
-			c.logTwoTierStats()
+		// Log slow-path results
+		_, slowCount, _ := c.coordinator.GetRoutingStats()
+		if slowCount <= 10 {
+			logger.Infof("🐢 SLOW [%d] %s → %d links, %d docs in %dμs\n",
+				currentDepth, pageURL, result.LinkCount, result.DocCount, result.ProcessingUs)
 		}
-	})
 
-	c.collector.OnError(func(r *colly.Response, err error) {
-		_, _, failed, _, _ := c.downloadManager.GetStats()
-		if failed < 20 {
-			fmt.Printf("❌ Crawl error: %v\n", err)
+		return decision, result.LinkCount, result.DocCount
+	}
+}
+
+// followFastPath follows the links and documents of a fast-path page
+func (c *CrawlerTwoTier) followFastPath(result *tokenizer.FastPathResult, currentDepth, priority int, tree *subtree, robots tokenizer.RobotsDirectives) {
+	followLinks, followDocs := c.robotsAllow(robots)
+
+	// Process extracted URLs
+	if followLinks {
+		for _, urlStr := range result.URLs {
+			c.processDiscoveredURL(urlStr, currentDepth, priority, tree)
 		}
-	})
+	}
+
+	// Documents linked from fast-path pages (when enabled)
+	if followDocs {
+		for _, docURL := range result.Documents {
+			c.enqueueDocument(docURL, currentDepth)
+			tree.found(tokenizer.DocumentInfo{URL: docURL})
+		}
+	}
 }
 
-// processDiscoveredURL handles a newly discovered URL
-func (c *CrawlerTwoTier) processDiscoveredURL(urlStr string, currentDepth int) {
-	parsed, err := url.Parse(urlStr)
-	if err != nil || parsed.Host == "" {
+// enqueueDocument hands a detected document to the download manager
+func (c *CrawlerTwoTier) enqueueDocument(docURL string, depth int) {
+	docURL = utils.ASCIIURL(docURL)
+	if u, err := url.Parse(docURL); err == nil && c.rewriteScheme(u) {
+		c.docFallbacks.LoadOrStore(u.String(), docURL)
+		docURL = u.String()
+	}
+	c.downloadManager.RecordDocumentFound(docURL)
+	c.queueDownload(docURL, depth)
+}
+
+// queueDownload hands docURL to the download manager unless it is already
+// downloaded or pending
+func (c *CrawlerTwoTier) queueDownload(docURL string, depth int) {
+	if c.downloadManager.IsDownloadedOrPending(docURL) {
 		return
 	}
 
-	cleanURL := utils.NormalizeParsedURL(parsed)
+	task := downloader.DownloadTask{
+		URL:      docURL,
+		Depth:    depth,
+		Retry:    0,
+		Priority: false,
+	}
+	if c.interfaceFor != nil {
+		if id := c.interfaceFor(docURL); id != downloader.AutoInterface {
+			task.Pinned, task.InterfaceID = true, id
+		}
+	}
 
-	if currentDepth < config.MaxDepth {
-		if !c.hasVisited(cleanURL) {
-			c.saveVisitedURL(cleanURL)
+	if !c.downloadManager.EnqueueTask(task) {
+		panics.Go("enqueue", docURL, func() { c.downloadManager.PersistentEnqueue(task) })
+	}
+}
+
+// SetInterfaceAffinity routes documents by policy: fn returns the index of
+// the network interface to download docURL on, or downloader.AutoInterface
+func (c *CrawlerTwoTier) SetInterfaceAffinity(fn func(docURL string) int) {
+	c.interfaceFor = fn
+}
+
+// SetMaxDepth overrides config.MaxDepth for this crawler
+func (c *CrawlerTwoTier) SetMaxDepth(depth int) {
+	c.maxDepth = depth
+	c.depthCounts = make([]int64, max(depth, 0)+1)
+}
+
+// SetMaxURLLength drops discovered URLs longer than n bytes, overriding
+// config.MaxURLLength. Zero means unlimited.
+func (c *CrawlerTwoTier) SetMaxURLLength(n int) {
+	c.maxURLLength = n
+}
 
-			newCtx := colly.NewContext()
-			newCtx.Put("depth", fmt.Sprintf("%d", currentDepth+1))
-			c.collector.Request("GET", urlStr, nil, newCtx, nil)
+// GetSkippedLongURLs returns how many discovered URLs were dropped for length
+func (c *CrawlerTwoTier) GetSkippedLongURLs() int64 {
+	return atomic.LoadInt64(&c.longURLs)
+}
+
+// SetMaxPages caps the number of pages requested, seeds included.
+// Zero means unlimited.
+func (c *CrawlerTwoTier) SetMaxPages(pages int) {
+	c.maxPages = int64(pages)
+}
+
+// reservePage claims one page from the page budget
+func (c *CrawlerTwoTier) reservePage() bool {
+	if c.maxPages <= 0 {
+		return true
+	}
+	return atomic.AddInt64(&c.pagesRequested, 1) <= c.maxPages
+}
+
+// unreservePage gives back a page claimed by reservePage
+func (c *CrawlerTwoTier) unreservePage() {
+	if c.maxPages > 0 {
+		atomic.AddInt64(&c.pagesRequested, -1)
+	}
+}
+
+// GetPageSizeHistogram returns the sizes of routed pages, per path
+func (c *CrawlerTwoTier) GetPageSizeHistogram() []tokenizer.SizeBucket {
+	return c.coordinator.GetSizeHistogram()
+}
+
+// EnableDecisionTrace records why pages were routed fast or slow.
+// See tokenizer.Coordinator.EnableDecisionTrace.
+func (c *CrawlerTwoTier) EnableDecisionTrace(size int, fn tokenizer.DecisionTraceFunc) {
+	c.coordinator.EnableDecisionTrace(size, fn)
+}
+
+// DecisionTrace returns the recent routing decisions, oldest first
+func (c *CrawlerTwoTier) DecisionTrace() []tokenizer.DecisionRecord {
+	return c.coordinator.DecisionTrace()
+}
+
+// SetAllowedSchemes sets which schemes discovered links may have (default:
+// tokenizer.DefaultSchemes); data:, blob:, tel: and the like are dropped
+// during tokenization. Local crawls also allow file.
+func (c *CrawlerTwoTier) SetAllowedSchemes(schemes []string) {
+	c.schemes = schemes
+	c.coordinator.SetAllowedSchemes(schemes)
+}
+
+// SetContextWindow configures how much text around document links is captured
+func (c *CrawlerTwoTier) SetContextWindow(length int, scope tokenizer.ContextScope) {
+	c.coordinator.SetContextWindow(length, scope)
+}
+
+// SetSizeRules routes pages by body size bands instead of the fast and
+// slow thresholds. See tokenizer.Coordinator.SetSizeRules.
+func (c *CrawlerTwoTier) SetSizeRules(rules []tokenizer.SizeRule) {
+	c.coordinator.SetSizeRules(rules)
+}
+
+// SetDocumentExtensions sets which file extensions are downloaded as
+// documents, e.g. ".pdf", "docx" (default: .pdf). Call before SetHeadProbe.
+func (c *CrawlerTwoTier) SetDocumentExtensions(exts []string) {
+	var normalized []string
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
 		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	if len(normalized) == 0 {
+		normalized = []string{".pdf"}
 	}
+	c.docExtensions = normalized
+}
+
+// SetFastPathDocuments lets fast-path pages enqueue linked documents too.
+// Off by default, so HTML-only crawls are unaffected.
+func (c *CrawlerTwoTier) SetFastPathDocuments(enabled bool) {
+	c.coordinator.SetFastPathDocDetection(enabled)
 }
 
-// logTwoTierStats prints two-tier performance metrics
-func (c *CrawlerTwoTier) logTwoTierStats() {
+// SetCrawlLimits sets how many pages are fetched at once and the delay
+// between requests to a host. It has no effect on a caller-supplied
+// collector. Call before Start.
+func (c *CrawlerTwoTier) SetCrawlLimits(parallelism int, delay time.Duration) {
+	if c.limitRule == nil {
+		return
+	}
+	c.limitRule.Parallelism = max(parallelism, 1)
+	c.limitRule.Delay = delay
+	if err := c.limitRule.Init(); err != nil {
+		logger.Errorf("❌ Failed to set crawl limits: %v\n", err)
+	}
+}
+
+// SetTimeouts applies t to page requests, in place of the config defaults
+func (c *CrawlerTwoTier) SetTimeouts(t network.Timeouts) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   t.Connect,
+		KeepAlive: config.KeepAliveTimeout,
+	}).DialContext
+	transport.TLSHandshakeTimeout = t.TLSHandshake
+	transport.ResponseHeaderTimeout = t.ResponseHeader
+
+	c.useTransport(transport)
+	c.collector.SetRequestTimeout(t.Request)
+
+	c.requestTimeout = t.Request
+	for _, client := range []*http.Client{c.streamClient, c.refetchClient} {
+		if client != nil {
+			client.Timeout = t.Request
+		}
+	}
+}
+
+// SetTransport sends page requests through rt, e.g. a test or recording
+// transport. It replaces any transport set by SetTimeouts.
+func (c *CrawlerTwoTier) SetTransport(rt http.RoundTripper) {
+	c.useTransport(rt)
+}
+
+// SetScanInlineJSON also looks for links in inline <script> JSON/JS.
+// See tokenizer.Coordinator.SetScanInlineJSON.
+func (c *CrawlerTwoTier) SetScanInlineJSON(enabled bool) {
+	c.coordinator.SetScanInlineJSON(enabled)
+}
+
+// SetStatsDB logs every processed page to r
+func (c *CrawlerTwoTier) SetStatsDB(r *statsdb.Recorder) {
+	c.statsDB = r
+}
+
+// SetWARCWriter archives every crawled page response to w
+func (c *CrawlerTwoTier) SetWARCWriter(w *warc.Writer) {
+	c.warcWriter = w
+}
+
+// archiveResponse writes a crawled page to the WARC writer
+func (c *CrawlerTwoTier) archiveResponse(r *colly.Response) {
+	var requestHeader http.Header
+	if r.Request.Headers != nil {
+		requestHeader = *r.Request.Headers
+	}
+	var responseHeader http.Header
+	if r.Headers != nil {
+		// colly hands us the decoded body, so the original encoding no longer applies
+		responseHeader = r.Headers.Clone()
+		responseHeader.Del("Content-Encoding")
+	}
+
+	err := c.warcWriter.WriteExchange(warc.Exchange{
+		URL:            r.Request.URL.String(),
+		Method:         r.Request.Method,
+		RequestHeader:  requestHeader,
+		StatusCode:     r.StatusCode,
+		ResponseHeader: responseHeader,
+		Body:           bytes.NewReader(r.Body),
+		BodyLength:     int64(len(r.Body)),
+	})
+	if err != nil {
+		logger.Errorf("❌ WARC: failed to archive %s: %v\n", r.Request.URL, err)
+	}
+}
+
+// processDiscoveredURL handles a newly discovered URL. A seed's links
+// inherit its priority; links further out get the default. tree, if not
+// nil, is the subtree the linking page belongs to.
+func (c *CrawlerTwoTier) processDiscoveredURL(urlStr string, currentDepth, priority int, tree *subtree) {
+	key, entry, ok := c.linkEntry(urlStr, currentDepth+1)
+	if !ok {
+		return
+	}
+	if currentDepth > 0 {
+		priority = 0
+	}
+	entry.Priority, entry.tree = priority, tree
+	c.schedule(key, entry)
+}
+
+// linkEntry checks a discovered link against the crawl's limits and, if
+// it is new and may be crawled at depth, marks it visited and returns the
+// frontier entry to schedule it with. Local crawls take the link from here.
+func (c *CrawlerTwoTier) linkEntry(urlStr string, depth int) (string, FrontierEntry, bool) {
+	// Ever-growing URLs (session tokens appended per hop) are a crawler trap
+	if c.maxURLLength > 0 && len(urlStr) > c.maxURLLength {
+		atomic.AddInt64(&c.longURLs, 1)
+		return "", FrontierEntry{}, false
+	}
+	if c.isExcluded(urlStr) {
+		return "", FrontierEntry{}, false
+	}
+
+	if c.local != nil {
+		c.local.discover(urlStr, depth-1)
+		return "", FrontierEntry{}, false
+	}
+
+	parsed, err := url.Parse(urlStr)
+	if err != nil || parsed.Host == "" {
+		return "", FrontierEntry{}, false
+	}
+	// One spelling per IDN host, for the visited set, host budgets and dialing
+	if utils.SetASCIIHost(parsed) {
+		urlStr = parsed.String()
+	}
+	linked := ""
+	if c.rewriteScheme(parsed) {
+		linked, urlStr = urlStr, parsed.String()
+	}
+	if !c.inScope(parsed) {
+		return "", FrontierEntry{}, false
+	}
+
+	cleanURL := c.visitKey(parsed)
+	if depth > c.maxDepth {
+		return "", FrontierEntry{}, false
+	}
+	if _, ok := c.claimVisit(cleanURL, strings.ToLower(parsed.Hostname())); !ok {
+		return "", FrontierEntry{}, false
+	}
+	c.recordVisit(cleanURL, depth)
+	return cleanURL, FrontierEntry{URL: urlStr, Depth: depth, Fallback: linked}, true
+}
+
+// logTwoTierStats prints two-tier performance metrics through logf
+func (c *CrawlerTwoTier) logTwoTierStats(logf func(format string, args ...any)) {
 	_, _, fastPercent := c.coordinator.GetRoutingStats()
 	fastPages, fastAvgUs, fastLinks := c.coordinator.GetFastPathStats()
 	slowPages, slowAvgUs, _, slowDocs := c.coordinator.GetSlowPathStats()
 
-	fmt.Printf("\n╔══════════════════════════════════════════════════════════╗\n")
-	fmt.Printf("║         TWO-TIER TOKENIZER PERFORMANCE STATS           ║\n")
-	fmt.Printf("╠══════════════════════════════════════════════════════════╣\n")
-	fmt.Printf("║ FAST PATH:  %6d pages | Avg: %4dμs | Links: %7d ║\n",
+	logf("\n╔══════════════════════════════════════════════════════════╗\n")
+	logf("║         TWO-TIER TOKENIZER PERFORMANCE STATS           ║\n")
+	logf("╠══════════════════════════════════════════════════════════╣\n")
+	logf("║ FAST PATH:  %6d pages | Avg: %4dμs | Links: %7d ║\n",
 		fastPages, fastAvgUs, fastLinks)
-	fmt.Printf("║ SLOW PATH:  %6d pages | Avg: %4dμs | Docs:  %7d ║\n",
+	logf("║ SLOW PATH:  %6d pages | Avg: %4dμs | Docs:  %7d ║\n",
 		slowPages, slowAvgUs, slowDocs)
-	fmt.Printf("║ ROUTING:    %5.1f%% fast | %5.1f%% slow              ║\n",
+	logf("║ ROUTING:    %5.1f%% fast | %5.1f%% slow              ║\n",
 		fastPercent, 100.0-fastPercent)
-	fmt.Printf("╚══════════════════════════════════════════════════════════╝\n\n")
+	logf("╚══════════════════════════════════════════════════════════╝\n\n")
+}
+
+// GetDepthDistribution returns how many URLs were visited at each depth,
+// indexed from 0 (the seeds) to the maximum depth
+func (c *CrawlerTwoTier) GetDepthDistribution() []int64 {
+	counts := make([]int64, len(c.depthCounts))
+	for i := range c.depthCounts {
+		counts[i] = atomic.LoadInt64(&c.depthCounts[i])
+	}
+	return counts
+}
+
+// logDepthDistribution prints the visited-URL histogram by depth through logf
+func (c *CrawlerTwoTier) logDepthDistribution(logf func(format string, args ...any)) {
+	counts := c.GetDepthDistribution()
+
+	deepest, peak := -1, int64(0)
+	for depth, n := range counts {
+		if n > 0 {
+			deepest = depth
+		}
+		peak = max(peak, n)
+	}
+	if deepest < 0 {
+		return
+	}
+
+	logf("📏 Visited URLs by depth:\n")
+	for depth := 0; depth <= deepest; depth++ {
+		bar := int(counts[depth] * 40 / peak)
+		logf("   %2d │ %-40s %d\n", depth, strings.Repeat("█", bar), counts[depth])
+	}
 }
 
 // hasVisited checks if URL was visited
@@ -228,36 +726,211 @@ func (c *CrawlerTwoTier) hasVisited(url string) bool {
 	return exists
 }
 
-// saveVisitedURL marks URL as visited
-func (c *CrawlerTwoTier) saveVisitedURL(url string) {
+// HasVisited reports whether rawURL is in the visited set: it was
+// scheduled from a link, or is a local file already read. rawURL is
+// normalized the way links are, so any variant of a visited URL matches.
+func (c *CrawlerTwoTier) HasVisited(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme == "file" {
+		// Local pages keep their path's case; see localCrawl.visit
+		return c.hasVisited((&url.URL{Scheme: "file", Path: parsed.Path}).String())
+	}
+	return c.hasVisited(c.visitKey(parsed))
+}
+
+// VisitedCount returns the size of the visited set
+func (c *CrawlerTwoTier) VisitedCount() int {
+	c.mapMutex.RLock()
+	defer c.mapMutex.RUnlock()
+	return len(c.visitedURLsMap)
+}
+
+// VisitedUnder returns how many visited URLs start with prefix, e.g.
+// "https://example.com/docs/". Case is ignored, as web URLs are
+// recorded in lower case. It walks the whole set.
+func (c *CrawlerTwoTier) VisitedUnder(prefix string) int {
+	c.mapMutex.RLock()
+	defer c.mapMutex.RUnlock()
+	n := 0
+	for u := range c.visitedURLsMap {
+		if len(u) >= len(prefix) && strings.EqualFold(u[:len(prefix)], prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// visitCount returns how many URLs have been marked visited
+func (c *CrawlerTwoTier) visitCount() int64 {
+	var n int64
+	for i := range c.depthCounts {
+		n += atomic.LoadInt64(&c.depthCounts[i])
+	}
+	return n
+}
+
+// URLMapSizes estimates the memory held by the visited URL map
+func (c *CrawlerTwoTier) URLMapSizes() []utils.MapSize {
+	c.mapMutex.RLock()
+	defer c.mapMutex.RUnlock()
+	return []utils.MapSize{utils.EstimateMapSize("visited", c.visitedURLsMap)}
+}
+
+// claimVisit marks url visited and takes a page from the crawl's and then
+// host's budgets, all under mapMutex, so two links to one page can't both
+// claim it. seen reports that url was already visited. A page its host
+// refuses gives its crawl page back. host is empty for local pages, which
+// have no host budget.
+func (c *CrawlerTwoTier) claimVisit(url, host string) (seen, ok bool) {
 	c.mapMutex.Lock()
+	defer c.mapMutex.Unlock()
+
+	if c.visitedURLsMap[url] {
+		return true, false
+	}
+	if !c.reservePage() {
+		return false, false
+	}
+	if host != "" && !c.reserveHostPage(host) {
+		c.unreservePage()
+		return false, false
+	}
 	c.visitedURLsMap[url] = true
-	c.mapMutex.Unlock()
+	return false, true
+}
+
+// recordVisit counts a URL just marked visited at depth and appends it to
+// the visited log
+func (c *CrawlerTwoTier) recordVisit(url string, depth int) {
+	atomic.AddInt64(&c.depthCounts[min(max(depth, 0), len(c.depthCounts)-1)], 1)
+
+	line := url + "\n"
+	if c.visitedTSV {
+		line = fmt.Sprintf("%s\t%d\t%s\n", url, depth, time.Now().Format(time.RFC3339))
+	}
 
-	go func() {
+	panics.Go("visited log", url, func() {
 		f, err := os.OpenFile(c.logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			return
 		}
 		defer f.Close()
-		f.WriteString(url + "\n")
-	}()
+		f.WriteString(line)
+	})
+}
+
+// SetVisitedLogTSV writes the visited log as url<TAB>depth<TAB>RFC 3339
+// timestamp instead of bare URLs
+func (c *CrawlerTwoTier) SetVisitedLogTSV(enabled bool) {
+	c.visitedTSV = enabled
 }
 
-// Start begins crawling
+// Start begins crawling. file:// start URLs are walked locally
+// instead of being fetched by colly.
 func (c *CrawlerTwoTier) Start() error {
-	return c.collector.Visit(c.startURL)
+	now := time.Now()
+	c.startedAt.Store(&now)
+
+	if strings.HasPrefix(c.startURL, "file://") {
+		if len(c.seeds) > 0 {
+			return fmt.Errorf("extra seeds are not supported for file:// crawls")
+		}
+		return c.startLocal()
+	}
+
+	if c.hasSeedPriorities() || c.paginationBoost > 0 {
+		c.visits = newVisitQueue(c.visitSlots())
+	}
+
+	// Seeds still unfetched at the checkpoint are part of the frontier
+	if c.resumed {
+		c.requestPending()
+		return nil
+	}
+
+	for _, seed := range append([]string{c.startURL}, c.seeds...) {
+		if !c.reservePage() {
+			break
+		}
+		if !c.reserveHostPage(utils.HostOf(seed)) {
+			c.unreservePage()
+			continue
+		}
+		if c.visits != nil {
+			if parsed, err := url.Parse(seed); err == nil {
+				c.queueVisit(frontierKey(parsed), FrontierEntry{URL: seed, Priority: c.seedPriority[seed]})
+			}
+			continue
+		}
+		if err := c.collector.Visit(seed); err != nil {
+			return fmt.Errorf("visiting %s: %w", seed, err)
+		}
+	}
+	if c.visits != nil {
+		c.dispatchVisits()
+	}
+	return nil
 }
 
 // Wait waits for completion
 func (c *CrawlerTwoTier) Wait() {
+	if c.local != nil {
+		c.local.wg.Wait()
+	}
 	c.collector.Wait()
+	for c.followPDFs {
+		// Downloaded PDFs can lead to more pages; done once they don't
+		visits := c.visitCount()
+		c.downloadManager.WaitIdle()
+		if c.visitCount() == visits {
+			break
+		}
+		c.collector.Wait()
+	}
+	if c.headProbe != nil {
+		c.headProbe.stop()
+	}
 
 	// Final stats
-	c.logTwoTierStats()
+	c.logTwoTierStats(logger.Summaryf)
+	c.logDepthDistribution(logger.Summaryf)
+	if noFollow := c.GetNoFollowCount(); noFollow > 0 {
+		logger.Summaryf("🤖 %d pages' links not followed (robots nofollow)\n", noFollow)
+	}
+	if skipped := c.GetHostBudgetSkips(); skipped > 0 {
+		logger.Summaryf("🎫 %d URLs skipped: %d hosts used their %d-page budget\n",
+			skipped, c.hostBudget.exhausted(), c.hostBudget.limit)
+	}
+	if duplicates := c.GetDuplicatePageCount(); duplicates > 0 {
+		logger.Summaryf("🪞 %d pages' links not followed as duplicates (%s dedup)\n", duplicates, c.dedup.Strategy)
+	}
+	if long := c.GetSkippedLongURLs(); long > 0 {
+		logger.Summaryf("✂️ %d URLs skipped for exceeding %d characters\n", long, c.maxURLLength)
+	}
+	if rewritten, fellBack := c.GetSchemeRewriteStats(); rewritten > 0 {
+		logger.Summaryf("🔒 %d links had their scheme rewritten, %d fetched as linked after failing\n", rewritten, fellBack)
+	}
+	if paginated := c.GetPaginationCount(); paginated > 0 {
+		logger.Summaryf("📑 %d next pages of paginated listings visited ahead of other links\n", paginated)
+	}
+	if capped := c.GetLinkCappedCount(); capped > 0 {
+		logger.Summaryf("✂️ %d pages had links beyond the first %d dropped\n", capped, c.maxLinksPerPage)
+	}
+	if streamed := c.GetStreamedCount(); streamed > 0 {
+		logger.Summaryf("🌊 %d large pages streamed through the fast path\n", streamed)
+	}
+	if truncated := c.GetTruncatedCount(); truncated > 0 {
+		logger.Summaryf("✂️ %d pages were truncated at %s\n", truncated, utils.FormatBytes(int64(c.collector.MaxBodySize)))
+	}
+	if advice := c.coordinator.SizeRecommendation(); advice != "" {
+		logger.Summaryf("📐 Page sizes: %s\n", advice)
+	}
 
 	if c.panicCount > 0 {
-		log.Printf("\n⚠️  Total panics recovered: %d\n", c.panicCount)
+		logger.Summaryf("\n⚠️  Total panics recovered: %d\n", c.panicCount)
 	}
 }
 
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeb/url_crawler/network"
+	"golang.org/x/time/rate"
+)
+
+// SetGlobalCrawlRate caps page requests to rps per second across all
+// hosts, on top of the per-host delay; zero or negative removes the cap.
+// Like SetCrawlLimits it only applies to the crawler's own transport, so a
+// caller-supplied collector is unaffected unless SetTimeouts or
+// SetTransport is used. Call before Start.
+func (c *CrawlerTwoTier) SetGlobalCrawlRate(rps float64) {
+	if rps <= 0 {
+		c.crawlLimiter = nil
+		return
+	}
+	c.crawlLimiter = rate.NewLimiter(rate.Limit(rps), 1)
+}
+
+// pageTransport wraps rt as the collector's transport: throttled by
+// SetGlobalCrawlRate, counted for GetCrawlStats and held to the in-flight cap
+func (c *CrawlerTwoTier) pageTransport(rt http.RoundTripper) http.RoundTripper {
+	return throttledTransport{next: c.countRequests(network.LimitInflight(rt)), c: c}
+}
+
+// useTransport sends page requests through rt, wrapped by pageTransport:
+// the collector's, and those pageClient makes for the crawler itself
+func (c *CrawlerTwoTier) useTransport(rt http.RoundTripper) {
+	c.transport = c.pageTransport(rt)
+	c.collector.WithTransport(c.transport)
+}
+
+// pageClient returns a client for the pages the crawler fetches beside
+// the collector (streamed, refetched, HEAD probed). It uses the page
+// transport current at each request, so they are throttled and counted
+// like the collector's and go through SetTransport's or SetTimeouts'.
+func (c *CrawlerTwoTier) pageClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: sharedTransport{c}}
+}
+
+// sharedTransport sends requests through the crawler's page transport
+type sharedTransport struct {
+	c *CrawlerTwoTier
+}
+
+func (t sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.c.transport.RoundTrip(req)
+}
+
+// throttledTransport waits for the crawler's global rate limiter, if any,
+// before each request
+type throttledTransport struct {
+	next http.RoundTripper
+	c    *CrawlerTwoTier
+}
+
+func (t throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if limiter := t.c.crawlLimiter; limiter != nil {
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return t.next.RoundTrip(req)
+}
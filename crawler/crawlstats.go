@@ -0,0 +1,80 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// CrawlStats describes the page-fetching side of the crawl, the
+// counterpart to the download manager's statistics
+type CrawlStats struct {
+	Fetched     int64         // Pages answered, streamed ones included
+	Failed      int64         // Page requests that ended in an error
+	Active      int64         // Page requests on the wire, body included; 0 with a caller-supplied collector
+	Queued      int64         // Pages scheduled and waiting for a request slot or their callbacks
+	Parallelism int           // Concurrent page requests allowed; 0 with a caller-supplied collector
+	Elapsed     time.Duration // Since Start
+}
+
+// Rate returns the average pages fetched per second
+func (s CrawlStats) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Fetched) / s.Elapsed.Seconds()
+}
+
+// GetCrawlStats returns the crawl-side counters
+func (c *CrawlerTwoTier) GetCrawlStats() CrawlStats {
+	s := CrawlStats{
+		Fetched: atomic.LoadInt64(&c.pagesFetched),
+		Failed:  atomic.LoadInt64(&c.pagesFailed),
+		Active:  atomic.LoadInt64(&c.activeRequests),
+	}
+	s.Queued = max(int64(c.frontier.size())-s.Active, 0)
+	if c.limitRule != nil {
+		s.Parallelism = c.limitRule.Parallelism
+	}
+	if started := c.startedAt.Load(); started != nil {
+		s.Elapsed = time.Since(*started)
+	}
+	return s
+}
+
+// countRequests wraps the collector's transport to count page requests in
+// flight, from sending until the body is closed
+func (c *CrawlerTwoTier) countRequests(rt http.RoundTripper) http.RoundTripper {
+	return countingTransport{next: rt, active: &c.activeRequests}
+}
+
+type countingTransport struct {
+	next   http.RoundTripper
+	active *int64
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(t.active, 1)
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		atomic.AddInt64(t.active, -1)
+		return nil, err
+	}
+	resp.Body = &countedBody{ReadCloser: resp.Body, active: t.active}
+	return resp, nil
+}
+
+// countedBody ends its request's count when closed
+type countedBody struct {
+	io.ReadCloser
+	active *int64
+	closed atomic.Bool
+}
+
+func (b *countedBody) Close() error {
+	if b.closed.CompareAndSwap(false, true) {
+		atomic.AddInt64(b.active, -1)
+	}
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,173 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/tokenizer"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// DedupRules collapse URL variants that usually serve the same page, so
+// /dir, /dir/ and /dir/index.html are visited once. They only affect
+// duplicate detection; the URL as linked is still the one requested.
+type DedupRules struct {
+	TrailingSlash bool     // Treat /dir and /dir/ as the same page
+	DefaultDocs   []string // File names equivalent to their directory, e.g. index.html
+	Strategy      DedupStrategy
+}
+
+// DedupStrategy chooses what else, besides its URL, makes a fetched page
+// a duplicate. A page is never fetched twice under one URL; a duplicate
+// is fetched once under its own URL, but its links are not followed.
+// Pages streamed by SetStreamLargePages are only deduplicated by URL.
+type DedupStrategy int
+
+const (
+	DedupURL       DedupStrategy = iota // Only the URL counts (default)
+	DedupContent                        // Pages with the same body, on any host, are one page
+	DedupCanonical                      // Pages naming the same rel=canonical URL are one page
+)
+
+// ParseDedupStrategy converts "url", "content" or "canonical" to a
+// DedupStrategy
+func ParseDedupStrategy(s string) (DedupStrategy, error) {
+	switch strings.ToLower(s) {
+	case "", "url":
+		return DedupURL, nil
+	case "content":
+		return DedupContent, nil
+	case "canonical":
+		return DedupCanonical, nil
+	default:
+		return DedupURL, fmt.Errorf("unknown dedup strategy %q (use url, content or canonical)", s)
+	}
+}
+
+func (s DedupStrategy) String() string {
+	switch s {
+	case DedupContent:
+		return "content"
+	case DedupCanonical:
+		return "canonical"
+	default:
+		return "url"
+	}
+}
+
+// contentHashes remembers the bodies of fetched pages for DedupContent
+type contentHashes struct {
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]struct{}
+}
+
+// add records body, reporting whether it is new
+func (h *contentHashes) add(body []byte) bool {
+	sum := sha256.Sum256(body)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.seen[sum]; ok {
+		return false
+	}
+	h.seen[sum] = struct{}{}
+	return true
+}
+
+// DefaultDocuments are the directory index names most servers use
+var DefaultDocuments = []string{
+	"index.html", "index.htm", "index.php",
+	"default.aspx", "default.asp", "default.htm",
+}
+
+// SetDedupRules sets how URL variants are collapsed for duplicate
+// detection. The zero value keeps every variant distinct. Call before Start.
+func (c *CrawlerTwoTier) SetDedupRules(rules DedupRules) {
+	c.dedup = rules
+	if rules.Strategy == DedupContent {
+		c.contentSeen = &contentHashes{seen: make(map[[sha256.Size]byte]struct{})}
+	}
+}
+
+// GetDuplicatePageCount returns how many fetched pages the dedup strategy
+// found to be duplicates, so their links were not followed
+func (c *CrawlerTwoTier) GetDuplicatePageCount() int64 {
+	return atomic.LoadInt64(&c.duplicatePages)
+}
+
+// isDuplicatePage reports whether the page fetched from pageURL is a
+// duplicate under the dedup strategy. It records the page, so the first
+// of a set of duplicates is not one.
+func (c *CrawlerTwoTier) isDuplicatePage(pageURL *url.URL, body []byte) bool {
+	var original string
+	switch c.dedup.Strategy {
+	case DedupContent:
+		if c.contentSeen.add(body) {
+			return false
+		}
+		original = "same content as a page already crawled"
+	case DedupCanonical:
+		href := tokenizer.CanonicalLink(body)
+		if href == "" {
+			return false
+		}
+		canonical, err := pageURL.Parse(href)
+		if err != nil || canonical.Host == "" {
+			return false
+		}
+		key := c.visitKey(canonical)
+		if key == c.visitKey(pageURL) || c.claimVisitKey(key) {
+			return false
+		}
+		original = "canonical " + canonical.String() + " already crawled"
+	default:
+		return false
+	}
+
+	if atomic.AddInt64(&c.duplicatePages, 1) <= 10 {
+		logger.Infof("🪞 Duplicate page %s: %s\n", pageURL, original)
+	}
+	return true
+}
+
+// claimVisitKey adds key to the visited map, reporting false if it was
+// already there. Unlike saveVisitedURL it neither logs nor counts the URL,
+// which will not be fetched itself.
+func (c *CrawlerTwoTier) claimVisitKey(key string) bool {
+	c.mapMutex.Lock()
+	defer c.mapMutex.Unlock()
+	if c.visitedURLsMap[key] {
+		return false
+	}
+	c.visitedURLsMap[key] = true
+	return true
+}
+
+// visitKey is the key u is recorded under in the visited map
+func (c *CrawlerTwoTier) visitKey(u *url.URL) string {
+	key := *u
+	key.Path = c.dedup.collapse(key.Path)
+	key.RawPath = ""
+	return utils.NormalizeParsedURL(&key)
+}
+
+// collapse rewrites p to the canonical form of its variants
+func (r DedupRules) collapse(p string) string {
+	if len(r.DefaultDocs) > 0 {
+		slash := strings.LastIndex(p, "/")
+		for _, doc := range r.DefaultDocs {
+			if strings.EqualFold(p[slash+1:], doc) {
+				p = p[:slash+1]
+				break
+			}
+		}
+	}
+	if r.TrailingSlash {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// defaultExcludePatterns skip common crawler traps: links that end the
+// session and endless calendar/date navigation
+var defaultExcludePatterns = []string{
+	`(?i)/(logout|log-out|logoff|signout|sign-out)\b`,
+	`(?i)[?&](logout|action=logout)\b`,
+	`(?i)[?&](date|day|month|year|week)=`,
+	`(?i)/calendar/`,
+}
+
+// AddExcludePattern skips discovered URLs matching the regular expression p.
+// Common traps (logout links, calendar pages, ?date= navigation) are
+// excluded by default; see ClearExcludePatterns.
+func (c *CrawlerTwoTier) AddExcludePattern(p string) error {
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+	}
+	c.excludePatterns = append(c.excludePatterns, re)
+	return nil
+}
+
+// ClearExcludePatterns removes every exclude pattern, including the defaults
+func (c *CrawlerTwoTier) ClearExcludePatterns() {
+	c.excludePatterns = nil
+}
+
+// isExcluded reports whether urlStr matches an exclude pattern
+func (c *CrawlerTwoTier) isExcluded(urlStr string) bool {
+	for _, re := range c.excludePatterns {
+		if re.MatchString(urlStr) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileDefaultExcludes returns the built-in exclude patterns
+func compileDefaultExcludes() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(defaultExcludePatterns))
+	for i, p := range defaultExcludePatterns {
+		patterns[i] = regexp.MustCompile(p)
+	}
+	return patterns
+}
@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// FrontierEntry is a page that was scheduled but has not been fetched yet
+type FrontierEntry struct {
+	URL      string `json:"url"`
+	Depth    int    `json:"depth"`
+	Priority int    `json:"priority,omitempty"`
+	Fallback string `json:"fallback,omitempty"` // URL as linked, tried if a rewritten URL fails
+
+	tree *subtree // CrawlSubtree the page was scheduled under, if any
+}
+
+// frontier tracks scheduled pages until their response or error arrives
+type frontier struct {
+	mu      sync.Mutex
+	pending map[string]FrontierEntry // normalized URL -> request
+}
+
+func newFrontier() *frontier {
+	return &frontier{pending: make(map[string]FrontierEntry)}
+}
+
+func (f *frontier) add(key string, entry FrontierEntry) {
+	if entry.tree != nil {
+		entry.tree.add()
+	}
+	f.mu.Lock()
+	old, replaced := f.pending[key]
+	f.pending[key] = entry
+	f.mu.Unlock()
+	if replaced && old.tree != nil {
+		old.tree.finish()
+	}
+}
+
+func (f *frontier) done(key string) {
+	f.mu.Lock()
+	entry, ok := f.pending[key]
+	delete(f.pending, key)
+	f.mu.Unlock()
+	if ok && entry.tree != nil {
+		entry.tree.finish()
+	}
+}
+
+// size returns how many pages are scheduled and not yet fetched
+func (f *frontier) size() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+// frontierKey is the key a request is tracked under. It works on a copy:
+// NormalizeParsedURL strips the query in place, and u is often the URL
+// about to be requested.
+func frontierKey(u *url.URL) string {
+	key := *u
+	return utils.NormalizeParsedURL(&key)
+}
+
+// markFetched removes the request behind r from the frontier
+func (c *CrawlerTwoTier) markFetched(r *colly.Response) {
+	key := r.Ctx.Get("frontier")
+	if key == "" {
+		key = frontierKey(r.Request.URL)
+	}
+	c.frontier.done(key)
+	c.releaseVisit(r)
+}
+
+// Frontier returns the visited URLs and the pages scheduled but not yet
+// fetched, for checkpointing
+func (c *CrawlerTwoTier) Frontier() (visited []string, pending []FrontierEntry) {
+	c.mapMutex.RLock()
+	visited = make([]string, 0, len(c.visitedURLsMap))
+	for u := range c.visitedURLsMap {
+		visited = append(visited, u)
+	}
+	c.mapMutex.RUnlock()
+
+	c.frontier.mu.Lock()
+	pending = make([]FrontierEntry, 0, len(c.frontier.pending))
+	for _, entry := range c.frontier.pending {
+		pending = append(pending, entry)
+	}
+	c.frontier.mu.Unlock()
+
+	return visited, pending
+}
+
+// RestoreFrontier resumes from a checkpoint: visited URLs are not crawled
+// again and Start requests the pending pages instead of the seeds.
+// Call before Start.
+func (c *CrawlerTwoTier) RestoreFrontier(visited []string, pending []FrontierEntry) {
+	c.mapMutex.Lock()
+	for _, u := range visited {
+		c.visitedURLsMap[u] = true
+	}
+	c.mapMutex.Unlock()
+
+	c.resumed = true
+	c.resumePending = pending
+}
+
+// requestPending re-requests the pages restored by RestoreFrontier
+func (c *CrawlerTwoTier) requestPending() {
+	for _, entry := range c.resumePending {
+		parsed, err := url.Parse(entry.URL)
+		if err != nil {
+			continue
+		}
+		if !c.reservePage() {
+			return
+		}
+		if !c.reserveHostPage(utils.HostOf(entry.URL)) {
+			c.unreservePage()
+			continue
+		}
+		if c.visits != nil {
+			c.queueVisit(frontierKey(parsed), entry)
+		} else {
+			c.schedule(frontierKey(parsed), entry)
+		}
+	}
+	c.resumePending = nil
+	if c.visits != nil {
+		c.dispatchVisits()
+	}
+}
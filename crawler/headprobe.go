@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/panics"
+	"golang.org/x/time/rate"
+)
+
+// headProbe checks extension-less links with HEAD requests and reports
+// the ones whose Content-Type is a document type. Probes are rate limited,
+// cached per URL, and dropped rather than queued without bound.
+type headProbe struct {
+	client       *http.Client
+	limiter      *rate.Limiter
+	contentTypes map[string]bool
+	found        func(docURL string, depth int)
+
+	mu    sync.Mutex
+	cache map[string]bool // URL → already probed
+
+	queue chan probeTask
+	wg    sync.WaitGroup
+}
+
+type probeTask struct {
+	url   string
+	depth int
+}
+
+func newHeadProbe(client *http.Client, docExtensions []string, found func(docURL string, depth int)) *headProbe {
+	p := &headProbe{
+		client:       client,
+		limiter:      rate.NewLimiter(rate.Limit(config.HeadProbeRate), config.HeadProbeWorkers),
+		contentTypes: make(map[string]bool),
+		found:        found,
+		cache:        make(map[string]bool),
+		queue:        make(chan probeTask, config.HeadProbeQueueSize),
+	}
+
+	for _, ext := range docExtensions {
+		if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(ext)); err == nil {
+			p.contentTypes[mediaType] = true
+		}
+	}
+
+	for i := 0; i < config.HeadProbeWorkers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+// SetHeadProbe enables HEAD requests on extension-less links found by the
+// slow path, enqueueing those served with a document Content-Type.
+// Off by default because every probe is an extra request.
+func (c *CrawlerTwoTier) SetHeadProbe(enabled bool) {
+	if enabled && c.headProbe == nil {
+		c.headProbe = newHeadProbe(c.pageClient(config.HeadProbeTimeout), c.docExtensions, c.enqueueDocument)
+	} else if !enabled && c.headProbe != nil {
+		c.headProbe.stop()
+		c.headProbe = nil
+	}
+}
+
+// consider schedules a probe if linkURL has no file extension
+func (p *headProbe) consider(linkURL string, depth int) {
+	u, err := url.Parse(linkURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	if path.Ext(u.Path) != "" || strings.HasSuffix(u.Path, "/") {
+		return
+	}
+
+	p.mu.Lock()
+	seen := p.cache[linkURL]
+	p.cache[linkURL] = true
+	p.mu.Unlock()
+	if seen {
+		return
+	}
+
+	select {
+	case p.queue <- probeTask{url: linkURL, depth: depth}:
+	default:
+		// Probe queue full, skip rather than stall the crawl
+	}
+}
+
+func (p *headProbe) worker() {
+	defer p.wg.Done()
+
+	for task := range p.queue {
+		p.probe(task)
+	}
+}
+
+// probe checks one link, recording a panic rather than losing the worker
+func (p *headProbe) probe(task probeTask) {
+	defer panics.Recover("HEAD probe", task.url)
+
+	p.limiter.Wait(context.Background())
+	if p.isDocument(task.url) {
+		p.found(task.url, task.depth)
+	}
+}
+
+// isDocument issues the HEAD request and checks the Content-Type
+func (p *headProbe) isDocument(docURL string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), config.HeadProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, docURL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && p.contentTypes[mediaType]
+}
+
+// stop waits for queued probes to finish
+func (p *headProbe) stop() {
+	close(p.queue)
+	p.wg.Wait()
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// hostBudget caps the pages requested from each host
+type hostBudget struct {
+	limit   int64
+	mu      sync.Mutex
+	pages   map[string]int64
+	skipped atomic.Int64 // URLs dropped because their host was at the limit
+}
+
+func newHostBudget(limit int) *hostBudget {
+	return &hostBudget{limit: int64(limit), pages: make(map[string]int64)}
+}
+
+// reserve claims one page from host's budget
+func (b *hostBudget) reserve(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pages[host] >= b.limit {
+		b.skipped.Add(1)
+		return false
+	}
+	b.pages[host]++
+	return true
+}
+
+// exhausted returns how many hosts have used their whole budget
+func (b *hostBudget) exhausted() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	for _, pages := range b.pages {
+		if pages >= b.limit {
+			n++
+		}
+	}
+	return n
+}
+
+// SetPerHostPageBudget caps the pages requested from any one host, seeds
+// included, so one large site can't take a multi-seed crawl's whole page
+// budget. Once a host is at the cap its newly discovered URLs are skipped.
+// Zero means unlimited. Call before Start.
+func (c *CrawlerTwoTier) SetPerHostPageBudget(n int) {
+	if n <= 0 {
+		c.hostBudget = nil
+		return
+	}
+	c.hostBudget = newHostBudget(n)
+}
+
+// GetHostBudgetSkips returns how many URLs were skipped because their host
+// had used its page budget
+func (c *CrawlerTwoTier) GetHostBudgetSkips() int64 {
+	if c.hostBudget == nil {
+		return 0
+	}
+	return c.hostBudget.skipped.Load()
+}
+
+// reserveHostPage claims one page from host's budget
+func (c *CrawlerTwoTier) reserveHostPage(host string) bool {
+	if c.hostBudget == nil {
+		return true
+	}
+	return c.hostBudget.reserve(host)
+}
@@ -0,0 +1,31 @@
+package crawler
+
+import (
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/logger"
+)
+
+// SetMaxLinksPerPage stops taking links from a page after n, overriding
+// config.MaxLinksPerPage, so a page with millions of hrefs can't flood the
+// frontier. Zero means unlimited.
+func (c *CrawlerTwoTier) SetMaxLinksPerPage(n int) {
+	c.maxLinksPerPage = max(n, 0)
+	c.coordinator.SetMaxLinksPerPage(n)
+}
+
+// GetLinkCappedCount returns how many pages had links dropped by the
+// per-page limit
+func (c *CrawlerTwoTier) GetLinkCappedCount() int64 {
+	return atomic.LoadInt64(&c.linkCappedPages)
+}
+
+// noteLinkCap counts and logs a page whose links were cut off
+func (c *CrawlerTwoTier) noteLinkCap(pageURL string, capped bool) {
+	if !capped {
+		return
+	}
+	atomic.AddInt64(&c.linkCappedPages, 1)
+	logger.Warnf("⚠️ Page has more than %d links, following the first %d: %s\n",
+		c.maxLinksPerPage, c.maxLinksPerPage, pageURL)
+}
@@ -0,0 +1,185 @@
+package crawler
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/statsdb"
+	"github.com/jeb/url_crawler/tokenizer"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// maxLocalPageSize matches the collector's MaxBodySize
+const maxLocalPageSize = config.MaxPageSize
+
+// localCrawl walks HTML files under a local directory, feeding them through
+// the same two-tier tokenizer as fetched pages. Only file:// links inside
+// root are followed, so a local crawl never touches the network.
+type localCrawl struct {
+	c     *CrawlerTwoTier
+	root  string
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// startLocal begins a crawl of the file:// start URL. A directory seeds
+// every HTML file below it; a file seeds just that page.
+func (c *CrawlerTwoTier) startLocal() error {
+	u, err := url.Parse(c.startURL)
+	if err != nil {
+		return err
+	}
+
+	start, err := filepath.Abs(u.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(start)
+	if err != nil {
+		return err
+	}
+
+	root := start
+	if !info.IsDir() {
+		root = filepath.Dir(start)
+	}
+
+	c.local = &localCrawl{
+		c:     c,
+		root:  root,
+		slots: make(chan struct{}, config.ConcurrentWorkers),
+	}
+	c.downloadManager.EnableLocalFiles(root)
+
+	schemes := c.schemes
+	if schemes == nil {
+		schemes = tokenizer.DefaultSchemes
+	}
+	c.coordinator.SetAllowedSchemes(append(slices.Clone(schemes), "file"))
+
+	logger.Infof("🚀🚀 [0] TWO-TIER local crawl started: %s\n", start)
+
+	if !info.IsDir() {
+		c.local.visit(start, 0)
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logger.Warnf("⚠️ Skipping %s: %v\n", path, err)
+			return nil
+		}
+		if !d.IsDir() && isHTMLFile(path) {
+			c.local.visit(path, 0)
+		}
+		return nil
+	})
+}
+
+// discover follows a link found on a local page
+func (l *localCrawl) discover(urlStr string, currentDepth int) {
+	if currentDepth >= l.c.maxDepth {
+		return
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Scheme != "file" {
+		return
+	}
+
+	path := filepath.Clean(u.Path)
+	if !l.contains(path) {
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "index.html")
+	}
+	if !isHTMLFile(path) {
+		return
+	}
+
+	l.visit(path, currentDepth+1)
+}
+
+// visit schedules a local page once
+func (l *localCrawl) visit(path string, depth int) {
+	key := (&url.URL{Scheme: "file", Path: path}).String()
+	if _, ok := l.c.claimVisit(key, ""); !ok {
+		return
+	}
+	l.c.recordVisit(key, depth)
+
+	l.wg.Add(1)
+	panics.Go("local fetch", key, func() {
+		defer l.wg.Done()
+		l.slots <- struct{}{}
+		defer func() { <-l.slots }()
+
+		l.fetch(path, depth)
+	})
+}
+
+// fetch reads one local page and processes it
+func (l *localCrawl) fetch(path string, depth int) {
+	pageURL := &url.URL{Scheme: "file", Path: path}
+
+	body, err := readLocalPage(path)
+	if err != nil {
+		atomic.AddInt64(&l.c.pagesFailed, 1)
+		l.c.downloadManager.RecordCrawlError(pageURL.String())
+		logger.Warnf("⚠️ Local read error: %v\n", err)
+		return
+	}
+
+	atomic.AddInt64(&l.c.pagesFetched, 1)
+	l.c.downloadManager.RecordPageCrawled(pageURL.String())
+	decision, links, docs := l.c.processPage(pageURL, body, mime.TypeByExtension(filepath.Ext(path)), depth, 0, nil, tokenizer.RobotsDirectives{})
+	if l.c.statsDB != nil {
+		l.c.statsDB.RecordPage(statsdb.PageRecord{
+			URL:          pageURL.String(),
+			Depth:        depth,
+			Size:         len(body),
+			PathDecision: decision.String(),
+			Links:        links,
+			Docs:         docs,
+		})
+	}
+}
+
+// contains reports whether path lies inside the crawl root
+func (l *localCrawl) contains(path string) bool {
+	rel, err := filepath.Rel(l.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// readLocalPage reads a page, refusing files larger than a fetched page could be
+func readLocalPage(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxLocalPageSize {
+		return nil, fmt.Errorf("%s is larger than %s", path, utils.FormatBytes(maxLocalPageSize))
+	}
+	return os.ReadFile(path)
+}
+
+// isHTMLFile reports whether path looks like an HTML page
+func isHTMLFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".xhtml":
+		return true
+	}
+	return false
+}
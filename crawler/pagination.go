@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/tokenizer"
+)
+
+// SetPaginationPriority has the rel=next page of a slow-path page visited
+// boost priority levels ahead of the page's other links, at the page's own
+// depth, so a paginated listing is followed to its end, in order, however
+// deep it goes; each page further down a chain is ahead of the one before.
+// The crawl's page budgets still apply. Zero (the default) follows
+// rel=next and rel=prev like any other link. Call before Start.
+func (c *CrawlerTwoTier) SetPaginationPriority(boost int) {
+	c.paginationBoost = max(boost, 0)
+}
+
+// GetPaginationCount returns how many rel=next pages were scheduled ahead
+// of other links by SetPaginationPriority
+func (c *CrawlerTwoTier) GetPaginationCount() int64 {
+	return atomic.LoadInt64(&c.paginatedPages)
+}
+
+// followPagination follows the rel=next and rel=prev links of a page.
+// <link rel=next> is in the head, where the page's links don't come from.
+func (c *CrawlerTwoTier) followPagination(meta tokenizer.PageMetadata, currentDepth, priority int, tree *subtree) {
+	if meta.Next != "" {
+		if c.paginationBoost == 0 {
+			c.processDiscoveredURL(meta.Next, currentDepth, priority, tree)
+		} else if key, entry, ok := c.linkEntry(meta.Next, currentDepth); ok {
+			entry.Priority, entry.tree = priority+c.paginationBoost, tree
+			atomic.AddInt64(&c.paginatedPages, 1)
+			c.schedule(key, entry)
+		}
+	}
+	if meta.Prev != "" {
+		c.processDiscoveredURL(meta.Prev, currentDepth, priority, tree)
+	}
+}
@@ -0,0 +1,173 @@
+package crawler
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/utils"
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfTextURL matches URLs written out in a PDF's text
+var pdfTextURL = regexp.MustCompile(`https?://[^\s<>"'()\[\]{}]+`)
+
+// SetFollowPDFLinks makes downloaded PDFs a source of links: URLs in link
+// annotations, the outline (table of contents) and page text are fed back
+// into the crawl as if found on a page at the PDF's depth, so depth, scope,
+// exclude and dedup rules all apply. Documents among them are downloaded
+// and, if PDFs, scanned in turn. Wait then also waits for the downloads,
+// since they can lead to more pages. Call before the crawl starts.
+func (c *CrawlerTwoTier) SetFollowPDFLinks(enabled bool) {
+	if !enabled || c.followPDFs {
+		return
+	}
+	c.followPDFs = true
+	c.downloadManager.RegisterPostProcessor(downloader.PostProcessorFunc(c.followPDFLinks))
+}
+
+// followPDFLinks is the post-processor behind SetFollowPDFLinks
+func (c *CrawlerTwoTier) followPDFLinks(path string, meta downloader.DownloadMeta) error {
+	if !isPDF(path, meta.ContentType) || meta.Bytes > config.PDFLinkMaxBytes {
+		return nil
+	}
+	if meta.Depth >= c.maxDepth {
+		return nil
+	}
+
+	base, err := url.Parse(meta.URL)
+	if err != nil {
+		return nil
+	}
+	links, err := pdfLinks(path)
+	if err != nil {
+		// A PDF we can't read is no reason to stop later processors
+		logger.Warnf("⚠️ PDF links: %s: %v\n", meta.URL, err)
+	}
+
+	docs := 0
+	for _, raw := range links {
+		u, err := base.Parse(strings.TrimSpace(raw))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		link := u.String()
+
+		if utils.IsDocumentURL(link, c.docExtensions) {
+			if (c.maxURLLength > 0 && len(link) > c.maxURLLength) || c.isExcluded(link) {
+				continue
+			}
+			c.enqueueDocument(link, meta.Depth+1)
+			docs++
+			continue
+		}
+		c.processDiscoveredURL(link, meta.Depth, 0, nil)
+	}
+
+	if len(links) > 0 {
+		logger.Infof("📎 PDF [%d] %s → %d links, %d docs\n", meta.Depth, meta.URL, len(links), docs)
+	}
+	return nil
+}
+
+// isPDF decides from the Content-Type, falling back to the file extension
+func isPDF(path, contentType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "application/octet-stream" {
+		return mediaType == "application/pdf"
+	}
+	return strings.EqualFold(filepath.Ext(path), ".pdf")
+}
+
+// pdfLinks returns the URLs in a PDF's link annotations, outline and page
+// text, at most config.PDFLinkMaxLinks of them. The PDF library panics on
+// some malformed files; that's returned as an error along with whatever was
+// found before it.
+func pdfLinks(path string) (links []string, err error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seen := make(map[string]bool)
+	add := func(link string) bool {
+		if link != "" && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+		return len(links) < config.PDFLinkMaxLinks
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("malformed PDF: %v", rec)
+		}
+	}()
+
+	if !outlineLinks(r.Trailer().Key("Root").Key("Outlines").Key("First"), add) {
+		return links, nil
+	}
+
+	fonts := make(map[string]*pdf.Font)
+	pages := min(r.NumPage(), config.PDFLinkMaxPages)
+	for i := 1; i <= pages; i++ {
+		page := r.Page(i)
+
+		annots := page.V.Key("Annots")
+		for j := 0; j < annots.Len(); j++ {
+			if !add(uriAction(annots.Index(j).Key("A"))) {
+				return links, nil
+			}
+		}
+
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			continue
+		}
+		for _, link := range pdfTextURL.FindAllString(text, -1) {
+			if !add(strings.TrimRight(link, ".,;:")) {
+				return links, nil
+			}
+		}
+	}
+	return links, nil
+}
+
+// outlineLinks walks the outline items starting at item, siblings and
+// children, passing URI actions to add. It stops, returning false, once add
+// does. The walk is capped so a cyclic outline can't loop forever.
+func outlineLinks(item pdf.Value, add func(string) bool) bool {
+	stack := []pdf.Value{item}
+	for visited := 0; len(stack) > 0 && visited < config.PDFOutlineMaxItems; visited++ {
+		item, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if item.IsNull() {
+			continue
+		}
+		if !add(uriAction(item.Key("A"))) {
+			return false
+		}
+		stack = append(stack, item.Key("Next"), item.Key("First"))
+	}
+	return true
+}
+
+// uriAction returns the target of a /URI action, or "" for other actions
+func uriAction(action pdf.Value) string {
+	if action.Key("S").Name() != "URI" {
+		return ""
+	}
+	return action.Key("URI").RawString()
+}
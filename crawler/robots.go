@@ -0,0 +1,47 @@
+package crawler
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/tokenizer"
+)
+
+// SetRespectRobots makes the crawler obey robots.txt, <meta name="robots">
+// and X-Robots-Tag. With nofollow, a page's links are not followed; its
+// documents are still downloaded unless it is also noindex. Off by default.
+// Has no effect on robots.txt with a caller-supplied collector.
+func (c *CrawlerTwoTier) SetRespectRobots(enabled bool) {
+	c.respectRobots = enabled
+	if c.limitRule != nil {
+		c.collector.IgnoreRobotsTxt = !enabled
+	}
+}
+
+// GetNoFollowCount returns how many pages had their links ignored because
+// of a robots nofollow
+func (c *CrawlerTwoTier) GetNoFollowCount() int64 {
+	return atomic.LoadInt64(&c.noFollowPages)
+}
+
+// headerRobots reads the X-Robots-Tag headers of a response
+func (c *CrawlerTwoTier) headerRobots(headers *http.Header) tokenizer.RobotsDirectives {
+	var d tokenizer.RobotsDirectives
+	if !c.respectRobots || headers == nil {
+		return d
+	}
+	for _, value := range headers.Values("X-Robots-Tag") {
+		d = d.Merge(tokenizer.ParseRobotsDirectives(value))
+	}
+	return d
+}
+
+// robotsAllow reports whether a page's links and documents may be followed
+// under its robots directives, counting pages whose links are dropped
+func (c *CrawlerTwoTier) robotsAllow(robots tokenizer.RobotsDirectives) (links, docs bool) {
+	if !c.respectRobots || !robots.NoFollow {
+		return true, true
+	}
+	atomic.AddInt64(&c.noFollowPages, 1)
+	return false, !robots.NoIndex
+}
@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// SchemeRewrite chooses whether discovered http(s) links keep their scheme
+type SchemeRewrite int
+
+const (
+	SchemeAsLinked SchemeRewrite = iota // Keep the scheme as linked (default)
+	SchemeHTTPS                         // Upgrade http links to https
+	SchemeSeed                          // Use the scheme of the seed on the same host
+)
+
+// ParseSchemeRewrite converts "off", "https" or "seed" to a SchemeRewrite
+func ParseSchemeRewrite(s string) (SchemeRewrite, error) {
+	switch strings.ToLower(s) {
+	case "", "off":
+		return SchemeAsLinked, nil
+	case "https":
+		return SchemeHTTPS, nil
+	case "seed":
+		return SchemeSeed, nil
+	default:
+		return SchemeAsLinked, fmt.Errorf("unknown scheme rewrite %q (use off, https or seed)", s)
+	}
+}
+
+// SetSchemeRewrite rewrites the scheme of discovered page and document
+// links before they are queued, e.g. to skip the redirect a site that has
+// moved to https still sends for every http link. A rewritten page or
+// document that fails is fetched once more as linked. Links with a port
+// other than the scheme's default are left alone. Call before Start.
+func (c *CrawlerTwoTier) SetSchemeRewrite(rewrite SchemeRewrite) {
+	c.schemeRewrite = rewrite
+	if rewrite != SchemeAsLinked && !c.docFallbackHooked {
+		c.docFallbackHooked = true
+		c.downloadManager.OnDownloadFailed(c.retryDocumentAsLinked)
+	}
+}
+
+// GetSchemeRewriteStats returns how many links had their scheme rewritten
+// and how many of those were fetched as linked after the rewrite failed
+func (c *CrawlerTwoTier) GetSchemeRewriteStats() (rewritten, fellBack int64) {
+	return atomic.LoadInt64(&c.schemeRewrites), atomic.LoadInt64(&c.schemeFallbacks)
+}
+
+// rewriteScheme applies the scheme rewrite to u in place, reporting
+// whether it changed
+func (c *CrawlerTwoTier) rewriteScheme(u *url.URL) bool {
+	if c.schemeRewrite == SchemeAsLinked {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return false
+	}
+
+	want := scheme
+	switch c.schemeRewrite {
+	case SchemeHTTPS:
+		want = "https"
+	case SchemeSeed:
+		for _, seed := range append([]string{c.startURL}, c.seeds...) {
+			if s, err := url.Parse(seed); err == nil && strings.EqualFold(s.Hostname(), u.Hostname()) {
+				want = strings.ToLower(s.Scheme)
+				break
+			}
+		}
+	}
+	if want == scheme || want != "http" && want != "https" {
+		return false
+	}
+
+	switch u.Port() {
+	case "":
+	case defaultPort(scheme):
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	default:
+		return false
+	}
+	u.Scheme = want
+	atomic.AddInt64(&c.schemeRewrites, 1)
+	return true
+}
+
+// defaultPort returns the port http or https uses when none is given
+func defaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// retryPageAsLinked requests a failed page again under the URL it was
+// linked as, if its scheme was rewritten. It must be called before
+// markFetched, so a subtree the page belongs to is not done in between.
+func (c *CrawlerTwoTier) retryPageAsLinked(r *colly.Response) {
+	linked := r.Ctx.Get("fallback")
+	if linked == "" {
+		return
+	}
+	u, err := url.Parse(linked)
+	if err != nil {
+		return
+	}
+	atomic.AddInt64(&c.schemeFallbacks, 1)
+	logger.Infof("↩️ %s failed, trying it as linked: %s\n", r.Request.URL, linked)
+
+	depth := 0
+	fmt.Sscanf(r.Ctx.Get("depth"), "%d", &depth)
+	c.schedule(frontierKey(u), FrontierEntry{URL: linked, Depth: depth, Priority: pagePriority(r.Ctx), tree: c.pageSubtree(r.Ctx)})
+}
+
+// retryDocumentAsLinked is the download failure hook behind
+// SetSchemeRewrite: it queues a document whose rewritten URL failed for
+// good under the URL it was linked as
+func (c *CrawlerTwoTier) retryDocumentAsLinked(meta downloader.DownloadMeta, err error) {
+	linked, ok := c.docFallbacks.LoadAndDelete(meta.URL)
+	if !ok || c.downloadManager.ShuttingDown() {
+		return
+	}
+	atomic.AddInt64(&c.schemeFallbacks, 1)
+	logger.Infof("↩️ %s failed (%v), trying it as linked: %s\n", meta.URL, err, linked)
+	c.queueDownload(linked.(string), meta.Depth)
+}
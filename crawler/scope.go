@@ -0,0 +1,115 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scope limits which discovered pages are crawled, relative to the seeds.
+// Documents linked from in-scope pages are downloaded wherever they live.
+type Scope int
+
+const (
+	ScopeAny     Scope = iota // No restriction beyond depth (default)
+	ScopeHost                 // Same host as a seed
+	ScopePrefix               // Path starts with a seed's path, as a plain string prefix
+	ScopeSubtree              // Path is inside a seed's directory
+	ScopeDomain               // Same registered domain (eTLD+1) as a seed, subdomains included
+)
+
+// ParseScope converts "any", "host", "prefix", "subtree" or "domain" to a Scope
+func ParseScope(s string) (Scope, error) {
+	switch strings.ToLower(s) {
+	case "", "any":
+		return ScopeAny, nil
+	case "host":
+		return ScopeHost, nil
+	case "prefix":
+		return ScopePrefix, nil
+	case "subtree":
+		return ScopeSubtree, nil
+	case "domain":
+		return ScopeDomain, nil
+	default:
+		return ScopeAny, fmt.Errorf("unknown crawl scope %q (use any, host, prefix, subtree or domain)", s)
+	}
+}
+
+// SetScope restricts crawled pages to the seeds' host, path prefix,
+// directory subtree or registered domain. With a start URL of
+// https://www.site.gov/reports/2024, prefix admits /reports/2024 and
+// /reports/2024-archive/, subtree admits everything under /reports/ and
+// domain admits site.gov and all its subdomains. Call before Start.
+func (c *CrawlerTwoTier) SetScope(scope Scope) {
+	c.scope = scope
+}
+
+// inScope reports whether u may be crawled under the configured scope
+func (c *CrawlerTwoTier) inScope(u *url.URL) bool {
+	if c.scope == ScopeAny {
+		return true
+	}
+
+	for _, seed := range append([]string{c.startURL}, c.seeds...) {
+		s, err := url.Parse(seed)
+		if err != nil {
+			continue
+		}
+		if c.scope == ScopeDomain {
+			if sameRegisteredDomain(s.Hostname(), u.Hostname()) {
+				return true
+			}
+			continue
+		}
+		if !strings.EqualFold(s.Hostname(), u.Hostname()) {
+			continue
+		}
+
+		switch c.scope {
+		case ScopeHost:
+			return true
+		case ScopePrefix:
+			if strings.HasPrefix(u.Path, s.Path) {
+				return true
+			}
+		case ScopeSubtree:
+			if strings.HasPrefix(u.Path, subtreeOf(s.Path)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameRegisteredDomain reports whether hosts a and b share an eTLD+1,
+// e.g. docs.example.co.uk and example.co.uk. IP addresses and hosts
+// without a public suffix only match themselves.
+func sameRegisteredDomain(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a == b {
+		return true
+	}
+	da, err := publicsuffix.EffectiveTLDPlusOne(a)
+	if err != nil {
+		return false
+	}
+	db, err := publicsuffix.EffectiveTLDPlusOne(b)
+	return err == nil && da == db
+}
+
+// subtreeOf returns the directory part of p with a trailing slash:
+// /reports/ and /reports/index.html both give /reports/
+func subtreeOf(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p
+	}
+	dir := path.Dir(p)
+	if dir == "/" || dir == "." {
+		return "/"
+	}
+	return dir + "/"
+}
@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/statsdb"
+	"github.com/jeb/url_crawler/tokenizer"
+)
+
+// robotsHeadSize is how much of a streamed page is held back to look for
+// a robots meta tag before it is scanned
+const robotsHeadSize = 64 * 1024
+
+// SetStreamLargePages streams HTML pages whose Content-Length is at least
+// minBytes through the fast path instead of buffering them: the collector's
+// transfer is dropped after the headers and the page is fetched again and
+// scanned as it arrives, up to config.MaxStreamedPageSize. This caps memory
+// on huge index pages. Streamed pages always take the fast path, with no
+// inline script scanning, and aren't written to the WARC archive. Zero (the
+// default) turns streaming off.
+func (c *CrawlerTwoTier) SetStreamLargePages(minBytes int64) {
+	if minBytes <= 0 {
+		c.streamThreshold = 0
+		c.streamClient = nil
+		return
+	}
+	c.streamThreshold = minBytes
+	c.streamClient = c.pageClient(c.requestTimeout)
+}
+
+// GetStreamedCount returns how many pages were streamed
+func (c *CrawlerTwoTier) GetStreamedCount() int64 {
+	return atomic.LoadInt64(&c.streamedPages)
+}
+
+// divertLargePage aborts the collector's transfer of a large HTML page and
+// marks the request for streaming; OnError picks it up
+func (c *CrawlerTwoTier) divertLargePage(r *colly.Response) {
+	if c.streamThreshold <= 0 || r.StatusCode != http.StatusOK || r.Headers == nil {
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Headers.Get("Content-Type"))
+	if err != nil || mediaType != "text/html" {
+		return
+	}
+	size, err := strconv.ParseInt(r.Headers.Get("Content-Length"), 10, 64)
+	if err != nil || size < c.streamThreshold {
+		return
+	}
+
+	r.Ctx.Put("stream", "1")
+	r.Request.Abort()
+}
+
+// streamPage fetches a page diverted by divertLargePage and follows its
+// links as the body is read
+func (c *CrawlerTwoTier) streamPage(r *colly.Response) {
+	defer c.markFetched(r)
+
+	currentDepth := 0
+	if d := r.Ctx.Get("depth"); d != "" {
+		fmt.Sscanf(d, "%d", &currentDepth)
+	}
+	pageURL := r.Request.URL.String()
+
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		atomic.AddInt64(&c.pagesFailed, 1)
+		c.downloadManager.RecordCrawlError(pageURL)
+		c.downloadManager.RecordStatus(downloader.StatusNetworkError)
+		logger.Errorf("❌ Streaming %s failed: %v\n", pageURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	c.downloadManager.RecordPageCrawled(pageURL)
+	c.downloadManager.RecordStatus(resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		atomic.AddInt64(&c.pagesFailed, 1)
+		c.downloadManager.RecordCrawlError(pageURL)
+		return
+	}
+	atomic.AddInt64(&c.pagesFetched, 1)
+	c.downloadManager.RecordContentType(resp.Header.Get("Content-Type"))
+	atomic.AddInt64(&c.streamedPages, 1)
+
+	robots := c.headerRobots(&resp.Header)
+	body := bufio.NewReaderSize(io.LimitReader(resp.Body, config.MaxStreamedPageSize), robotsHeadSize)
+	if c.respectRobots {
+		head, _ := body.Peek(robotsHeadSize)
+		robots = robots.Merge(tokenizer.RobotsMeta(head))
+	}
+
+	result, size, err := c.coordinator.ProcessFastPathStream(body, resp.Request.URL, c.docExtensions)
+	if err != nil {
+		logger.Warnf("⚠️ Stream of %s cut short after %d bytes, following the links found so far: %v\n", pageURL, size, err)
+	}
+	c.followFastPath(result, currentDepth, pagePriority(r.Ctx), c.pageSubtree(r.Ctx), robots)
+	c.noteLinkCap(pageURL, result.Capped)
+
+	logger.Infof("🌊 STREAMED [%d] %s → %d links from %d bytes in %dμs\n",
+		currentDepth, pageURL, result.LinkCount, size, result.ProcessingUs)
+
+	if c.statsDB != nil {
+		c.statsDB.RecordPage(statsdb.PageRecord{
+			URL:          pageURL,
+			Depth:        currentDepth,
+			Status:       resp.StatusCode,
+			Size:         int(size),
+			PathDecision: tokenizer.FastPath.String(),
+			Links:        result.LinkCount,
+			Docs:         len(result.Documents),
+		})
+	}
+}
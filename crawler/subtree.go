@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/jeb/url_crawler/tokenizer"
+)
+
+// subtree tracks the pages scheduled under one CrawlSubtree root and the
+// documents they link to. Its pages carry it in their frontier entry, so
+// it counts a page from when it is scheduled until the frontier lets go of
+// it, fetched or not.
+type subtree struct {
+	mu      sync.Mutex
+	pending int
+	docs    []tokenizer.DocumentInfo
+	seen    map[string]bool // document URL -> already in docs
+	done    chan struct{}
+}
+
+func newSubtree() *subtree {
+	return &subtree{seen: make(map[string]bool), done: make(chan struct{})}
+}
+
+// add counts one more scheduled page
+func (t *subtree) add() {
+	t.mu.Lock()
+	t.pending++
+	t.mu.Unlock()
+}
+
+// finish counts a scheduled page as done, closing done after the last
+func (t *subtree) finish() {
+	t.mu.Lock()
+	t.pending--
+	if t.pending == 0 {
+		close(t.done)
+	}
+	t.mu.Unlock()
+}
+
+// found records a document linked from a page of the subtree. t may be
+// nil, for pages outside any subtree.
+func (t *subtree) found(doc tokenizer.DocumentInfo) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if !t.seen[doc.URL] {
+		t.seen[doc.URL] = true
+		t.docs = append(t.docs, doc)
+	}
+	t.mu.Unlock()
+}
+
+func (t *subtree) documents() []tokenizer.DocumentInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]tokenizer.DocumentInfo(nil), t.docs...)
+}
+
+// CrawlSubtree crawls rawURL and the pages under it, returning once they
+// are all fetched or failed, with the documents they link to. Depth is
+// counted from rawURL, which must be in scope and not crawled yet; pages
+// already crawled from elsewhere are not revisited, so they and their
+// links are not part of the subtree. Documents are returned as found:
+// their downloads go on in the background. If ctx ends first, CrawlSubtree
+// returns its error while the subtree goes on crawling. Call after Start;
+// it can run alongside the main crawl and other subtrees.
+func (c *CrawlerTwoTier) CrawlSubtree(ctx context.Context, rawURL string) ([]tokenizer.DocumentInfo, error) {
+	if c.local != nil {
+		return nil, fmt.Errorf("subtrees are not supported for file:// crawls")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("%s is not an absolute URL", rawURL)
+	}
+	if !c.inScope(parsed) {
+		return nil, fmt.Errorf("%s is out of scope", rawURL)
+	}
+
+	cleanURL := c.visitKey(parsed)
+	seen, ok := c.claimVisit(cleanURL, strings.ToLower(parsed.Hostname()))
+	if seen {
+		return nil, fmt.Errorf("%s was already crawled", rawURL)
+	}
+	if !ok {
+		return nil, fmt.Errorf("page budget spent, not crawling %s", rawURL)
+	}
+	c.recordVisit(cleanURL, 0)
+
+	tree := newSubtree()
+	entry := FrontierEntry{URL: parsed.String(), tree: tree}
+	if c.visits != nil {
+		c.schedule(cleanURL, entry)
+	} else {
+		c.frontier.add(cleanURL, entry)
+		if err := c.requestPage(cleanURL, entry, false); err != nil {
+			c.frontier.done(cleanURL)
+			return nil, err
+		}
+	}
+
+	select {
+	case <-tree.done:
+		return tree.documents(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pageSubtree returns the subtree the page behind ctx belongs to, or nil.
+// It must be called before markFetched lets go of the page.
+func (c *CrawlerTwoTier) pageSubtree(ctx *colly.Context) *subtree {
+	c.frontier.mu.Lock()
+	defer c.frontier.mu.Unlock()
+	return c.frontier.pending[ctx.Get("frontier")].tree
+}
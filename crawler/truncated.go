@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// maxTruncatedWarnings caps the per-page truncation warnings
+const maxTruncatedWarnings = 10
+
+// SetRefetchTruncated re-downloads pages cut off by the collector's
+// MaxBodySize, up to config.MaxRefetchPageSize, so links near the end of
+// very large index pages are not lost. Off by default; truncated pages are
+// always counted (GetTruncatedCount).
+func (c *CrawlerTwoTier) SetRefetchTruncated(enabled bool) {
+	if !enabled {
+		c.refetchClient = nil
+		return
+	}
+	c.refetchClient = c.pageClient(c.requestTimeout)
+}
+
+// GetTruncatedCount returns how many pages hit the collector's MaxBodySize
+func (c *CrawlerTwoTier) GetTruncatedCount() int64 {
+	return atomic.LoadInt64(&c.truncatedPages)
+}
+
+// fullBody returns r's body, refetched without the size cap if it was
+// truncated and refetching is enabled
+func (c *CrawlerTwoTier) fullBody(r *colly.Response) []byte {
+	limit := c.collector.MaxBodySize
+	if limit <= 0 || len(r.Body) < limit {
+		return r.Body
+	}
+
+	n := atomic.AddInt64(&c.truncatedPages, 1)
+	if c.refetchClient == nil {
+		if n <= maxTruncatedWarnings {
+			logger.Warnf("⚠️ Page truncated at %d bytes, trailing links may be missed: %s\n", limit, r.Request.URL)
+		}
+		return r.Body
+	}
+
+	body, err := c.refetch(r.Request.URL.String())
+	if err != nil {
+		logger.Warnf("⚠️ Refetching truncated page %s failed: %v\n", r.Request.URL, err)
+		return r.Body
+	}
+	return body
+}
+
+// refetch downloads pageURL in full, up to config.MaxRefetchPageSize
+func (c *CrawlerTwoTier) refetch(pageURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+
+	resp, err := c.refetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, config.MaxRefetchPageSize))
+}
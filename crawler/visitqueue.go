@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// queuedVisit is a page request waiting for a crawl slot
+type queuedVisit struct {
+	key   string // frontier key
+	entry FrontierEntry
+	seq   int64 // arrival order, the final tie-break
+}
+
+// visitHeap orders waiting pages by priority, then depth, then arrival
+type visitHeap []queuedVisit
+
+func (h visitHeap) Len() int { return len(h) }
+func (h visitHeap) Less(i, j int) bool {
+	a, b := h[i].entry, h[j].entry
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	return h[i].seq < h[j].seq
+}
+func (h visitHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *visitHeap) Push(x any)   { *h = append(*h, x.(queuedVisit)) }
+func (h *visitHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+// visitQueue holds page requests back until a slot is free and hands out
+// the highest-priority one first. Colly starts every request at once and
+// lets them race for its parallelism limit, which leaves the fetch order
+// to chance.
+type visitQueue struct {
+	mu    sync.Mutex
+	items visitHeap
+	seq   int64
+	free  int // slots not taken by a request in flight
+}
+
+func newVisitQueue(slots int) *visitQueue {
+	return &visitQueue{free: max(slots, 1)}
+}
+
+func (q *visitQueue) push(key string, entry FrontierEntry) {
+	q.mu.Lock()
+	q.seq++
+	heap.Push(&q.items, queuedVisit{key: key, entry: entry, seq: q.seq})
+	q.mu.Unlock()
+}
+
+// next takes a slot and the best waiting page, if there are both
+func (q *visitQueue) next() (queuedVisit, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.free == 0 || len(q.items) == 0 {
+		return queuedVisit{}, false
+	}
+	q.free--
+	return heap.Pop(&q.items).(queuedVisit), true
+}
+
+func (q *visitQueue) release() {
+	q.mu.Lock()
+	q.free++
+	q.mu.Unlock()
+}
+
+// AddSeed adds another start URL. Seeds with a higher priority, and the
+// pages they link to directly, are fetched before those with a lower one;
+// the default is 0. Adding the start URL itself just sets its priority.
+// Call before Start.
+func (c *CrawlerTwoTier) AddSeed(seedURL string, priority int) {
+	if _, ok := c.seedPriority[seedURL]; !ok && seedURL != c.startURL {
+		c.seeds = append(c.seeds, seedURL)
+	}
+	c.seedPriority[seedURL] = priority
+}
+
+// hasSeedPriorities reports whether any seed was given a priority, one of
+// the times Start puts page requests through a visitQueue
+func (c *CrawlerTwoTier) hasSeedPriorities() bool {
+	for _, p := range c.seedPriority {
+		if p != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// schedule requests a page, through the visit queue if there is one
+func (c *CrawlerTwoTier) schedule(key string, entry FrontierEntry) {
+	if c.visits != nil {
+		c.queueVisit(key, entry)
+		c.dispatchVisits()
+		return
+	}
+	c.frontier.add(key, entry)
+	if err := c.requestPage(key, entry, false); err != nil {
+		// Never fetched (e.g. disallowed by robots.txt)
+		c.frontier.done(key)
+	}
+}
+
+// queueVisit adds a page to the visit queue without requesting anything,
+// so a batch is ranked as a whole before the first slot is handed out
+func (c *CrawlerTwoTier) queueVisit(key string, entry FrontierEntry) {
+	c.frontier.add(key, entry)
+	c.visits.push(key, entry)
+}
+
+// dispatchVisits requests queued pages while there are free slots
+func (c *CrawlerTwoTier) dispatchVisits() {
+	for {
+		v, ok := c.visits.next()
+		if !ok {
+			return
+		}
+		if err := c.requestPage(v.key, v.entry, true); err != nil {
+			c.frontier.done(v.key)
+			c.visits.release()
+			if v.entry.Depth == 0 {
+				logger.Errorf("❌ Failed to visit seed %s: %v\n", v.entry.URL, err)
+			}
+		}
+	}
+}
+
+// requestPage hands a page to the collector. queued marks it as holding a
+// visit queue slot, given back by markFetched.
+func (c *CrawlerTwoTier) requestPage(key string, entry FrontierEntry, queued bool) error {
+	ctx := colly.NewContext()
+	ctx.Put("depth", fmt.Sprintf("%d", entry.Depth))
+	ctx.Put("frontier", key)
+	if entry.Priority != 0 {
+		ctx.Put("priority", fmt.Sprintf("%d", entry.Priority))
+	}
+	if queued {
+		ctx.Put("queued", "1")
+	}
+	if entry.Fallback != "" {
+		ctx.Put("fallback", entry.Fallback)
+	}
+	return c.collector.Request("GET", entry.URL, nil, ctx, nil)
+}
+
+// releaseVisit gives back the visit queue slot held by r's request, once
+func (c *CrawlerTwoTier) releaseVisit(r *colly.Response) {
+	if c.visits == nil || r.Ctx.Get("queued") == "" {
+		return
+	}
+	r.Ctx.Put("queued", "")
+	c.visits.release()
+	c.dispatchVisits()
+}
+
+// visitSlots is how many queued pages may be in flight at once, matching
+// the collector's parallelism so the queue, not colly, picks the order
+func (c *CrawlerTwoTier) visitSlots() int {
+	if c.limitRule != nil {
+		return c.limitRule.Parallelism
+	}
+	return config.ConcurrentWorkers
+}
+
+// pagePriority returns the priority of the page behind ctx
+func pagePriority(ctx *colly.Context) int {
+	priority := 0
+	if p := ctx.Get("priority"); p != "" {
+		fmt.Sscanf(p, "%d", &priority)
+	}
+	return priority
+}
@@ -0,0 +1,29 @@
+package downloader
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// SetRetryBackoff sets the base and cap of the retry delay. Retry n waits a
+// random time in [0, min(base*2^n, cap)], so a burst of failures doesn't
+// come back as a burst of retries. Call before StartWorkers.
+func (m *Manager) SetRetryBackoff(base, maxDelay time.Duration) {
+	m.retryBase = base
+	m.retryCap = maxDelay
+}
+
+// retryDelay returns the full-jitter exponential backoff for retry n (from 1)
+func (m *Manager) retryDelay(retry int) time.Duration {
+	ceiling := m.retryBase
+	for i := 1; i < retry && ceiling < m.retryCap; i++ {
+		ceiling *= 2
+	}
+	if ceiling > m.retryCap {
+		ceiling = m.retryCap
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
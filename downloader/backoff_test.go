@@ -0,0 +1,78 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelayBounds(t *testing.T) {
+	m := &Manager{}
+	m.SetRetryBackoff(100*time.Millisecond, 1*time.Second)
+
+	tests := []struct {
+		retry   int
+		ceiling time.Duration // min(base*2^(retry-1), cap)
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, 1 * time.Second},
+		{20, 1 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			if d := m.retryDelay(tt.retry); d < 0 || d > tt.ceiling {
+				t.Fatalf("retryDelay(%d) = %v, want within [0, %v]", tt.retry, d, tt.ceiling)
+			}
+		}
+	}
+}
+
+func TestRetryDelayJitters(t *testing.T) {
+	m := &Manager{}
+	m.SetRetryBackoff(100*time.Millisecond, 30*time.Second)
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		seen[m.retryDelay(3)] = true
+	}
+	// Retry 3 draws from [0, 400ms] in nanoseconds, so repeats mean the
+	// delay isn't random
+	if len(seen) < 50 {
+		t.Fatalf("retryDelay gave only %d distinct delays in 100 calls", len(seen))
+	}
+}
+
+func TestRetryDelayZeroBase(t *testing.T) {
+	m := &Manager{}
+	m.SetRetryBackoff(0, time.Second)
+	if d := m.retryDelay(3); d != 0 {
+		t.Fatalf("retryDelay with no base = %v, want 0", d)
+	}
+}
+
+func TestRequeueAfterShutdownFailsTask(t *testing.T) {
+	m := newTestManager(t, 1)
+	m.SetRetryBackoff(0, 0) // The timer fires as soon as Shutdown has closed the queues
+
+	failed := make(chan string, 1)
+	m.OnDownloadFailed(func(meta DownloadMeta, err error) { failed <- meta.URL })
+	m.Shutdown()
+
+	task := DownloadTask{URL: "https://example.com/a.pdf", Retry: 1}
+	m.markPendingDownload(task)
+	m.requeue(task, DownloadMeta{URL: task.URL}, ErrNetwork)
+
+	select {
+	case u := <-failed:
+		if u != task.URL {
+			t.Fatalf("OnDownloadFailed got %s, want %s", u, task.URL)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a retry after Shutdown never reached OnDownloadFailed")
+	}
+	if f := m.GetFailedDownloads(); len(f) != 1 || f[0].URL != task.URL {
+		t.Errorf("GetFailedDownloads() = %+v", f)
+	}
+}
@@ -0,0 +1,282 @@
+package downloader
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// BreakerConfig sets when a host's circuit breaker opens and for how long
+type BreakerConfig struct {
+	Window      int           // Recent downloads from the host the failure rate is taken over
+	FailureRate float64       // Share of those that failed which opens the breaker
+	Cooldown    time.Duration // How long an open breaker holds tasks back before a probe
+}
+
+// DefaultBreakerConfig is the breaker SetCircuitBreaker is usually given
+var DefaultBreakerConfig = BreakerConfig{
+	Window:      config.BreakerWindow,
+	FailureRate: config.BreakerFailureRate,
+	Cooldown:    config.BreakerCooldown,
+}
+
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // Downloads run
+	breakerOpen                         // Cooling down: tasks are deferred
+	breakerHalfOpen                     // One probe download decides
+)
+
+// hostBreaker is one host's circuit breaker
+type hostBreaker struct {
+	state    breakerState
+	outcomes []bool // Ring of recent results, true for a failure
+	next     int
+	failures int
+	probing  bool // A probe download is in flight
+	deferred []DownloadTask
+}
+
+// breakers holds a circuit breaker per host. Failures are network errors,
+// 5xx and 429 responses; any other response shows the host is up.
+type breakers struct {
+	mu     sync.Mutex
+	cfg    BreakerConfig
+	hosts  map[string]*hostBreaker
+	trips  int64
+	parked int // Tasks deferred across all hosts
+}
+
+// SetCircuitBreaker stops downloading from a host once cfg.FailureRate of
+// its last cfg.Window downloads failed, e.g. because it is down or blocking
+// us. Its tasks are deferred, not failed, for a jittered cfg.Cooldown; then
+// one is let through as a probe. If the probe gets a response the host's
+// deferred tasks are queued again, otherwise it cools down once more.
+// Tasks still deferred at Shutdown fail with ErrCircuitOpen. A zero
+// Window disables the breakers. Call before StartWorkers.
+func (m *Manager) SetCircuitBreaker(cfg BreakerConfig) {
+	if cfg.Window <= 0 {
+		m.breakers = nil
+		return
+	}
+	m.breakers = &breakers{cfg: cfg, hosts: make(map[string]*hostBreaker)}
+}
+
+// GetCircuitBreakerStats returns how many times a host's breaker opened
+// and how many tasks are deferred now
+func (m *Manager) GetCircuitBreakerStats() (trips int64, deferred int) {
+	if m.breakers == nil {
+		return 0, 0
+	}
+	m.breakers.mu.Lock()
+	defer m.breakers.mu.Unlock()
+	return m.breakers.trips, m.breakers.parked
+}
+
+// host returns host's breaker, creating it closed. Callers hold b.mu.
+func (b *breakers) host(host string) *hostBreaker {
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostBreaker{outcomes: make([]bool, 0, b.cfg.Window)}
+		b.hosts[host] = h
+	}
+	return h
+}
+
+// allow reports whether task may be downloaded now. Otherwise it is
+// deferred until its host's breaker closes.
+func (b *breakers) allow(task DownloadTask) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(utils.HostOf(task.URL))
+	switch {
+	case h.state == breakerClosed:
+		return true
+	case h.state == breakerHalfOpen && !h.probing:
+		h.probing = true
+		return true
+	}
+	h.deferred = append(h.deferred, task)
+	b.parked++
+	return false
+}
+
+// hostFailure sorts a download result into a failure of the host, a sign
+// it is up, or neither (nothing was asked of it)
+func hostFailure(err error) (failed, known bool) {
+	var statusErr *HTTPStatusError
+	switch {
+	case err == nil, errors.Is(err, ErrNotModified), errors.Is(err, ErrSizeFiltered), errors.Is(err, ErrVerify):
+		return false, true
+	case errors.As(err, &statusErr):
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests, true
+	case errors.Is(err, ErrNetwork), errors.Is(err, ErrTimeout):
+		return true, true
+	}
+	return false, false
+}
+
+// record feeds the result of a download from rawURL to its host's breaker.
+// It returns the tasks to queue again, and whether the breaker opened.
+func (b *breakers) record(rawURL string, err error) (resume []DownloadTask, opened bool) {
+	failed, known := hostFailure(err)
+	host := utils.HostOf(rawURL)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(host)
+	if !known {
+		// A probe that made no request: let the next deferred task probe
+		if h.state == breakerHalfOpen && h.probing {
+			h.probing = false
+			return b.nextProbe(h), false
+		}
+		return nil, false
+	}
+
+	switch h.state {
+	case breakerClosed:
+		h.add(failed, b.cfg.Window)
+		if len(h.outcomes) == b.cfg.Window && float64(h.failures) >= b.cfg.FailureRate*float64(b.cfg.Window) {
+			h.state = breakerOpen
+			b.trips++
+			logger.Warnf("🔌 %s: %d of the last %d downloads failed, holding its tasks back for %v\n",
+				host, h.failures, b.cfg.Window, b.cfg.Cooldown)
+			return nil, true
+		}
+	case breakerHalfOpen:
+		h.probing = false
+		if failed {
+			h.state = breakerOpen
+			logger.Warnf("🔌 %s: probe failed (%v), holding its tasks back for %v more\n", host, err, b.cfg.Cooldown)
+			return nil, true
+		}
+		h.state = breakerClosed
+		h.outcomes, h.next, h.failures = h.outcomes[:0], 0, 0
+		resume, h.deferred = h.deferred, nil
+		b.parked -= len(resume)
+		logger.Infof("🔌 %s is responding again, resuming %d deferred downloads\n", host, len(resume))
+		return resume, false
+	}
+	// Open: downloads started before it opened don't change anything
+	return nil, false
+}
+
+// add puts a result in the ring of the last window results
+func (h *hostBreaker) add(failed bool, window int) {
+	if len(h.outcomes) < window {
+		h.outcomes = append(h.outcomes, failed)
+	} else {
+		if h.outcomes[h.next] {
+			h.failures--
+		}
+		h.outcomes[h.next] = failed
+		h.next = (h.next + 1) % window
+	}
+	if failed {
+		h.failures++
+	}
+}
+
+// halfOpen ends host's cooldown, returning the deferred task to probe it
+// with, if any. Otherwise the next task for the host is the probe.
+func (b *breakers) halfOpen(host string) []DownloadTask {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(host)
+	h.state = breakerHalfOpen
+	return b.nextProbe(h)
+}
+
+// nextProbe takes a deferred task out to probe h with. Whichever task
+// for the host reaches a worker first is the probe. Callers hold b.mu.
+func (b *breakers) nextProbe(h *hostBreaker) []DownloadTask {
+	if len(h.deferred) == 0 {
+		return nil
+	}
+	probe := h.deferred[0]
+	h.deferred = h.deferred[1:]
+	b.parked--
+	return []DownloadTask{probe}
+}
+
+// drain takes every deferred task, leaving the breakers empty
+func (b *breakers) drain() []DownloadTask {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var tasks []DownloadTask
+	for _, h := range b.hosts {
+		tasks = append(tasks, h.deferred...)
+		h.deferred = nil
+	}
+	b.parked = 0
+	return tasks
+}
+
+// recordBreaker feeds a download result to the circuit breakers, queueing
+// tasks a closed breaker lets go and timing the cooldown of one that opened
+func (m *Manager) recordBreaker(rawURL string, err error) {
+	resume, opened := m.breakers.record(rawURL, err)
+	m.resubmit(resume)
+	if !opened {
+		return
+	}
+
+	// Jittered, so hosts that failed together aren't probed together
+	cooldown := m.breakers.cfg.Cooldown + rand.N(m.breakers.cfg.Cooldown/4+1)
+	host := utils.HostOf(rawURL)
+	panics.Go("circuit breaker", host, func() {
+		timer := time.NewTimer(cooldown)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			m.resubmit(m.breakers.halfOpen(host))
+		case <-m.shutdownChan:
+		}
+	})
+}
+
+// resubmit queues deferred tasks again, waiting for room rather than
+// failing them when the queue is full
+func (m *Manager) resubmit(tasks []DownloadTask) {
+	if len(tasks) == 0 {
+		return
+	}
+	panics.Go("circuit breaker", tasks[0].URL, func() {
+		for _, t := range tasks {
+			for {
+				if m.ShuttingDown() {
+					m.failDeferred([]DownloadTask{t})
+					break
+				}
+				select {
+				case m.retryQueue(t) <- t:
+				default:
+					time.Sleep(config.IdlePollInterval)
+					continue
+				}
+				break
+			}
+		}
+	})
+}
+
+// failDeferred gives up on tasks held back by a circuit breaker
+func (m *Manager) failDeferred(tasks []DownloadTask) {
+	for _, t := range tasks {
+		m.markDownloadFailed(t.URL, t.Retry, ErrCircuitOpen)
+		m.notifyDownloadFailed(DownloadMeta{URL: t.URL, Depth: t.Depth}, ErrCircuitOpen)
+	}
+}
@@ -0,0 +1,52 @@
+package downloader
+
+import "github.com/jeb/url_crawler/panics"
+
+// PendingTasks returns the downloads queued, waiting for a retry or in
+// progress, for checkpointing
+func (m *Manager) PendingTasks() []DownloadTask {
+	m.mapMutex.RLock()
+	defer m.mapMutex.RUnlock()
+
+	tasks := make([]DownloadTask, 0, len(m.pendingDownloads))
+	for _, task := range m.pendingDownloads {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// DownloadedURLs returns every URL downloaded so far
+func (m *Manager) DownloadedURLs() []string {
+	m.mapMutex.RLock()
+	defer m.mapMutex.RUnlock()
+
+	urls := make([]string, 0, len(m.downloadedFiles))
+	for u := range m.downloadedFiles {
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// RestoreDownloads resumes from a checkpoint: downloaded URLs are not
+// fetched again and pending tasks are queued again with fresh retries.
+// Call after StartWorkers.
+func (m *Manager) RestoreDownloads(downloaded []string, pending []DownloadTask) {
+	m.mapMutex.Lock()
+	for _, u := range downloaded {
+		m.downloadedFiles[u] = true
+	}
+	m.mapMutex.Unlock()
+
+	panics.Go("restore downloads", "", func() {
+		for _, task := range pending {
+			task.Retry = 0
+			// The interface may be gone since the checkpoint was written
+			if task.Pinned && (task.InterfaceID < 0 || task.InterfaceID >= len(m.networkInterfaces)) {
+				task.Pinned = false
+			}
+			if !m.EnqueueTask(task) && !m.IsDownloadedOrPending(task.URL) {
+				m.PersistentEnqueue(task)
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package downloader
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// SetConditionalRequests sends If-Modified-Since, with the file's
+// modification time, when a document's file is already on disk from an
+// earlier crawl. A 304 Not Modified keeps that file and is counted apart
+// from downloads (GetNotModifiedCount); the manifest records it as
+// "not_modified". The Skip overwrite policy never sends the request, so it
+// is unaffected. Call before StartWorkers.
+func (m *Manager) SetConditionalRequests(enabled bool) {
+	m.conditional = enabled
+}
+
+// GetNotModifiedCount returns how many documents the server reported
+// unchanged since the saved copy
+func (m *Manager) GetNotModifiedCount() int64 {
+	return atomic.LoadInt64(&m.stats.notModified)
+}
+
+// existingFile returns the file docURL would be saved as, if one is
+// already in an output directory
+func (m *Manager) existingFile(docURL string) (string, os.FileInfo) {
+	for _, dir := range m.outputDirs.dirs {
+		path := m.fileOwners.resolve(filepath.Join(dir, m.outputFilename(docURL, nil)), docURL)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, info
+		}
+	}
+	return "", nil
+}
+
+// makeConditional adds If-Modified-Since to req when docURL's file already
+// exists, returning that file or ""
+func (m *Manager) makeConditional(req *http.Request, docURL string) (string, os.FileInfo) {
+	if !m.conditional {
+		return "", nil
+	}
+	path, info := m.existingFile(docURL)
+	if path != "" {
+		req.Header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+	return path, info
+}
+
+// notModified fills in meta for a 304 answer about the saved file at path
+func notModified(meta DownloadMeta, path string, info os.FileInfo) (DownloadMeta, error) {
+	meta.Path = path
+	meta.Bytes = info.Size()
+	meta.CompletedAt = time.Now()
+	return meta, ErrNotModified
+}
@@ -0,0 +1,62 @@
+package downloader
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+// contentTypeStats tallies the media types of fetched pages and documents
+type contentTypeStats struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newContentTypeStats() *contentTypeStats {
+	return &contentTypeStats{counts: make(map[string]int64)}
+}
+
+func (s *contentTypeStats) record(contentType string) {
+	mediaType := "(none)"
+	if contentType != "" {
+		if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+			mediaType = mt
+		} else {
+			mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+		}
+	}
+
+	s.mu.Lock()
+	s.counts[mediaType]++
+	s.mu.Unlock()
+}
+
+func (s *contentTypeStats) snapshot() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]int64, len(s.counts))
+	for mediaType, n := range s.counts {
+		out[mediaType] = n
+	}
+	return out
+}
+
+func (s *contentTypeStats) reset() {
+	s.mu.Lock()
+	clear(s.counts)
+	s.mu.Unlock()
+}
+
+// RecordContentType counts the Content-Type of a response from the crawler
+// or downloader. Parameters such as charset are dropped.
+func (m *Manager) RecordContentType(contentType string) {
+	m.recordStat(func() { m.contentTypes.record(contentType) })
+}
+
+// GetContentTypeDistribution returns how many responses had each media
+// type, e.g. text/html or application/pdf. Responses without a
+// Content-Type are counted as "(none)".
+func (m *Manager) GetContentTypeDistribution() map[string]int64 {
+	return m.contentTypes.snapshot()
+}
@@ -0,0 +1,80 @@
+package downloader
+
+import (
+	"sync"
+
+	"github.com/jeb/url_crawler/utils"
+)
+
+// DomainStat holds per-host crawl and download counters
+type DomainStat struct {
+	PagesCrawled    int64
+	DocumentsFound  int64
+	BytesDownloaded int64
+	Errors          int64
+}
+
+// domainStats tracks statistics keyed by host
+type domainStats struct {
+	mu    sync.Mutex
+	hosts map[string]*DomainStat
+}
+
+func newDomainStats() *domainStats {
+	return &domainStats{hosts: make(map[string]*DomainStat)}
+}
+
+// update applies fn to the stat for the host of rawURL
+func (d *domainStats) update(rawURL string, fn func(s *DomainStat)) {
+	host := utils.HostOf(rawURL)
+	if host == "" {
+		return
+	}
+
+	d.mu.Lock()
+	s, ok := d.hosts[host]
+	if !ok {
+		s = &DomainStat{}
+		d.hosts[host] = s
+	}
+	fn(s)
+	d.mu.Unlock()
+}
+
+// snapshot returns a copy of all per-host stats
+func (d *domainStats) snapshot() map[string]DomainStat {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make(map[string]DomainStat, len(d.hosts))
+	for host, s := range d.hosts {
+		out[host] = *s
+	}
+	return out
+}
+
+func (d *domainStats) reset() {
+	d.mu.Lock()
+	clear(d.hosts)
+	d.mu.Unlock()
+}
+
+// RecordPageCrawled counts a crawled page against its host
+func (m *Manager) RecordPageCrawled(pageURL string) {
+	m.recordStat(func() { m.domainStats.update(pageURL, func(s *DomainStat) { s.PagesCrawled++ }) })
+}
+
+// RecordDocumentFound counts a detected document against its host
+func (m *Manager) RecordDocumentFound(docURL string) {
+	m.recordStat(func() { m.domainStats.update(docURL, func(s *DomainStat) { s.DocumentsFound++ }) })
+}
+
+// RecordCrawlError counts a failed page fetch against its host
+func (m *Manager) RecordCrawlError(pageURL string) {
+	m.recordStat(func() { m.domainStats.update(pageURL, func(s *DomainStat) { s.Errors++ }) })
+}
+
+// GetDomainStats returns a copy of the per-host statistics
+func (m *Manager) GetDomainStats() map[string]DomainStat {
+	return m.domainStats.snapshot()
+}
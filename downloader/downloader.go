@@ -2,9 +2,12 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"path/filepath"
 	"sync"
@@ -12,26 +15,48 @@ import (
 	"time"
 
 	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
 	"github.com/jeb/url_crawler/network"
+	"github.com/jeb/url_crawler/panics"
 	"github.com/jeb/url_crawler/utils"
+	"github.com/jeb/url_crawler/warc"
 	"golang.org/x/time/rate"
 )
 
+// AutoInterface is the interface index that lets the manager pick the
+// network interface for a task, e.g. from a crawler's interface affinity
+const AutoInterface = -1
+
 // DownloadTask represents a download task
 type DownloadTask struct {
 	URL         string
 	Depth       int
 	Retry       int
 	Priority    bool
-	InterfaceID int // Which network interface to use
+	Pinned      bool // Download only on InterfaceID; otherwise load-balanced
+	InterfaceID int  // Network interface index, used when Pinned
+	MaxRetries  int  // Retry budget; 0 uses config.MaxRetries, negative disables retries
+}
+
+// retryBudget returns how many retries the task is allowed
+func (t DownloadTask) retryBudget() int {
+	if t.MaxRetries == 0 {
+		return config.MaxRetries
+	}
+	return max(t.MaxRetries, 0)
 }
 
 // Manager manages the download system
 type Manager struct {
 	networkInterfaces     []network.NetworkInterface
 	downloadQueues        []chan DownloadTask
+	hostQueues            []chan DownloadTask // non-nil in host-sharded mode
 	priorityQueue         chan DownloadTask
-	downloadLimiter       *rate.Limiter
+	downloadLimiter       *rate.Limiter   // nil unless SetDownloadRate
+	interfaceLimiters     []*rate.Limiter // one per interface, see SetInterfaceRate
+	hostLimiters          *hostLimiters   // nil unless SetPerHostRate
+	activeHosts           *activeHosts    // nil unless SetMaxActiveHosts
+	breakers              *breakers       // nil unless SetCircuitBreaker
 	downloadWG            sync.WaitGroup
 	activeWorkers         int64
 	shutdownChan          chan struct{}
@@ -39,21 +64,72 @@ type Manager struct {
 
 	// State management
 	downloadedFiles  map[string]bool
-	pendingDownloads map[string]bool
-	failedDownloads  map[string]int
+	pendingDownloads map[string]DownloadTask
+	failedDownloads  map[string]*FailedDownload
+	retryBase        time.Duration
+	initialWorkers   int
+	priorityEvery    int // see SetPriorityPreference
+	maxWorkers       int
+	retryCap         time.Duration
+	dropped          droppedTasks
 	mapMutex         *sync.RWMutex
 
+	// Per-host and per-status statistics
+	domainStats  *domainStats
+	statusStats  *statusStats
+	contentTypes *contentTypeStats
+	hostRates    *hostRequestRates
+	timing       *timingStats
+
+	// Post-download processing
+	postProcessors   []PostProcessor
+	postProcessMutex sync.RWMutex
+	postProcessOnce  sync.Once
+	postProcessQueue chan DownloadMeta
+	postProcessWG    sync.WaitGroup
+	postProcessBusy  int64 // Downloads queued or running in the processor chain
+	postProcessDrops int64 // Downloads not post-processed because the queue was full
+	failureHooks     []func(meta DownloadMeta, err error)
+
+	// What to do when a download's file already exists
+	overwritePolicy OverwritePolicy
+	fileOwners      *fileOwners
+	storage         *storageGuard
+	outputDirs      *dirSelector
+	filenameMode    FilenameMode
+	filenameFunc    FilenameFunc // nil unless SetFilenameFunc
+	noSniff         bool         // don't infer missing extensions from content
+	verify          bool         // SetVerifyDownloads
+	captureHeaders  bool         // SetCaptureHeaders
+	conditional     bool         // SetConditionalRequests
+	successCodes    map[int]bool
+	minSize         int64 // SetSizeFilter bounds; 0 means open
+	maxSize         int64
+	manifest        *manifest // nil unless SetManifest
+
+	// Optional WARC archiving of document responses
+	warcWriter *warc.Writer
+
+	// file:// support for local crawls
+	localFilesOnce sync.Once
+
 	// File paths
-	targetDir       string
 	downloadLogPath string
 
-	// Statistics
-	stats struct {
-		downloadAttempts int64
-		downloadSuccess  int64
-		downloadFailed   int64
-		bytesDownloaded  int64
-		startTime        time.Time
+	// Statistics. Updates hold statsMu shared, Snapshot and ResetStats hold
+	// it exclusively, so a snapshot never sees an update half-applied.
+	statsMu sync.RWMutex
+	stats   struct {
+		downloadAttempts     int64
+		downloadSuccess      int64
+		downloadFailed       int64
+		downloadSkipped      int64
+		downloadSizeFiltered int64
+		bytesDownloaded      int64
+		verified             int64
+		verifyFailed         int64
+		notModified          int64
+		startTime            time.Time
 	}
 }
 
@@ -64,10 +140,23 @@ func NewManager(networkInterfaces []network.NetworkInterface, targetDir, downloa
 		downloadQueues:    make([]chan DownloadTask, len(networkInterfaces)),
 		priorityQueue:     make(chan DownloadTask, config.MaxQueueSize),
 		downloadedFiles:   make(map[string]bool),
-		pendingDownloads:  make(map[string]bool),
-		failedDownloads:   make(map[string]int),
+		pendingDownloads:  make(map[string]DownloadTask),
+		failedDownloads:   make(map[string]*FailedDownload),
+		retryBase:         config.RetryBackoff,
+		initialWorkers:    config.InitialDownloadWorkers,
+		priorityEvery:     1,
+		successCodes:      map[int]bool{http.StatusOK: true},
+		maxWorkers:        config.MaxDownloadWorkers,
+		retryCap:          config.RetryBackoffCap,
 		mapMutex:          &sync.RWMutex{},
-		targetDir:         targetDir,
+		domainStats:       newDomainStats(),
+		statusStats:       newStatusStats(),
+		contentTypes:      newContentTypeStats(),
+		hostRates:         newHostRequestRates(),
+		timing:            newTimingStats(),
+		fileOwners:        newFileOwners(),
+		storage:           newStorageGuard([]string{targetDir}),
+		outputDirs:        newDirSelector([]string{targetDir}, DirRoundRobin),
 		downloadLogPath:   downloadLogPath,
 		shutdownChan:      make(chan struct{}),
 	}
@@ -78,8 +167,11 @@ func NewManager(networkInterfaces []network.NetworkInterface, targetDir, downloa
 		m.downloadQueues[i] = make(chan DownloadTask, queueSize)
 	}
 
-	// Ultra-permissive rate limiting
-	m.downloadLimiter = rate.NewLimiter(rate.Every(10*time.Microsecond), config.MaxDownloadWorkers*3)
+	// Each NIC runs at its own pace
+	m.interfaceLimiters = make([]*rate.Limiter, len(networkInterfaces))
+	for i, iface := range networkInterfaces {
+		m.interfaceLimiters[i] = newInterfaceLimiter(iface)
+	}
 
 	m.stats.startTime = time.Now()
 
@@ -88,159 +180,483 @@ func NewManager(networkInterfaces []network.NetworkInterface, targetDir, downloa
 
 // StartWorkers starts download workers distributed across interfaces
 func (m *Manager) StartWorkers() {
-	fmt.Println("\n👥 Starting multi-NIC workers...")
+	logger.Infof("\n👥 Starting multi-NIC workers...\n")
 
 	totalWorkers := 0
+	// Each interface gets its share of the initial workers, rounded up so
+	// every interface has one to drain its queue
+	share := (m.initialWorkers + len(m.networkInterfaces) - 1) / len(m.networkInterfaces)
 	for i, iface := range m.networkInterfaces {
-		workers := min(iface.WorkerCount, config.InitialDownloadWorkers/len(m.networkInterfaces)+100)
+		workers := min(iface.WorkerCount, share)
 		for j := 0; j < workers; j++ {
 			m.downloadWG.Add(1)
-			go m.multiNICDownloadWorker(i, j%len(iface.Clients))
+			go m.multiNICDownloadWorker(i, j%len(iface.Clients), 0)
 			atomic.AddInt64(&m.activeWorkers, 1)
 			totalWorkers++
 		}
 
-		fmt.Printf("🚀 %s: Started %d workers\n", iface.Name, workers)
+		logger.Infof("🚀 %s: Started %d workers\n", iface.Name, workers)
 	}
 
-	fmt.Printf("💪 Total workers started: %d\n", totalWorkers)
+	logger.Infof("💪 Total workers started: %d\n", totalWorkers)
 }
 
 // multiNICDownloadWorker processes downloads on a specific network interface
-func (m *Manager) multiNICDownloadWorker(interfaceID, clientIndex int) {
+func (m *Manager) multiNICDownloadWorker(interfaceID, clientIndex int, startDelay time.Duration) {
 	defer m.downloadWG.Done()
 	defer atomic.AddInt64(&m.activeWorkers, -1)
 
+	if startDelay > 0 {
+		select {
+		case <-time.After(startDelay):
+		case <-m.shutdownChan:
+		}
+	}
+
 	iface := m.networkInterfaces[interfaceID]
 	client := iface.Clients[clientIndex]
 	workerName := fmt.Sprintf("%s-W%d", iface.Name, clientIndex)
+	shardCursor := interfaceID + clientIndex
 
-	for {
+	for iteration := 0; ; iteration++ {
 		var task DownloadTask
 		var ok bool
-
-		// Check priority queue first
-		select {
-		case task, ok = <-m.priorityQueue:
-			if !ok {
-				// Priority queue closed
-			} else {
-				goto processTask
+		var admitted bool // task already holds an active-host slot
+
+		// Check priority queue first, unless the preference is capped
+		preferPriority := m.prefersPriority(iteration)
+		if preferPriority {
+			select {
+			case task, ok = <-m.priorityQueue:
+				if !ok {
+					// Priority queue closed
+				} else {
+					goto processTask
+				}
+			default:
+				// Priority queue empty
 			}
-		default:
-			// Priority queue empty
 		}
 
 		// Check interface-specific queue
 		select {
 		case task, ok = <-m.downloadQueues[interfaceID]:
 			if !ok {
-				// Interface queue closed
+				// Interface queue closed, finish any sharded work first
+				if task, ok = m.takeFromShards(&shardCursor); ok {
+					goto processTask
+				}
 				return
 			}
 		default:
-			// No work available, sleep briefly
-			time.Sleep(1 * time.Millisecond)
+			// Priority work passed over above still runs before going idle
+			if !preferPriority {
+				select {
+				case task, ok = <-m.priorityQueue:
+					if ok {
+						goto processTask
+					}
+				default:
+				}
+			}
+
+			// Host shards (no-op unless sharding is enabled)
+			if task, ok = m.takeFromShards(&shardCursor); ok {
+				goto processTask
+			}
+
+			// No work available, sleep briefly (jittered so idle workers desynchronize)
+			time.Sleep(1*time.Millisecond + rand.N(config.WorkerIdleJitter))
 			continue
 		}
 
 	processTask:
-		// Rate limiting
-		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
-		m.downloadLimiter.Wait(ctx)
-		cancel()
+		// Hosts that keep failing are left alone for a while
+		if m.breakers != nil && !admitted && !m.breakers.allow(task) {
+			continue
+		}
 
-		atomic.AddInt64(&m.stats.downloadAttempts, 1)
+		// Cap on distinct hosts in flight; parked tasks come back via release
+		if m.activeHosts != nil && !admitted && !m.activeHosts.acquire(task) {
+			continue
+		}
+		admitted = false
 
-		err := m.downloadDocument(task.URL, client, workerName)
-		if err != nil {
-			atomic.AddInt64(&m.stats.downloadFailed, 1)
+		m.runTask(task, interfaceID, client, workerName)
 
-			if task.Retry < config.MaxRetries {
-				task.Retry++
-				task.Priority = true
-				task.InterfaceID = interfaceID
-
-				go func(t DownloadTask) {
-					time.Sleep(config.RetryBackoff * time.Duration(t.Retry))
-					select {
-					case m.priorityQueue <- t:
-						// Successfully re-queued
-					default:
-						m.markDownloadFailed(t.URL)
-					}
-				}(task)
-			} else {
-				m.markDownloadFailed(task.URL)
+		if m.activeHosts != nil {
+			if task, admitted = m.activeHosts.release(task.URL); admitted {
+				goto processTask
 			}
+		}
+	}
+}
+
+// runTask downloads task and settles it as completed, failed or requeued.
+// A panic outside the download itself fails the task if it is still
+// pending, so the worker goes on to its next task instead of dying.
+func (m *Manager) runTask(task DownloadTask, interfaceID int, client *http.Client, workerName string) {
+	defer func() {
+		if value := recover(); value != nil {
+			panics.Record(workerName, task.URL, value)
+			m.failIfPending(task, fmt.Errorf("%w: %v", ErrPanic, value))
+		}
+	}()
+
+	// Hold off while the target directory is unwritable
+	m.storage.wait(m.shutdownChan)
+
+	// Rate limiting
+	m.waitForRate(interfaceID)
+
+	if m.hostLimiters != nil {
+		m.hostLimiters.wait(task.URL)
+	}
+
+	m.addStat(&m.stats.downloadAttempts, 1)
+
+	meta, err := m.fetchDocument(task.URL, client, workerName)
+	meta.Depth = task.Depth
+	meta.Interface = m.networkInterfaces[interfaceID].Name
+	if m.breakers != nil {
+		m.recordBreaker(task.URL, err)
+	}
+	if errors.Is(err, ErrFileExists) {
+		m.addStat(&m.stats.downloadSkipped, 1)
+		m.markDownloadCompleted(task.URL)
+	} else if errors.Is(err, ErrNotModified) {
+		m.addStat(&m.stats.notModified, 1)
+		if m.manifest != nil {
+			m.manifest.record(meta, "not_modified")
+		}
+		m.markDownloadCompleted(task.URL)
+	} else if errors.Is(err, ErrSizeFiltered) {
+		m.addStat(&m.stats.downloadSizeFiltered, 1)
+		m.markDownloadCompleted(task.URL)
+	} else if errors.Is(err, ErrPanic) {
+		// Would only panic again
+		m.addStat(&m.stats.downloadFailed, 1)
+		m.markDownloadFailed(task.URL, task.Retry+1, err)
+		m.notifyDownloadFailed(meta, err)
+	} else if isStorageError(err) {
+		// Not the server's fault: retry without using up the task's retries
+		m.addStat(&m.stats.downloadFailed, 1)
+		m.storage.failed(err, m.shutdownChan)
+		m.requeue(task, meta, err)
+	} else if err != nil {
+		m.recordStat(func() {
+			atomic.AddInt64(&m.stats.downloadFailed, 1)
+			m.domainStats.update(task.URL, func(s *DomainStat) { s.Errors++ })
+		})
+
+		if task.Retry < task.retryBudget() {
+			task.Retry++
+			m.requeue(task, meta, err)
 		} else {
+			m.markDownloadFailed(task.URL, task.Retry+1, err)
+			m.notifyDownloadFailed(meta, err)
+		}
+	} else {
+		m.recordStat(func() {
 			atomic.AddInt64(&m.stats.downloadSuccess, 1)
-			m.markDownloadCompleted(task.URL)
+			m.timing.record(meta)
+		})
+		m.storage.succeeded()
+		if m.manifest != nil {
+			m.manifest.record(meta, "downloaded")
+		}
+		// Queued for post-processing before it stops being pending, so
+		// WaitIdle never sees it in neither state
+		m.submitPostProcess(meta)
+		m.markDownloadCompleted(task.URL)
+	}
+}
+
+// failIfPending fails task unless it was already settled
+func (m *Manager) failIfPending(task DownloadTask, err error) {
+	m.mapMutex.RLock()
+	_, pending := m.pendingDownloads[task.URL]
+	m.mapMutex.RUnlock()
+	if !pending {
+		return
+	}
+	m.addStat(&m.stats.downloadFailed, 1)
+	m.markDownloadFailed(task.URL, task.Retry+1, err)
+	m.notifyDownloadFailed(DownloadMeta{URL: task.URL, Depth: task.Depth}, err)
+}
+
+// requeue puts a failed task back on the priority queue (or its pinned
+// interface queue) after a backoff, giving up if the queue is full
+func (m *Manager) requeue(task DownloadTask, meta DownloadMeta, err error) {
+	task.Priority = true
+
+	go func(t DownloadTask) {
+		defer panics.Recover("download retry", t.URL)
+
+		// The retry never runs if the manager shuts down or the queue is
+		// full. Network errors counted it when requeueing; storage errors
+		// don't use up retries.
+		giveUp := func() {
+			attempts := t.Retry
+			if isStorageError(err) {
+				attempts++
+			}
+			m.markDownloadFailed(t.URL, attempts, err)
+			m.notifyDownloadFailed(meta, err)
+		}
+
+		timer := time.NewTimer(m.retryDelay(t.Retry))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-m.shutdownChan:
+			giveUp()
+			return
+		}
+
+		if m.ShuttingDown() || !m.sendRetry(t) {
+			giveUp()
 		}
+	}(task)
+}
+
+// sendRetry puts t back on its queue without waiting. It reports false if
+// the queue is full, or was closed by a Shutdown that raced the send.
+func (m *Manager) sendRetry(t DownloadTask) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+
+	select {
+	case m.retryQueue(t) <- t:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryQueue is where task goes back to: the priority queue, or its own
+// interface's queue if it is pinned
+func (m *Manager) retryQueue(task DownloadTask) chan DownloadTask {
+	if task.Pinned {
+		return m.downloadQueues[task.InterfaceID]
 	}
+	return m.priorityQueue
+}
+
+// fetchDocument is downloadDocument with a panic returned as ErrPanic, so
+// one bad document can't take its worker down
+func (m *Manager) fetchDocument(docURL string, client *http.Client, workerName string) (meta DownloadMeta, err error) {
+	meta = DownloadMeta{URL: docURL}
+	defer recoveredPanic(workerName, docURL, &err)
+	return m.downloadDocument(docURL, client, workerName)
 }
 
 // downloadDocument downloads a document using the specified HTTP client
-func (m *Manager) downloadDocument(docURL string, client *http.Client, workerName string) error {
+func (m *Manager) downloadDocument(docURL string, client *http.Client, workerName string) (DownloadMeta, error) {
+	meta := DownloadMeta{URL: docURL}
+
 	req, err := http.NewRequestWithContext(context.Background(), "GET", docURL, nil)
 	if err != nil {
-		return err
+		return meta, err
 	}
 	req.Header.Set("User-Agent", config.UserAgent)
 	req.Header.Set("Accept", "*/*")
 	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
 	req.Header.Set("Connection", "keep-alive")
 
+	// Under Skip, avoid the request entirely when the URL's file is already
+	// in any output directory
+	if m.overwritePolicy == Skip {
+		if path, _ := m.existingFile(docURL); path != "" {
+			return meta, ErrFileExists
+		}
+	}
+	savedPath, savedInfo := m.makeConditional(req, docURL)
+
+	// Hold an in-flight slot (see network.SetMaxInflight) until the body is read
+	release, err := network.AcquireInflight(req.Context())
+	if err != nil {
+		return meta, err
+	}
+	defer release()
+
+	// TTFB runs from sending the request to the first response byte;
+	// the transfer takes the rest
+	sent := time.Now()
+	m.recordStat(func() { m.hostRates.record(docURL, sent) })
+	var firstByte time.Time
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}))
+
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		m.RecordStatus(StatusNetworkError)
+		return meta, networkError(err)
 	}
 	defer resp.Body.Close()
+	if firstByte.IsZero() {
+		firstByte = time.Now() // transports that don't report trace events
+	}
+	meta.TTFB = firstByte.Sub(sent)
+	m.RecordStatus(resp.StatusCode)
+	meta.StatusCode = resp.StatusCode
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusNotModified && savedPath != "" {
+		return notModified(meta, savedPath, savedInfo)
+	}
+	if !m.successCodes[resp.StatusCode] {
+		return meta, &HTTPStatusError{Code: resp.StatusCode}
 	}
+	m.RecordContentType(resp.Header.Get("Content-Type"))
 
-	filename := utils.ExtractFilename(docURL, resp.Header)
-	path := filepath.Join(m.targetDir, filename)
+	if resp.ContentLength >= 0 && !m.sizeAllowed(resp.ContentLength) {
+		return meta, ErrSizeFiltered
+	}
 
-	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	filename := m.outputFilename(docURL, resp)
+	// Counted whether or not the server sent a Content-Length
+	var body io.Reader = &maxSizeReader{r: resp.Body, remaining: m.sizeLimit()}
+	if !m.noSniff {
+		if filename, body, err = sniffExtension(filename, resp.Header, body); err != nil {
+			if errors.Is(err, ErrSizeFiltered) {
+				return meta, err
+			}
+			return meta, networkError(err)
+		}
+	}
+	var check *verifier
+	if m.verify {
+		check = newVerifier(resp.Header)
+		body = io.TeeReader(body, check)
+	}
+	path := m.fileOwners.claim(filepath.Join(m.outputDirs.pick(docURL), filename), docURL)
+	if m.filenameFunc != nil {
+		// Custom names may place files in subdirectories
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return meta, &storageError{err}
+		}
+	}
+
+	out, path, err := m.createOutputFile(path)
 	if err != nil {
-		return err
+		return meta, &storageError{err}
 	}
 	defer out.Close()
 
 	// Use massive buffer optimized for 10GbE
 	buf := make([]byte, config.DownloadBufferSize)
-	written, err := io.CopyBuffer(out, resp.Body, buf)
+	written, err := io.CopyBuffer(storageWriter{out}, body, buf)
+	if err == nil && !m.sizeAllowed(written) {
+		err = ErrSizeFiltered
+	}
+	if errors.Is(err, ErrSizeFiltered) {
+		out.Close()
+		os.Remove(path)
+		if written > m.sizeLimit() {
+			logger.Warnf("⚠️ %s sent more than %s (Content-Length: %d), download aborted\n",
+				docURL, utils.FormatBytes(m.sizeLimit()), resp.ContentLength)
+		}
+		return meta, err
+	}
+	if err != nil && !isStorageError(err) {
+		err = networkError(err)
+	}
+	if err == nil && check != nil {
+		if err = out.Sync(); err != nil {
+			err = &storageError{err}
+		} else if err = check.check(path); errors.Is(err, ErrVerify) {
+			out.Close()
+			os.Remove(path)
+			m.addStat(&m.stats.verifyFailed, 1)
+			logger.Warnf("⚠️ %s failed verification, file removed: %v\n", docURL, err)
+		} else if err == nil {
+			m.addStat(&m.stats.verified, 1)
+		}
+	}
 
 	if err == nil {
-		atomic.AddInt64(&m.stats.bytesDownloaded, written)
+		m.recordStat(func() {
+			atomic.AddInt64(&m.stats.bytesDownloaded, written)
+			m.domainStats.update(docURL, func(s *DomainStat) { s.BytesDownloaded += written })
+		})
+
+		meta.Path = path
+		meta.Bytes = written
+		meta.ContentType = resp.Header.Get("Content-Type")
+		meta.CompletedAt = time.Now()
+		meta.TransferTime = meta.CompletedAt.Sub(firstByte)
+
+		if m.captureHeaders {
+			writeHeaders(path, docURL, resp, meta.CompletedAt)
+		}
+		if m.warcWriter != nil {
+			m.archiveDownload(req, resp, path, written)
+		}
 	}
 
-	return err
+	return meta, err
 }
 
-// EnqueueTask adds a task to the download queue
+// SetWARCWriter archives every successful document response to w
+// in addition to the flat file. Call before StartWorkers.
+func (m *Manager) SetWARCWriter(w *warc.Writer) {
+	m.warcWriter = w
+}
+
+// archiveDownload writes a downloaded file to the WARC writer
+func (m *Manager) archiveDownload(req *http.Request, resp *http.Response, path string, size int64) {
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Errorf("❌ WARC: cannot reopen %s: %v\n", path, err)
+		return
+	}
+	defer f.Close()
+
+	err = m.warcWriter.WriteExchange(warc.Exchange{
+		URL:            req.URL.String(),
+		Method:         req.Method,
+		RequestHeader:  req.Header,
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header,
+		Body:           f,
+		BodyLength:     size,
+	})
+	if err != nil {
+		logger.Errorf("❌ WARC: failed to archive %s: %v\n", req.URL, err)
+	}
+}
+
+// EnqueueTask adds a task to the download queue. Tasks are load-balanced
+// across interfaces unless Pinned, which keeps them on InterfaceID's queue.
 func (m *Manager) EnqueueTask(task DownloadTask) bool {
 	if m.IsDownloadedOrPending(task.URL) {
 		return false
 	}
 
+	if task.Pinned {
+		return m.enqueuePinned(task)
+	}
+	if m.hostQueues != nil {
+		return m.enqueueShard(task)
+	}
+
 	// Load-balanced interface selection
 	interfaceID := int(atomic.AddInt64(&m.currentInterfaceIndex, 1)) % len(m.networkInterfaces)
-	task.InterfaceID = interfaceID
 
 	// Try interface-specific queue
 	select {
 	case m.downloadQueues[interfaceID] <- task:
-		m.markPendingDownload(task.URL)
+		m.markPendingDownload(task)
 		return true
 	default:
 		// Queue full, try priority queue
 		select {
 		case m.priorityQueue <- task:
-			m.markPendingDownload(task.URL)
+			m.markPendingDownload(task)
 			return true
 		default:
 			// Both queues full
@@ -249,23 +665,52 @@ func (m *Manager) EnqueueTask(task DownloadTask) bool {
 	}
 }
 
+// enqueuePinned queues a task on its requested interface only.
+// The shared priority queue is skipped since any interface may drain it.
+func (m *Manager) enqueuePinned(task DownloadTask) bool {
+	if task.InterfaceID < 0 || task.InterfaceID >= len(m.downloadQueues) {
+		return false
+	}
+
+	select {
+	case m.downloadQueues[task.InterfaceID] <- task:
+		m.markPendingDownload(task)
+		return true
+	default:
+		return false
+	}
+}
+
 // PersistentEnqueue tries persistently to enqueue a task
 func (m *Manager) PersistentEnqueue(task DownloadTask) {
 	maxAttempts := 50
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		time.Sleep(time.Duration(attempt*50) * time.Millisecond)
 
+		if task.Pinned {
+			if m.enqueuePinned(task) {
+				return
+			}
+			continue
+		}
+		if m.hostQueues != nil {
+			if m.enqueueShard(task) {
+				return
+			}
+			continue
+		}
+
 		// Try priority queue first
 		select {
 		case m.priorityQueue <- task:
-			m.markPendingDownload(task.URL)
+			m.markPendingDownload(task)
 			return
 		default:
 			// Try interface-specific queues
 			for i := range m.downloadQueues {
 				select {
 				case m.downloadQueues[i] <- task:
-					m.markPendingDownload(task.URL)
+					m.markPendingDownload(task)
 					return
 				default:
 					continue
@@ -273,7 +718,8 @@ func (m *Manager) PersistentEnqueue(task DownloadTask) {
 			}
 		}
 	}
-	fmt.Printf("❌ [%d] Multi-NIC dropped after %d attempts: %s\n", task.Depth, maxAttempts, task.URL)
+	m.dropped.add(task)
+	logger.Errorf("❌ [%d] Multi-NIC dropped after %d attempts: %s\n", task.Depth, maxAttempts, task.URL)
 }
 
 // IsDownloadedOrPending checks if a URL has been downloaded or is pending
@@ -285,10 +731,22 @@ func (m *Manager) IsDownloadedOrPending(url string) bool {
 	return downloaded || pending
 }
 
-// markPendingDownload marks a URL as pending download
-func (m *Manager) markPendingDownload(url string) {
+// URLMapSizes estimates the memory held by the downloaded, pending and
+// failed URL maps
+func (m *Manager) URLMapSizes() []utils.MapSize {
+	m.mapMutex.RLock()
+	defer m.mapMutex.RUnlock()
+	return []utils.MapSize{
+		utils.EstimateMapSize("downloaded", m.downloadedFiles),
+		utils.EstimateMapSize("pending", m.pendingDownloads),
+		utils.EstimateMapSize("failed", m.failedDownloads),
+	}
+}
+
+// markPendingDownload marks a task's URL as pending download
+func (m *Manager) markPendingDownload(task DownloadTask) {
 	m.mapMutex.Lock()
-	m.pendingDownloads[url] = true
+	m.pendingDownloads[task.URL] = task
 	m.mapMutex.Unlock()
 }
 
@@ -300,26 +758,21 @@ func (m *Manager) markDownloadCompleted(url string) {
 	m.mapMutex.Unlock()
 
 	// Async logging to avoid blocking worker
-	go func() {
+	panics.Go("download log", url, func() {
 		f, err := os.OpenFile(m.downloadLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			return
 		}
 		defer f.Close()
 		f.WriteString(url + "\n")
-	}()
-}
-
-// markDownloadFailed marks a download as failed
-func (m *Manager) markDownloadFailed(url string) {
-	m.mapMutex.Lock()
-	delete(m.pendingDownloads, url)
-	m.failedDownloads[url]++
-	m.mapMutex.Unlock()
+	})
 }
 
 // GetStats returns current download statistics
 func (m *Manager) GetStats() (attempts, success, failed, bytes int64, elapsed time.Duration) {
+	m.statsMu.RLock()
+	defer m.statsMu.RUnlock()
+
 	attempts = atomic.LoadInt64(&m.stats.downloadAttempts)
 	success = atomic.LoadInt64(&m.stats.downloadSuccess)
 	failed = atomic.LoadInt64(&m.stats.downloadFailed)
@@ -342,6 +795,17 @@ func (m *Manager) GetQueueStatus() (totalQueued, totalCapacity int) {
 		totalQueued += len(queue)
 		totalCapacity += cap(queue)
 	}
+	for _, queue := range m.hostQueues {
+		totalQueued += len(queue)
+		totalCapacity += cap(queue)
+	}
+	if m.activeHosts != nil {
+		totalQueued += m.activeHosts.parked()
+	}
+	if m.breakers != nil {
+		_, deferred := m.GetCircuitBreakerStats()
+		totalQueued += deferred
+	}
 
 	return
 }
@@ -358,7 +822,7 @@ func (m *Manager) GetPriorityQueue() chan DownloadTask {
 
 // AddWorkers adds new workers to the system
 func (m *Manager) AddWorkers(count int) {
-	if count <= 0 {
+	if count <= 0 || len(m.networkInterfaces) == 0 {
 		return
 	}
 
@@ -373,8 +837,11 @@ func (m *Manager) AddWorkers(count int) {
 		}
 
 		for j := 0; j < workers; j++ {
+			// Stagger scale-up workers so they don't hit hosts in lockstep
+			startDelay := rand.N(config.WorkerStartupJitter)
+
 			m.downloadWG.Add(1)
-			go m.multiNICDownloadWorker(i, j%len(iface.Clients))
+			go m.multiNICDownloadWorker(i, j%len(iface.Clients), startDelay)
 			atomic.AddInt64(&m.activeWorkers, 1)
 		}
 	}
@@ -387,7 +854,28 @@ func (m *Manager) Shutdown() {
 	for _, queue := range m.downloadQueues {
 		close(queue)
 	}
+	for _, queue := range m.hostQueues {
+		close(queue)
+	}
 	m.downloadWG.Wait()
+	if m.breakers != nil {
+		m.failDeferred(m.breakers.drain())
+	}
+	m.stopPostProcessing()
+	if m.manifest != nil {
+		m.manifest.close()
+	}
+}
+
+// ShuttingDown reports whether Shutdown has been called, after which no
+// more tasks can be queued
+func (m *Manager) ShuttingDown() bool {
+	select {
+	case <-m.shutdownChan:
+		return true
+	default:
+		return false
+	}
 }
 
 // Wait waits for all downloads to complete
@@ -0,0 +1,51 @@
+package downloader
+
+import (
+	"bufio"
+	"os"
+	"sync"
+)
+
+// droppedTasks remembers documents that never made it into a queue
+type droppedTasks struct {
+	mu    sync.Mutex
+	tasks []DownloadTask
+}
+
+func (d *droppedTasks) add(task DownloadTask) {
+	d.mu.Lock()
+	d.tasks = append(d.tasks, task)
+	d.mu.Unlock()
+}
+
+// GetDroppedDownloads returns the tasks PersistentEnqueue gave up on
+func (m *Manager) GetDroppedDownloads() []DownloadTask {
+	m.dropped.mu.Lock()
+	defer m.dropped.mu.Unlock()
+	return append([]DownloadTask(nil), m.dropped.tasks...)
+}
+
+// WriteDroppedDownloads writes one dropped URL per line to path, suitable
+// for feeding back into a later crawl. Nothing is written when no
+// downloads were dropped; the count is returned either way.
+func (m *Manager) WriteDroppedDownloads(path string) (int, error) {
+	tasks := m.GetDroppedDownloads()
+	if len(tasks) == 0 {
+		return 0, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, task := range tasks {
+		w.WriteString(task.URL + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	return len(tasks), f.Close()
+}
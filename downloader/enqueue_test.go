@@ -0,0 +1,56 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeb/url_crawler/network"
+)
+
+func newTestManager(t *testing.T, interfaces int) *Manager {
+	t.Helper()
+	var nics []network.NetworkInterface
+	for i := 0; i < interfaces; i++ {
+		nics = append(nics, network.NewInterfaceWithTransport(fmt.Sprintf("nic%d", i), http.DefaultTransport, 1))
+	}
+	dir := t.TempDir()
+	return NewManager(nics, dir, filepath.Join(dir, "downloads.log"))
+}
+
+func TestZeroValueTaskIsLoadBalanced(t *testing.T) {
+	m := newTestManager(t, 2)
+
+	for i := 0; i < 4; i++ {
+		task := DownloadTask{URL: fmt.Sprintf("https://example.com/%d.pdf", i)}
+		if !m.EnqueueTask(task) {
+			t.Fatalf("EnqueueTask(%s) refused", task.URL)
+		}
+	}
+	for i, q := range m.downloadQueues {
+		if len(q) != 2 {
+			t.Errorf("interface %d queue holds %d tasks, want 2", i, len(q))
+		}
+	}
+	if got := m.retryQueue(DownloadTask{URL: "https://example.com/x.pdf"}); got != m.priorityQueue {
+		t.Errorf("a zero-value task retries on an interface queue, want the priority queue")
+	}
+}
+
+func TestPinnedTaskStaysOnItsInterface(t *testing.T) {
+	m := newTestManager(t, 2)
+
+	for i := 0; i < 3; i++ {
+		task := DownloadTask{URL: fmt.Sprintf("https://example.com/%d.pdf", i), Pinned: true, InterfaceID: 1}
+		if !m.EnqueueTask(task) {
+			t.Fatalf("EnqueueTask(%s) refused", task.URL)
+		}
+	}
+	if len(m.downloadQueues[0]) != 0 || len(m.downloadQueues[1]) != 3 {
+		t.Errorf("queue lengths = %d, %d; want 0, 3", len(m.downloadQueues[0]), len(m.downloadQueues[1]))
+	}
+	if m.EnqueueTask(DownloadTask{URL: "https://example.com/bad.pdf", Pinned: true, InterfaceID: 2}) {
+		t.Errorf("a task pinned to a missing interface was queued")
+	}
+}
@@ -0,0 +1,51 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jeb/url_crawler/panics"
+)
+
+// Errors returned from downloads, to the retry logic and OnDownloadFailed
+// callbacks. Match them with errors.Is, and *HTTPStatusError with errors.As.
+var (
+	ErrFileExists   = errors.New("file already exists")                    // Skip policy found the file on disk
+	ErrSizeFiltered = errors.New("file size outside the configured range") // See SetSizeFilter
+	ErrTimeout      = errors.New("timed out")                              // Connect, header or body timeout
+	ErrNetwork      = errors.New("network error")                          // Any other transport failure
+	ErrStorage      = errors.New("cannot write to target directory")       // Local write failed
+	ErrDiskFull     = errors.New("target directory is full")               // A storage error caused by ENOSPC
+	ErrVerify       = errors.New("downloaded file failed verification")    // See SetVerifyDownloads
+	ErrNotModified  = errors.New("not modified since the saved copy")      // See SetConditionalRequests
+	ErrPanic        = errors.New("panicked")                               // Recorded by package panics; not retried
+	ErrCircuitOpen  = errors.New("host circuit breaker still open")        // Deferred task given up at Shutdown
+)
+
+// HTTPStatusError reports a response whose code is not in SetSuccessCodes
+type HTTPStatusError struct {
+	Code int
+}
+
+func (e *HTTPStatusError) Error() string { return fmt.Sprintf("HTTP %d", e.Code) }
+
+// recoveredPanic runs in a deferred call: it turns a panic while handling
+// docURL into ErrPanic in *err, recording it for the panic report
+func recoveredPanic(where, docURL string, err *error) {
+	if value := recover(); value != nil {
+		panics.Record(where, docURL, value)
+		*err = fmt.Errorf("%w: %v", ErrPanic, value)
+	}
+}
+
+// networkError tags a transport or body read failure as ErrTimeout or
+// ErrNetwork, keeping the original error in the chain
+func networkError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", ErrNetwork, err)
+}
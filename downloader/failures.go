@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FailedDownload is a document that failed for good, with why
+type FailedDownload struct {
+	URL       string
+	Attempts  int    // Tries made, retries included, over every time it was queued
+	Class     string // Error class, see ErrorClass
+	LastError string
+}
+
+// ErrorClass names the kind of a download error, for grouping failures:
+// "timeout", "network", "disk full", "storage", "http 403" and so on, or
+// "other"
+func ErrorClass(err error) string {
+	var statusErr *HTTPStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("http %d", statusErr.Code)
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	case errors.Is(err, ErrNetwork):
+		return "network"
+	case errors.Is(err, ErrVerify):
+		return "verification"
+	case errors.Is(err, ErrPanic):
+		return "panic"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit open"
+	case errors.Is(err, ErrDiskFull):
+		return "disk full"
+	case errors.Is(err, ErrStorage):
+		return "storage"
+	}
+	return "other"
+}
+
+// markDownloadFailed marks a download as failed after attempts tries,
+// keeping err as the reason
+func (m *Manager) markDownloadFailed(url string, attempts int, err error) {
+	m.mapMutex.Lock()
+	defer m.mapMutex.Unlock()
+
+	delete(m.pendingDownloads, url)
+	f, ok := m.failedDownloads[url]
+	if !ok {
+		f = &FailedDownload{URL: url}
+		m.failedDownloads[url] = f
+	}
+	f.Attempts += attempts
+	f.Class = ErrorClass(err)
+	f.LastError = err.Error()
+}
+
+// GetFailedDownloads returns the documents that failed for good, sorted by URL
+func (m *Manager) GetFailedDownloads() []FailedDownload {
+	m.mapMutex.RLock()
+	failures := make([]FailedDownload, 0, len(m.failedDownloads))
+	for _, f := range m.failedDownloads {
+		failures = append(failures, *f)
+	}
+	m.mapMutex.RUnlock()
+
+	sort.Slice(failures, func(i, j int) bool { return failures[i].URL < failures[j].URL })
+	return failures
+}
+
+// GetFailureClasses counts the failed documents by error class
+func (m *Manager) GetFailureClasses() map[string]int {
+	m.mapMutex.RLock()
+	defer m.mapMutex.RUnlock()
+
+	classes := make(map[string]int)
+	for _, f := range m.failedDownloads {
+		classes[f.Class]++
+	}
+	return classes
+}
+
+// WriteFailures writes the failed documents to path as TSV: URL, attempts,
+// error class and last error. Nothing is written when no downloads failed;
+// the count is returned either way.
+func (m *Manager) WriteFailures(path string) (int, error) {
+	failures := m.GetFailedDownloads()
+	if len(failures) == 0 {
+		return 0, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	w := bufio.NewWriter(f)
+	w.WriteString("url\tattempts\tclass\tlast_error\n")
+	for _, failure := range failures {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", failure.URL, failure.Attempts, failure.Class, clean.Replace(failure.LastError))
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	return len(failures), f.Close()
+}
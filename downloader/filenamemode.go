@@ -0,0 +1,140 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jeb/url_crawler/utils"
+)
+
+// FilenameMode chooses how downloaded files are named
+type FilenameMode int
+
+const (
+	URLBased  FilenameMode = iota // Last path segment (or Content-Disposition), sanitized
+	HashBased                     // SHA-256 of the normalized URL plus the detected extension
+)
+
+// ParseFilenameMode converts "url" or "hash" to a FilenameMode
+func ParseFilenameMode(s string) (FilenameMode, error) {
+	switch strings.ToLower(s) {
+	case "", "url":
+		return URLBased, nil
+	case "hash":
+		return HashBased, nil
+	default:
+		return URLBased, fmt.Errorf("unknown filename mode %q (use url or hash)", s)
+	}
+}
+
+// SetFilenameMode sets how downloaded files are named. HashBased names are
+// stable across runs and collision-free; pair it with SetManifest to keep
+// the name→URL mapping. Call before StartWorkers.
+func (m *Manager) SetFilenameMode(mode FilenameMode) {
+	m.filenameMode = mode
+}
+
+// FilenameFunc names the file a document is saved under. resp is nil when
+// the name is needed before the request is sent, as the Skip overwrite
+// policy looks for an existing file first. Returning "" falls back to the
+// FilenameMode.
+type FilenameFunc func(docURL string, resp *http.Response) string
+
+// SetFilenameFunc names files with fn instead of the FilenameMode. Names
+// may contain "/" to save into subdirectories of the output directory;
+// each part is sanitized and "." and ".." parts are dropped. The overwrite
+// policy and content sniffing still apply. Call before StartWorkers.
+func (m *Manager) SetFilenameFunc(fn FilenameFunc) {
+	m.filenameFunc = fn
+}
+
+// customFilename returns the FilenameFunc's name for docURL, made safe,
+// or "" if there is no FilenameFunc or it gave no name
+func (m *Manager) customFilename(docURL string, resp *http.Response) string {
+	if m.filenameFunc == nil {
+		return ""
+	}
+	var parts []string
+	for _, part := range strings.Split(m.filenameFunc(docURL, resp), "/") {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		parts = append(parts, utils.SanitizeFilename(part))
+	}
+	return filepath.Join(parts...)
+}
+
+// outputFilename returns the file name docURL is saved under; resp is nil
+// before the request
+func (m *Manager) outputFilename(docURL string, resp *http.Response) string {
+	if name := m.customFilename(docURL, resp); name != "" {
+		return name
+	}
+	header := http.Header{}
+	if resp != nil {
+		header = resp.Header
+	}
+	if m.filenameMode != HashBased {
+		return utils.ExtractFilename(docURL, header)
+	}
+
+	normalized := docURL
+	if u, err := url.Parse(docURL); err == nil {
+		normalized = utils.NormalizeParsedURL(u)
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:16]) + detectExtension(docURL, header)
+}
+
+// detectExtension returns the URL path's extension, or one matching the
+// Content-Type when the path has none
+func detectExtension(docURL string, header http.Header) string {
+	if u, err := url.Parse(docURL); err == nil {
+		if ext := path.Ext(u.Path); ext != "" && len(ext) <= 10 {
+			return utils.SanitizeFilename(strings.ToLower(ext))
+		}
+	}
+	return extensionForType(header.Get("Content-Type"))
+}
+
+// manifest appends one
+// "filename<TAB>url<TAB>bytes<TAB>ttfb_ms<TAB>transfer_ms<TAB>outcome" line
+// per download
+type manifest struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// SetManifest records every completed download in the TSV file at path,
+// mapping the saved file to its URL, with its size, TTFB and transfer time
+// in milliseconds, and whether it was "downloaded" or "not_modified" (see
+// SetConditionalRequests). Call before StartWorkers.
+func (m *Manager) SetManifest(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	m.manifest = &manifest{file: f}
+	return nil
+}
+
+func (w *manifest) record(meta DownloadMeta, outcome string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.file, "%s\t%s\t%d\t%d\t%d\t%s\n", meta.Path, meta.URL, meta.Bytes,
+		meta.TTFB.Milliseconds(), meta.TransferTime.Milliseconds(), outcome)
+}
+
+func (w *manifest) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
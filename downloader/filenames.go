@@ -0,0 +1,53 @@
+package downloader
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileOwners maps output paths to the URL that claimed them, so two URLs
+// with the same filename (/a/report.pdf, /b/report.pdf) don't clobber
+// each other in the flat layout
+type fileOwners struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+func newFileOwners() *fileOwners {
+	return &fileOwners{owners: make(map[string]string)}
+}
+
+// resolve returns the path docURL should use, without claiming it
+func (f *fileOwners) resolve(path, docURL string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.resolveLocked(path, docURL)
+}
+
+// claim returns the path docURL should use and records docURL as its owner
+func (f *fileOwners) claim(path, docURL string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path = f.resolveLocked(path, docURL)
+	f.owners[path] = docURL
+	return path
+}
+
+func (f *fileOwners) resolveLocked(path, docURL string) string {
+	if owner, ok := f.owners[path]; ok && owner != docURL {
+		return withURLHash(path, docURL)
+	}
+	return path
+}
+
+// withURLHash inserts a short hash of docURL before the extension:
+// report.pdf → report_ab12cd.pdf
+func withURLHash(path, docURL string) string {
+	sum := sha1.Sum([]byte(docURL))
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + hex.EncodeToString(sum[:3]) + ext
+}
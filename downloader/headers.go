@@ -0,0 +1,45 @@
+package downloader
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jeb/url_crawler/logger"
+)
+
+// SetCaptureHeaders writes each saved file's response status and headers
+// next to it as <file>.headers.json. Call before StartWorkers.
+func (m *Manager) SetCaptureHeaders(enabled bool) {
+	m.captureHeaders = enabled
+}
+
+// headerRecord is the content of a .headers.json sidecar
+type headerRecord struct {
+	URL        string      `json:"url"`
+	Proto      string      `json:"proto"`
+	Status     string      `json:"status"`
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers"`
+	FetchedAt  time.Time   `json:"fetched_at"`
+}
+
+// writeHeaders saves resp's status and headers beside the file at path.
+// A failure is logged; the download itself still counts.
+func writeHeaders(path, docURL string, resp *http.Response, fetchedAt time.Time) {
+	data, err := json.MarshalIndent(headerRecord{
+		URL:        docURL,
+		Proto:      resp.Proto,
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		FetchedAt:  fetchedAt,
+	}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path+".headers.json", append(data, '\n'), 0644)
+	}
+	if err != nil {
+		logger.Warnf("⚠️ Failed to save headers for %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,86 @@
+package downloader
+
+import (
+	"sync"
+
+	"github.com/jeb/url_crawler/utils"
+)
+
+// activeHosts bounds how many distinct hosts are downloaded from at once.
+// Tasks for a host that is not active while every slot is taken are parked
+// and handed to the next worker that frees a slot.
+type activeHosts struct {
+	mu       sync.Mutex
+	max      int
+	inflight map[string]int // host -> downloads in progress
+	waiting  []DownloadTask
+}
+
+// SetMaxActiveHosts caps the number of distinct hosts with downloads in
+// progress at the same time. Tasks for further hosts wait until a slot frees.
+// This bounds host breadth, not per-host concurrency. Zero or negative
+// disables the cap. Call before StartWorkers.
+func (m *Manager) SetMaxActiveHosts(n int) {
+	if n <= 0 {
+		m.activeHosts = nil
+		return
+	}
+	m.activeHosts = &activeHosts{
+		max:      n,
+		inflight: make(map[string]int),
+	}
+}
+
+// acquire admits task if its host is already active or a slot is free.
+// Otherwise the task is parked and acquire returns false.
+func (a *activeHosts) acquire(task DownloadTask) bool {
+	host := utils.HostOf(task.URL)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.admit(host) {
+		return true
+	}
+	a.waiting = append(a.waiting, task)
+	return false
+}
+
+// release ends a download from rawURL's host and returns a parked task that
+// can now run, already admitted, if there is one
+func (a *activeHosts) release(rawURL string) (DownloadTask, bool) {
+	host := utils.HostOf(rawURL)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.inflight[host] <= 1 {
+		delete(a.inflight, host)
+	} else {
+		a.inflight[host]--
+	}
+
+	for i, task := range a.waiting {
+		if a.admit(utils.HostOf(task.URL)) {
+			a.waiting = append(a.waiting[:i], a.waiting[i+1:]...)
+			return task, true
+		}
+	}
+	return DownloadTask{}, false
+}
+
+// admit counts a download against host if allowed. Callers hold a.mu.
+func (a *activeHosts) admit(host string) bool {
+	if a.inflight[host] == 0 && len(a.inflight) >= a.max {
+		return false
+	}
+	a.inflight[host]++
+	return true
+}
+
+// parked returns the number of tasks waiting for a host slot
+func (a *activeHosts) parked() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.waiting)
+}
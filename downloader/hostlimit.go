@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/jeb/url_crawler/utils"
+	"golang.org/x/time/rate"
+)
+
+// hostLimiters enforces an aggregate request rate per host, shared by the
+// workers of every interface
+type hostLimiters struct {
+	mu        sync.Mutex
+	rps       rate.Limit
+	overrides map[string]rate.Limit // per-host rates set with SetHostRate
+	limiters  map[string]*rate.Limiter
+}
+
+// SetPerHostRate caps downloads to rps requests per second per host across
+// all network interfaces combined. Zero or negative disables the cap.
+// Call before StartWorkers.
+func (m *Manager) SetPerHostRate(rps float64) {
+	if rps <= 0 {
+		if m.hostLimiters != nil && len(m.hostLimiters.overrides) > 0 {
+			m.hostLimiters.rps = rate.Inf
+			return
+		}
+		m.hostLimiters = nil
+		return
+	}
+	m.ensureHostLimiters().rps = rate.Limit(rps)
+}
+
+// SetHostRate sets the download rate for one host, overriding
+// SetPerHostRate for it. Call before StartWorkers.
+func (m *Manager) SetHostRate(host string, rps float64) {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
+	}
+	m.ensureHostLimiters().overrides[strings.ToLower(host)] = limit
+}
+
+// ensureHostLimiters creates the limiter set, unlimited by default
+func (m *Manager) ensureHostLimiters() *hostLimiters {
+	if m.hostLimiters == nil {
+		m.hostLimiters = &hostLimiters{
+			rps:       rate.Inf,
+			overrides: make(map[string]rate.Limit),
+			limiters:  make(map[string]*rate.Limiter),
+		}
+	}
+	return m.hostLimiters
+}
+
+// SetDownloadRate caps all document downloads combined to rps requests per
+// second, separately from the crawl's PoliteDelay and on top of each
+// interface's own limit. Zero or negative removes the cap.
+// Call before StartWorkers.
+func (m *Manager) SetDownloadRate(rps float64) {
+	if rps <= 0 {
+		m.downloadLimiter = nil
+		return
+	}
+	m.downloadLimiter = rate.NewLimiter(rate.Limit(rps), 1)
+}
+
+// wait blocks until rawURL's host may be requested again
+func (h *hostLimiters) wait(rawURL string) {
+	host := utils.HostOf(rawURL)
+
+	h.mu.Lock()
+	limiter, ok := h.limiters[host]
+	if !ok {
+		rps, ok := h.overrides[host]
+		if !ok {
+			rps = h.rps
+		}
+		limiter = rate.NewLimiter(rps, 1)
+		h.limiters[host] = limiter
+	}
+	h.mu.Unlock()
+
+	limiter.Wait(context.Background())
+}
@@ -0,0 +1,57 @@
+package downloader
+
+import (
+	"hash/fnv"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// SetHostSharding switches auto-assigned tasks from the per-interface queues
+// to queues sharded by hostname, so one slow or unresponsive host can only
+// fill its own shard. Workers on every interface drain all shards in turn.
+// Call before StartWorkers; pinned tasks keep using their interface queue.
+func (m *Manager) SetHostSharding(enabled bool) {
+	if !enabled {
+		m.hostQueues = nil
+		return
+	}
+
+	m.hostQueues = make([]chan DownloadTask, config.HostQueueShards)
+	for i := range m.hostQueues {
+		m.hostQueues[i] = make(chan DownloadTask, config.MaxQueueSize/config.HostQueueShards)
+	}
+}
+
+// enqueueShard queues a task on its host's shard without spilling elsewhere
+func (m *Manager) enqueueShard(task DownloadTask) bool {
+	h := fnv.New32a()
+	h.Write([]byte(utils.HostOf(task.URL)))
+	shard := m.hostQueues[h.Sum32()%uint32(len(m.hostQueues))]
+
+	select {
+	case shard <- task:
+		m.markPendingDownload(task)
+		return true
+	default:
+		return false
+	}
+}
+
+// takeFromShards returns the next task from the shards, starting at *cursor
+// so each worker rotates through hosts instead of favouring the first shard
+func (m *Manager) takeFromShards(cursor *int) (DownloadTask, bool) {
+	for range m.hostQueues {
+		shard := m.hostQueues[*cursor%len(m.hostQueues)]
+		*cursor++
+
+		select {
+		case task, ok := <-shard:
+			if ok {
+				return task, true
+			}
+		default:
+		}
+	}
+	return DownloadTask{}, false
+}
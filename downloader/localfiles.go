@@ -0,0 +1,42 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// localFileTransport serves file:// URLs, confined to a root directory
+type localFileTransport struct {
+	root  string
+	files http.RoundTripper
+}
+
+func (t *localFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Clean(req.URL.Path)
+	rel, err := filepath.Rel(t.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("file %s is outside %s", path, t.root)
+	}
+	return t.files.RoundTrip(req)
+}
+
+// EnableLocalFiles lets workers "download" file:// documents under root,
+// which is how a local crawl copies documents into the target directory.
+// Without it, file:// URLs fail like any other unsupported scheme.
+func (m *Manager) EnableLocalFiles(root string) {
+	m.localFilesOnce.Do(func() {
+		transport := &localFileTransport{
+			root:  root,
+			files: http.NewFileTransport(http.Dir("/")),
+		}
+		for _, iface := range m.networkInterfaces {
+			for _, client := range iface.Clients {
+				if t, ok := client.Transport.(*http.Transport); ok {
+					t.RegisterProtocol("file", transport)
+				}
+			}
+		}
+	})
+}
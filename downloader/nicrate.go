@@ -0,0 +1,59 @@
+package downloader
+
+import (
+	"context"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/network"
+	"golang.org/x/time/rate"
+)
+
+// newInterfaceLimiter gives an interface a token bucket sized to its
+// capacity, so one NIC's downloads never spend another's tokens
+func newInterfaceLimiter(iface network.NetworkInterface) *rate.Limiter {
+	workers := iface.WorkerCount
+	if workers <= 0 {
+		workers = max(len(iface.Clients), 1)
+	}
+	return rate.NewLimiter(rate.Limit(workers*config.InterfaceRatePerWorker), workers)
+}
+
+// SetInterfaceRate caps downloads through the named interface to rps
+// requests per second, replacing the default sized from its worker count.
+// Zero or negative removes the cap. Other interfaces are unaffected.
+// It reports false if there is no such interface. Call before StartWorkers.
+func (m *Manager) SetInterfaceRate(name string, rps float64) bool {
+	for i, iface := range m.networkInterfaces {
+		if iface.Name != name {
+			continue
+		}
+		if rps <= 0 {
+			m.interfaceLimiters[i] = rate.NewLimiter(rate.Inf, 1)
+		} else {
+			m.interfaceLimiters[i] = rate.NewLimiter(rate.Limit(rps), 1)
+		}
+		return true
+	}
+	return false
+}
+
+// GetInterfaceRates returns each interface's download rate limit in
+// requests per second, indexed like the interfaces; 0 means unlimited
+func (m *Manager) GetInterfaceRates() []float64 {
+	rates := make([]float64, len(m.interfaceLimiters))
+	for i, l := range m.interfaceLimiters {
+		if l.Limit() != rate.Inf {
+			rates[i] = float64(l.Limit())
+		}
+	}
+	return rates
+}
+
+// waitForRate blocks until the interface's limiter, then the overall
+// SetDownloadRate cap if any, allow another download
+func (m *Manager) waitForRate(interfaceID int) {
+	m.interfaceLimiters[interfaceID].Wait(context.Background())
+	if m.downloadLimiter != nil {
+		m.downloadLimiter.Wait(context.Background())
+	}
+}
@@ -0,0 +1,109 @@
+package downloader
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/jeb/url_crawler/utils"
+)
+
+// DirPolicy chooses which output directory a download is written to
+type DirPolicy int
+
+const (
+	DirRoundRobin  DirPolicy = iota // Rotate through the directories
+	DirByHost                       // Every file from a host goes to the same directory
+	DirByFreeSpace                  // The directory with the most free space
+)
+
+// freeSpaceRefresh is how long free-space readings are reused
+const freeSpaceRefresh = 5 * time.Second
+
+// ParseDirPolicy converts "round-robin", "host" or "free-space" to a DirPolicy
+func ParseDirPolicy(s string) (DirPolicy, error) {
+	switch strings.ToLower(s) {
+	case "", "round-robin", "roundrobin":
+		return DirRoundRobin, nil
+	case "host", "by-host":
+		return DirByHost, nil
+	case "free-space", "space":
+		return DirByFreeSpace, nil
+	default:
+		return DirRoundRobin, fmt.Errorf("unknown directory policy %q (use round-robin, host or free-space)", s)
+	}
+}
+
+// dirSelector spreads downloads across one or more output directories
+type dirSelector struct {
+	dirs   []string
+	policy DirPolicy
+	next   atomic.Uint64
+
+	mu        sync.Mutex
+	freeBytes []uint64
+	checked   time.Time
+}
+
+func newDirSelector(dirs []string, policy DirPolicy) *dirSelector {
+	return &dirSelector{dirs: dirs, policy: policy}
+}
+
+// SetOutputDirs spreads downloads across dirs, e.g. one per disk, using
+// policy. The directory passed to NewManager is replaced. Call before
+// StartWorkers.
+func (m *Manager) SetOutputDirs(dirs []string, policy DirPolicy) error {
+	if len(dirs) == 0 {
+		return fmt.Errorf("no output directories given")
+	}
+	m.outputDirs = newDirSelector(dirs, policy)
+	m.storage.dirs = dirs
+	return nil
+}
+
+// pick returns the directory docURL should be written to
+func (d *dirSelector) pick(docURL string) string {
+	if len(d.dirs) == 1 {
+		return d.dirs[0]
+	}
+
+	switch d.policy {
+	case DirByHost:
+		h := fnv.New32a()
+		h.Write([]byte(utils.HostOf(docURL)))
+		return d.dirs[h.Sum32()%uint32(len(d.dirs))]
+	case DirByFreeSpace:
+		return d.dirs[d.mostFree()]
+	default:
+		return d.dirs[(d.next.Add(1)-1)%uint64(len(d.dirs))]
+	}
+}
+
+// mostFree returns the index of the directory with the most free space
+func (d *dirSelector) mostFree() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if time.Since(d.checked) > freeSpaceRefresh {
+		d.freeBytes = make([]uint64, len(d.dirs))
+		for i, dir := range d.dirs {
+			var st syscall.Statfs_t
+			if err := syscall.Statfs(dir, &st); err == nil {
+				d.freeBytes[i] = uint64(st.Bavail) * uint64(st.Bsize)
+			}
+		}
+		d.checked = time.Now()
+	}
+
+	best := 0
+	for i, free := range d.freeBytes {
+		if free > d.freeBytes[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,75 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// OverwritePolicy decides what happens when a download's file already exists
+type OverwritePolicy int
+
+const (
+	Overwrite OverwritePolicy = iota // Replace the existing file (default)
+	Skip                             // Keep the existing file and skip the download
+	Rename                           // Write to name_1.ext, name_2.ext, ...
+)
+
+// maxRenameAttempts bounds the counter used by the Rename policy
+const maxRenameAttempts = 10000
+
+// ParseOverwritePolicy converts "overwrite", "skip" or "rename" to a policy
+func ParseOverwritePolicy(s string) (OverwritePolicy, error) {
+	switch strings.ToLower(s) {
+	case "overwrite":
+		return Overwrite, nil
+	case "skip":
+		return Skip, nil
+	case "rename":
+		return Rename, nil
+	}
+	return Overwrite, fmt.Errorf("unknown overwrite policy %q (use overwrite, skip or rename)", s)
+}
+
+// SetOverwritePolicy sets how existing files are handled
+func (m *Manager) SetOverwritePolicy(policy OverwritePolicy) {
+	m.overwritePolicy = policy
+}
+
+// GetSkippedCount returns the number of downloads skipped because the file existed
+func (m *Manager) GetSkippedCount() int64 {
+	return atomic.LoadInt64(&m.stats.downloadSkipped)
+}
+
+// createOutputFile opens the destination for a download according to the
+// overwrite policy and returns the path actually used
+func (m *Manager) createOutputFile(path string) (*os.File, string, error) {
+	switch m.overwritePolicy {
+	case Skip:
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			return nil, path, ErrFileExists
+		}
+		return f, path, err
+
+	case Rename:
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		candidate := path
+		for i := 1; i <= maxRenameAttempts; i++ {
+			f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if !errors.Is(err, os.ErrExist) {
+				return f, candidate, err
+			}
+			candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+		}
+		return nil, path, fmt.Errorf("no free name for %s after %d attempts", path, maxRenameAttempts)
+
+	default:
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		return f, path, err
+	}
+}
@@ -0,0 +1,115 @@
+package downloader
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/utils"
+	"golang.org/x/time/rate"
+)
+
+// HostRate compares the download request rate achieved against one host
+// with the configured per-host target
+type HostRate struct {
+	Host     string  `json:"host"`
+	Requests int64   `json:"requests"`
+	AvgRate  float64 `json:"avg_rate"`  // Requests per second from first to last request
+	PeakRate float64 `json:"peak_rate"` // Most requests started in any one clock second
+	Target   float64 `json:"target"`    // Configured requests per second; 0 means unlimited
+	Exceeded bool    `json:"exceeded"`  // PeakRate went past Target plus the limiter's burst
+}
+
+// hostRequestRates records when download requests to each host start
+type hostRequestRates struct {
+	mu    sync.Mutex
+	hosts map[string]*hostRequests
+}
+
+type hostRequests struct {
+	count       int64
+	first, last time.Time
+	window      int64 // Unix second being counted
+	inWindow    int64
+	peak        int64
+}
+
+func newHostRequestRates() *hostRequestRates {
+	return &hostRequestRates{hosts: make(map[string]*hostRequests)}
+}
+
+func (r *hostRequestRates) record(rawURL string, now time.Time) {
+	host := utils.HostOf(rawURL)
+	second := now.Unix()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.hosts[host]
+	if !ok {
+		h = &hostRequests{first: now, window: second}
+		r.hosts[host] = h
+	}
+	h.count++
+	h.last = now
+	if second != h.window {
+		h.window, h.inWindow = second, 0
+	}
+	h.inWindow++
+	h.peak = max(h.peak, h.inWindow)
+}
+
+func (r *hostRequestRates) reset() {
+	r.mu.Lock()
+	clear(r.hosts)
+	r.mu.Unlock()
+}
+
+// GetHostRequestRates returns the achieved download request rate for every
+// host against its SetPerHostRate/SetHostRate target, busiest hosts first.
+// Page requests made by the crawler are not included.
+func (m *Manager) GetHostRequestRates() []HostRate {
+	m.hostRates.mu.Lock()
+	rates := make([]HostRate, 0, len(m.hostRates.hosts))
+	for host, h := range m.hostRates.hosts {
+		hr := HostRate{Host: host, Requests: h.count, PeakRate: float64(h.peak)}
+		if span := h.last.Sub(h.first).Seconds(); h.count > 1 && span > 0 {
+			hr.AvgRate = float64(h.count-1) / span
+		}
+		rates = append(rates, hr)
+	}
+	m.hostRates.mu.Unlock()
+
+	for i := range rates {
+		rates[i].Target = m.hostLimiters.target(rates[i].Host)
+		// A limiter with burst 1 can start ceil(Target) requests in one
+		// clock second, plus one carried over from the previous second
+		rates[i].Exceeded = rates[i].Target > 0 && rates[i].PeakRate > rates[i].Target+1
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Requests != rates[j].Requests {
+			return rates[i].Requests > rates[j].Requests
+		}
+		return rates[i].Host < rates[j].Host
+	})
+	return rates
+}
+
+// target returns the configured rate for host, or 0 when it is unlimited
+func (h *hostLimiters) target(host string) float64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limit, ok := h.overrides[host]
+	if !ok {
+		limit = h.rps
+	}
+	if limit == rate.Inf {
+		return 0
+	}
+	return float64(limit)
+}
@@ -0,0 +1,182 @@
+package downloader
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+)
+
+// DownloadMeta describes a download attempt. For completed downloads it
+// describes the file written to disk; for failures only URL, StatusCode
+// (zero on network errors), Depth and Interface are set.
+type DownloadMeta struct {
+	URL         string
+	Path        string
+	Bytes       int64
+	StatusCode  int
+	ContentType string
+	Depth       int
+	Interface   string
+	CompletedAt time.Time
+
+	TTFB         time.Duration // Request sent to first response byte
+	TransferTime time.Duration // First byte to last byte written
+}
+
+// PostProcessor handles a downloaded file after it has been written.
+// Processors run on a bounded pool, never on download workers. When the
+// pool falls config.PostProcessQueueSize files behind, further files skip
+// post-processing rather than hold up downloads; see GetPostProcessDrops.
+type PostProcessor interface {
+	Process(path string, meta DownloadMeta) error
+}
+
+// PostProcessorFunc adapts an ordinary function to the PostProcessor interface
+type PostProcessorFunc func(path string, meta DownloadMeta) error
+
+// Process calls f(path, meta)
+func (f PostProcessorFunc) Process(path string, meta DownloadMeta) error {
+	return f(path, meta)
+}
+
+// RegisterPostProcessor appends p to the processor chain. Processors run in
+// registration order; an error stops the chain for that file.
+// Processors registered while downloads are running only see the files
+// completed after registration.
+func (m *Manager) RegisterPostProcessor(p PostProcessor) {
+	m.postProcessMutex.Lock()
+	defer m.postProcessMutex.Unlock()
+
+	m.postProcessors = append(m.postProcessors, p)
+	m.postProcessOnce.Do(func() {
+		m.postProcessQueue = make(chan DownloadMeta, config.PostProcessQueueSize)
+		for i := 0; i < config.PostProcessWorkers; i++ {
+			m.postProcessWG.Add(1)
+			go m.postProcessWorker(m.postProcessQueue)
+		}
+	})
+}
+
+// OnDownloadComplete registers fn to be called for every completed download.
+// Callbacks run on the post-processing pool, so a slow consumer never stalls
+// download workers; one that falls a full queue behind misses downloads.
+func (m *Manager) OnDownloadComplete(fn func(meta DownloadMeta)) {
+	m.RegisterPostProcessor(PostProcessorFunc(func(_ string, meta DownloadMeta) error {
+		fn(meta)
+		return nil
+	}))
+}
+
+// OnDownloadFailed registers fn to be called when a download has failed for
+// good, after its retries are used up. err can be matched against the Err*
+// sentinels and *HTTPStatusError. Callbacks run on the download worker and
+// must not block.
+func (m *Manager) OnDownloadFailed(fn func(meta DownloadMeta, err error)) {
+	m.postProcessMutex.Lock()
+	m.failureHooks = append(m.failureHooks, fn)
+	m.postProcessMutex.Unlock()
+}
+
+// notifyDownloadFailed runs the failure callbacks
+func (m *Manager) notifyDownloadFailed(meta DownloadMeta, err error) {
+	m.postProcessMutex.RLock()
+	hooks := m.failureHooks
+	m.postProcessMutex.RUnlock()
+
+	for _, fn := range hooks {
+		runFailureHook(fn, meta, err)
+	}
+}
+
+// runProcessor runs p on a completed download, returning a panic as ErrPanic
+func runProcessor(p PostProcessor, meta DownloadMeta) (err error) {
+	defer recoveredPanic("post-processor", meta.URL, &err)
+	return p.Process(meta.Path, meta)
+}
+
+// runFailureHook runs an OnDownloadFailed callback, recording a panic
+// rather than letting it reach the download worker
+func runFailureHook(fn func(DownloadMeta, error), meta DownloadMeta, err error) {
+	defer panics.Recover("download failure hook", meta.URL)
+	fn(meta, err)
+}
+
+// maxPostProcessDropWarnings caps the warnings for downloads dropped by
+// a full post-processing queue
+const maxPostProcessDropWarnings = 10
+
+// submitPostProcess hands a completed download to the processor pool. It
+// never blocks: with the queue full the download is counted as dropped
+// and not post-processed.
+func (m *Manager) submitPostProcess(meta DownloadMeta) {
+	m.postProcessMutex.RLock()
+	queue := m.postProcessQueue
+	m.postProcessMutex.RUnlock()
+
+	if queue == nil {
+		return
+	}
+	atomic.AddInt64(&m.postProcessBusy, 1)
+	select {
+	case queue <- meta:
+	default:
+		atomic.AddInt64(&m.postProcessBusy, -1)
+		if n := atomic.AddInt64(&m.postProcessDrops, 1); n <= maxPostProcessDropWarnings {
+			logger.Warnf("⚠️ Post-processing queue full, skipping %s\n", meta.Path)
+		}
+	}
+}
+
+// GetPostProcessDrops returns how many completed downloads skipped
+// post-processing because the queue was full
+func (m *Manager) GetPostProcessDrops() int64 {
+	return atomic.LoadInt64(&m.postProcessDrops)
+}
+
+// postProcessWorker runs the processor chain for each completed download
+func (m *Manager) postProcessWorker(queue <-chan DownloadMeta) {
+	defer m.postProcessWG.Done()
+
+	for meta := range queue {
+		m.postProcessMutex.RLock()
+		processors := m.postProcessors
+		m.postProcessMutex.RUnlock()
+
+		for _, p := range processors {
+			if err := runProcessor(p, meta); err != nil {
+				logger.Errorf("❌ Post-processing failed for %s: %v\n", meta.Path, err)
+				break
+			}
+		}
+		atomic.AddInt64(&m.postProcessBusy, -1)
+	}
+}
+
+// WaitIdle blocks until no download is queued, in progress or waiting to
+// be retried, and every completed one has been through the post-processors.
+// Unlike Wait it doesn't need Shutdown, so a caller can check whether the
+// downloads produced more work before shutting down.
+func (m *Manager) WaitIdle() {
+	for {
+		m.mapMutex.RLock()
+		pending := len(m.pendingDownloads)
+		m.mapMutex.RUnlock()
+
+		if pending == 0 && atomic.LoadInt64(&m.postProcessBusy) == 0 {
+			return
+		}
+		time.Sleep(config.IdlePollInterval)
+	}
+}
+
+// stopPostProcessing drains the processor pool
+func (m *Manager) stopPostProcessing() {
+	if m.postProcessQueue == nil {
+		return
+	}
+	close(m.postProcessQueue)
+	m.postProcessWG.Wait()
+}
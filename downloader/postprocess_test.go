@@ -0,0 +1,32 @@
+package downloader
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFullPostProcessQueueDoesNotBlock(t *testing.T) {
+	m := newTestManager(t, 1)
+	m.postProcessQueue = make(chan DownloadMeta, 1) // No workers drain it
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			m.submitPostProcess(DownloadMeta{URL: fmt.Sprintf("https://example.com/%d.pdf", i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("submitPostProcess blocked on a full queue")
+	}
+	if drops := m.GetPostProcessDrops(); drops != 2 {
+		t.Errorf("GetPostProcessDrops() = %d, want 2", drops)
+	}
+	if busy := atomic.LoadInt64(&m.postProcessBusy); busy != 1 {
+		t.Errorf("postProcessBusy = %d, want 1", busy)
+	}
+}
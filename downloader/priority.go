@@ -0,0 +1,17 @@
+package downloader
+
+// SetPriorityPreference sets how often workers check the priority queue
+// (retries and overflow from full queues) before their own queue. With
+// every = 1, the default, priority work always goes first. With every = n,
+// it goes first on one pass in n, so a flood of retries can't starve fresh
+// downloads. With every = 0, workers are strict FIFO on their own queue and
+// only take priority work when it is empty. Call before StartWorkers.
+func (m *Manager) SetPriorityPreference(every int) {
+	m.priorityEvery = max(every, 0)
+}
+
+// prefersPriority reports whether a worker's pass number iteration checks
+// the priority queue first
+func (m *Manager) prefersPriority(iteration int) bool {
+	return m.priorityEvery > 0 && iteration%m.priorityEvery == 0
+}
@@ -0,0 +1,52 @@
+package downloader
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/config"
+)
+
+// SetSizeFilter skips documents smaller than minBytes or larger than
+// maxBytes; zero maxBytes means config.MaxDownloadSize and zero minBytes
+// no minimum. The Content-Length header is checked before anything is
+// written. Bytes are also counted as they arrive, so a body without one, or
+// longer than it claimed, is cut off at the maximum and its partial file
+// removed; short files are removed once complete. Call before StartWorkers.
+func (m *Manager) SetSizeFilter(minBytes, maxBytes int64) {
+	m.minSize = max(minBytes, 0)
+	m.maxSize = max(maxBytes, 0)
+}
+
+// GetSizeFilteredCount returns the number of downloads skipped by SetSizeFilter
+func (m *Manager) GetSizeFilteredCount() int64 {
+	return atomic.LoadInt64(&m.stats.downloadSizeFiltered)
+}
+
+// sizeLimit returns the largest file a download may write
+func (m *Manager) sizeLimit() int64 {
+	if m.maxSize > 0 {
+		return m.maxSize
+	}
+	return config.MaxDownloadSize
+}
+
+// sizeAllowed reports whether a file of n bytes passes the size filter
+func (m *Manager) sizeAllowed(n int64) bool {
+	return n >= m.minSize && n <= m.sizeLimit()
+}
+
+// maxSizeReader fails with ErrSizeFiltered once more than remaining bytes are read
+type maxSizeReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrSizeFiltered
+	}
+	return n, err
+}
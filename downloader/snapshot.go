@@ -0,0 +1,96 @@
+package downloader
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time copy of the download statistics. It's taken with
+// every statistics update held off, so the counters and distributions all
+// describe the same set of events.
+type Stats struct {
+	Since        time.Time // Start of the window: manager creation or the last ResetStats
+	Elapsed      time.Duration
+	Attempts     int64
+	Success      int64
+	Failed       int64
+	Skipped      int64
+	SizeFiltered int64
+	NotModified  int64 // Unchanged since the saved copy; see SetConditionalRequests
+	Bytes        int64
+	StatusCodes  map[int]int64
+	ContentTypes map[string]int64
+	Domains      map[string]DomainStat
+	Timing       TimingStats
+}
+
+// Snapshot returns a coherent copy of the statistics gathered since the
+// manager started or since the last ResetStats
+func (m *Manager) Snapshot() Stats {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	return m.snapshotLocked()
+}
+
+// ResetStats starts a new statistics window, e.g. at a checkpoint of a long
+// crawl, and returns the window it closed. Counters, status and content-type
+// distributions, per-domain stats, timing samples and host request rates are
+// all cleared together.
+func (m *Manager) ResetStats() Stats {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	closed := m.snapshotLocked()
+
+	atomic.StoreInt64(&m.stats.downloadAttempts, 0)
+	atomic.StoreInt64(&m.stats.downloadSuccess, 0)
+	atomic.StoreInt64(&m.stats.downloadFailed, 0)
+	atomic.StoreInt64(&m.stats.downloadSkipped, 0)
+	atomic.StoreInt64(&m.stats.downloadSizeFiltered, 0)
+	atomic.StoreInt64(&m.stats.notModified, 0)
+	atomic.StoreInt64(&m.stats.bytesDownloaded, 0)
+	m.stats.startTime = time.Now()
+
+	m.statusStats.reset()
+	m.contentTypes.reset()
+	m.domainStats.reset()
+	m.timing.reset()
+	m.hostRates.reset()
+
+	return closed
+}
+
+// snapshotLocked copies the statistics; statsMu must be held exclusively
+func (m *Manager) snapshotLocked() Stats {
+	return Stats{
+		Since:        m.stats.startTime,
+		Elapsed:      time.Since(m.stats.startTime),
+		Attempts:     atomic.LoadInt64(&m.stats.downloadAttempts),
+		Success:      atomic.LoadInt64(&m.stats.downloadSuccess),
+		Failed:       atomic.LoadInt64(&m.stats.downloadFailed),
+		Skipped:      atomic.LoadInt64(&m.stats.downloadSkipped),
+		SizeFiltered: atomic.LoadInt64(&m.stats.downloadSizeFiltered),
+		NotModified:  atomic.LoadInt64(&m.stats.notModified),
+		Bytes:        atomic.LoadInt64(&m.stats.bytesDownloaded),
+		StatusCodes:  m.statusStats.snapshot(),
+		ContentTypes: m.contentTypes.snapshot(),
+		Domains:      m.domainStats.snapshot(),
+		Timing:       m.timing.snapshot(),
+	}
+}
+
+// addStat adds n to one of the m.stats counters
+func (m *Manager) addStat(counter *int64, n int64) {
+	m.statsMu.RLock()
+	atomic.AddInt64(counter, n)
+	m.statsMu.RUnlock()
+}
+
+// recordStat runs an update to the statistics so that Snapshot and
+// ResetStats see it either entirely or not at all
+func (m *Manager) recordStat(update func()) {
+	m.statsMu.RLock()
+	update()
+	m.statsMu.RUnlock()
+}
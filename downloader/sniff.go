@@ -0,0 +1,104 @@
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is how much of the body http.DetectContentType looks at
+const sniffLen = 512
+
+// preferredExtensions picks one extension for types mime lists several for
+var preferredExtensions = map[string]string{
+	"application/pdf":    ".pdf",
+	"application/zip":    ".zip",
+	"application/x-gzip": ".gz",
+	"application/gzip":   ".gz",
+	"application/json":   ".json",
+	"application/xml":    ".xml",
+	"text/xml":           ".xml",
+	"text/html":          ".html",
+	"text/plain":         ".txt",
+	"text/csv":           ".csv",
+	"image/jpeg":         ".jpg",
+	"image/png":          ".png",
+	"image/gif":          ".gif",
+	"application/msword": ".doc",
+	"application/rtf":    ".rtf",
+}
+
+// scriptExtensions name the handler rather than the content
+// (report.php?id=3 serving a PDF), so they're treated as missing
+var scriptExtensions = map[string]bool{
+	".php": true, ".asp": true, ".aspx": true, ".ashx": true,
+	".jsp": true, ".cgi": true, ".do": true, ".action": true,
+}
+
+// SetContentSniffing controls whether files whose name has no usable
+// extension get one from the Content-Type or, failing that, from the first
+// 512 bytes of the body. Enabled by default.
+func (m *Manager) SetContentSniffing(enabled bool) {
+	m.noSniff = !enabled
+}
+
+// needsExtension reports whether filename lacks an extension that says
+// what the file is
+func needsExtension(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == "" || len(ext) > 10 || scriptExtensions[ext]
+}
+
+// extensionForType maps a Content-Type value to a file extension
+func extensionForType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" {
+		return ""
+	}
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// sniffExtension gives filename an extension from the response when it has
+// none. It returns the new name and a reader that still yields the whole body.
+func sniffExtension(filename string, header http.Header, body io.Reader) (string, io.Reader, error) {
+	if !needsExtension(filename) {
+		return filename, body, nil
+	}
+	if ext := extensionForType(header.Get("Content-Type")); ext != "" {
+		return withExtension(filename, ext), body, nil
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return filename, body, err
+	}
+	head = head[:n]
+	body = io.MultiReader(bytes.NewReader(head), body)
+
+	if n == 0 {
+		return filename, body, nil
+	}
+	if ext := extensionForType(http.DetectContentType(head)); ext != "" {
+		filename = withExtension(filename, ext)
+	}
+	return filename, body, nil
+}
+
+// withExtension appends ext, replacing a script extension if there is one
+func withExtension(filename, ext string) string {
+	if old := filepath.Ext(filename); scriptExtensions[strings.ToLower(old)] {
+		filename = strings.TrimSuffix(filename, old)
+	}
+	return filename + ext
+}
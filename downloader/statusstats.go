@@ -0,0 +1,50 @@
+package downloader
+
+import "sync"
+
+// StatusNetworkError is the status code recorded when no HTTP response was received
+const StatusNetworkError = 0
+
+// statusStats is a histogram of HTTP status codes
+type statusStats struct {
+	mu     sync.Mutex
+	counts map[int]int64
+}
+
+func newStatusStats() *statusStats {
+	return &statusStats{counts: make(map[int]int64)}
+}
+
+func (s *statusStats) record(code int) {
+	s.mu.Lock()
+	s.counts[code]++
+	s.mu.Unlock()
+}
+
+func (s *statusStats) snapshot() map[int]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[int]int64, len(s.counts))
+	for code, n := range s.counts {
+		out[code] = n
+	}
+	return out
+}
+
+func (s *statusStats) reset() {
+	s.mu.Lock()
+	clear(s.counts)
+	s.mu.Unlock()
+}
+
+// RecordStatus counts a response status code from the crawler or downloader.
+// Use StatusNetworkError when the request failed before a response arrived.
+func (m *Manager) RecordStatus(code int) {
+	m.recordStat(func() { m.statusStats.record(code) })
+}
+
+// GetStatusDistribution returns a copy of the status code histogram
+func (m *Manager) GetStatusDistribution() map[int]int64 {
+	return m.statusStats.snapshot()
+}
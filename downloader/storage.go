@@ -0,0 +1,170 @@
+package downloader
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+)
+
+// storageError marks a failure writing to the target directory, as opposed
+// to a failure fetching from the server
+type storageError struct {
+	err error
+}
+
+func (e *storageError) Error() string { return "writing to target directory: " + e.err.Error() }
+func (e *storageError) Unwrap() error { return e.err }
+
+// Is makes every storage error match ErrStorage, and ENOSPC also ErrDiskFull
+func (e *storageError) Is(target error) bool {
+	return target == ErrStorage || target == ErrDiskFull && errors.Is(e.err, syscall.ENOSPC)
+}
+
+// isStorageError reports whether err came from the local filesystem
+func isStorageError(err error) bool {
+	return errors.Is(err, ErrStorage)
+}
+
+// storageWriter tags write errors as storage errors, so io.Copy failures can
+// be told apart from read (network) failures
+type storageWriter struct {
+	w io.Writer
+}
+
+func (s storageWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if err != nil {
+		err = &storageError{err}
+	}
+	return n, err
+}
+
+// storageGuard pauses downloads after repeated write failures and resumes
+// them once a probe file can be written to every output directory again
+type storageGuard struct {
+	dirs     []string
+	recovery func() error // optional, run before each probe (e.g. remount)
+
+	mu       sync.Mutex
+	failures int
+	paused   bool
+	resumed  chan struct{}
+}
+
+func newStorageGuard(dirs []string) *storageGuard {
+	return &storageGuard{dirs: dirs}
+}
+
+// SetStorageRecovery sets a function run before each write probe while
+// downloads are paused for an unwritable target directory, e.g. one that
+// remounts a network share. Call before StartWorkers.
+func (m *Manager) SetStorageRecovery(fn func() error) {
+	m.storage.recovery = fn
+}
+
+// succeeded resets the failure count after a successful write
+func (g *storageGuard) succeeded() {
+	g.mu.Lock()
+	g.failures = 0
+	g.mu.Unlock()
+}
+
+// failed counts a write failure and pauses downloads once
+// config.StorageFailureThreshold of them happen in a row
+func (g *storageGuard) failed(err error, shutdown <-chan struct{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.failures++
+	if g.paused || g.failures < config.StorageFailureThreshold {
+		return
+	}
+
+	g.paused = true
+	g.resumed = make(chan struct{})
+	logger.Errorf("❌ %d downloads failed to write in a row (last: %v); pausing downloads until %s is writable again\n",
+		g.failures, err, strings.Join(g.dirs, ", "))
+
+	panics.Go("storage probe", "", func() { g.probe(shutdown) })
+}
+
+// wait blocks while downloads are paused
+func (g *storageGuard) wait(shutdown <-chan struct{}) {
+	g.mu.Lock()
+	paused, resumed := g.paused, g.resumed
+	g.mu.Unlock()
+
+	if paused {
+		select {
+		case <-resumed:
+		case <-shutdown:
+		}
+	}
+}
+
+// probe retries writing to the target directory until it works
+func (g *storageGuard) probe(shutdown <-chan struct{}) {
+	ticker := time.NewTicker(config.StorageProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		if g.recovery != nil {
+			if err := g.recovery(); err != nil {
+				logger.Warnf("⚠️ Storage recovery failed: %v\n", err)
+			}
+		}
+		if err := g.writeProbes(); err != nil {
+			logger.Warnf("⚠️ Still not writable: %v\n", err)
+			continue
+		}
+
+		g.mu.Lock()
+		g.paused = false
+		g.failures = 0
+		close(g.resumed)
+		g.mu.Unlock()
+
+		logger.Infof("✅ %s writable again, resuming downloads\n", strings.Join(g.dirs, ", "))
+		return
+	}
+}
+
+// writeProbes checks that every output directory accepts writes
+func (g *storageGuard) writeProbes() error {
+	for _, dir := range g.dirs {
+		if err := writeProbe(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProbe creates, writes and removes a small file in dir
+func writeProbe(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	defer os.Remove(name)
+
+	if _, err := f.WriteString("probe"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,41 @@
+package downloader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseStatusCodes parses a comma-separated list of HTTP status codes, where
+// "2xx" stands for 200-299, e.g. "200,206" or "2xx"
+func ParseStatusCodes(s string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if len(field) == 3 && strings.HasSuffix(field, "xx") && field[0] >= '1' && field[0] <= '5' {
+			base := int(field[0]-'0') * 100
+			for code := base; code < base+100; code++ {
+				codes = append(codes, code)
+			}
+			continue
+		}
+
+		code, err := strconv.Atoi(field)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %q (use e.g. 200,206 or 2xx)", field)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// SetSuccessCodes sets which response codes count as a successful download;
+// the default is 200 only. The body is saved for every listed code, so 206
+// Partial Content keeps what the server sent and 204 No Content leaves an
+// empty file. Call before StartWorkers.
+func (m *Manager) SetSuccessCodes(codes []int) {
+	m.successCodes = make(map[int]bool, len(codes))
+	for _, code := range codes {
+		m.successCodes[code] = true
+	}
+}
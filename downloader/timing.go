@@ -0,0 +1,93 @@
+package downloader
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+)
+
+// Percentiles summarizes a set of durations
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// TimingStats are per-download latency figures over the most recent
+// successful downloads. Slow TTFB points at the server or the path to it,
+// slow transfer at bandwidth.
+type TimingStats struct {
+	Samples  int
+	TTFB     Percentiles // Request sent to first response byte
+	Transfer Percentiles // First byte to last byte written
+}
+
+// timingStats keeps the latest config.TimingSamples downloads in a ring
+type timingStats struct {
+	mu       sync.Mutex
+	ttfb     []time.Duration
+	transfer []time.Duration
+	next     int
+}
+
+func newTimingStats() *timingStats {
+	return &timingStats{
+		ttfb:     make([]time.Duration, 0, config.TimingSamples),
+		transfer: make([]time.Duration, 0, config.TimingSamples),
+	}
+}
+
+func (t *timingStats) record(meta DownloadMeta) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.ttfb) < cap(t.ttfb) {
+		t.ttfb = append(t.ttfb, meta.TTFB)
+		t.transfer = append(t.transfer, meta.TransferTime)
+		return
+	}
+	t.ttfb[t.next] = meta.TTFB
+	t.transfer[t.next] = meta.TransferTime
+	t.next = (t.next + 1) % len(t.ttfb)
+}
+
+func (t *timingStats) snapshot() TimingStats {
+	t.mu.Lock()
+	ttfb := slices.Clone(t.ttfb)
+	transfer := slices.Clone(t.transfer)
+	t.mu.Unlock()
+
+	return TimingStats{
+		Samples:  len(ttfb),
+		TTFB:     percentilesOf(ttfb),
+		Transfer: percentilesOf(transfer),
+	}
+}
+
+func (t *timingStats) reset() {
+	t.mu.Lock()
+	t.ttfb = t.ttfb[:0]
+	t.transfer = t.transfer[:0]
+	t.next = 0
+	t.mu.Unlock()
+}
+
+// percentilesOf sorts samples in place and reads off the percentiles
+func percentilesOf(samples []time.Duration) Percentiles {
+	if len(samples) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(samples)
+	at := func(p float64) time.Duration {
+		return samples[int(p*float64(len(samples)-1))]
+	}
+	return Percentiles{P50: at(0.50), P90: at(0.90), P99: at(0.99), Max: samples[len(samples)-1]}
+}
+
+// GetTimingStats returns TTFB and transfer-time percentiles for recent downloads
+func (m *Manager) GetTimingStats() TimingStats {
+	return m.timing.snapshot()
+}
@@ -0,0 +1,105 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// SetVerifyDownloads re-reads every saved file and checks it against a
+// SHA-256 taken while the body streamed in, and the body against a
+// Content-MD5 or Digest (md5, sha-256) header when the server sends one.
+// A file that fails is removed and the download retried. Call before
+// StartWorkers.
+func (m *Manager) SetVerifyDownloads(enabled bool) {
+	m.verify = enabled
+}
+
+// GetVerifyStats returns how many saved files passed verification and how
+// many failed it
+func (m *Manager) GetVerifyStats() (verified, failed int64) {
+	return atomic.LoadInt64(&m.stats.verified), atomic.LoadInt64(&m.stats.verifyFailed)
+}
+
+// verifier hashes a body as it is copied to disk
+type verifier struct {
+	sha  hash.Hash
+	md5  hash.Hash         // nil unless the server sent an MD5
+	want map[string][]byte // digests the server sent, by algorithm
+}
+
+func newVerifier(header http.Header) *verifier {
+	v := &verifier{sha: sha256.New(), want: serverDigests(header)}
+	if _, ok := v.want["md5"]; ok {
+		v.md5 = md5.New()
+	}
+	return v
+}
+
+func (v *verifier) Write(p []byte) (int, error) {
+	v.sha.Write(p)
+	if v.md5 != nil {
+		v.md5.Write(p)
+	}
+	return len(p), nil
+}
+
+// check compares the body against the server's digests, then the file at
+// path against the body
+func (v *verifier) check(path string) error {
+	for alg, want := range v.want {
+		got := v.sha.Sum(nil)
+		if alg == "md5" {
+			got = v.md5.Sum(nil)
+		}
+		if !bytes.Equal(got, want) {
+			return fmt.Errorf("%w: body %s is %x, server sent %x", ErrVerify, alg, got, want)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return &storageError{err}
+	}
+	defer f.Close()
+	onDisk := sha256.New()
+	if _, err := io.Copy(onDisk, f); err != nil {
+		return &storageError{err}
+	}
+	if !bytes.Equal(onDisk.Sum(nil), v.sha.Sum(nil)) {
+		return fmt.Errorf("%w: %s does not match the bytes received", ErrVerify, path)
+	}
+	return nil
+}
+
+// serverDigests decodes the Content-MD5 header and the md5 and sha-256
+// values of a Digest header (RFC 3230); others are ignored
+func serverDigests(header http.Header) map[string][]byte {
+	digests := make(map[string][]byte)
+	if sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(header.Get("Content-MD5"))); err == nil && len(sum) == md5.Size {
+		digests["md5"] = sum
+	}
+	for _, part := range strings.Split(header.Get("Digest"), ",") {
+		alg, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		alg = strings.ToLower(alg)
+		if alg != "md5" && alg != "sha-256" {
+			continue
+		}
+		sum, err := base64.StdEncoding.DecodeString(value)
+		if err == nil && (alg == "md5" && len(sum) == md5.Size || alg == "sha-256" && len(sum) == sha256.Size) {
+			digests[alg] = sum
+		}
+	}
+	return digests
+}
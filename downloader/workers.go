@@ -0,0 +1,13 @@
+package downloader
+
+// SetWorkerLimits sets how many download workers start and how many the
+// monitor may scale up to. Call before StartWorkers.
+func (m *Manager) SetWorkerLimits(initial, maxWorkers int) {
+	m.initialWorkers = max(initial, 1)
+	m.maxWorkers = max(maxWorkers, m.initialWorkers)
+}
+
+// GetMaxWorkers returns the most download workers the scaler may run
+func (m *Manager) GetMaxWorkers() int {
+	return m.maxWorkers
+}
@@ -0,0 +1,146 @@
+// Package events publishes completed downloads to a message broker.
+// It is optional: the crawler core only exposes Manager.OnDownloadComplete,
+// and this adapter turns those callbacks into JSON messages.
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// DownloadEvent is the JSON payload published for each completed download
+type DownloadEvent struct {
+	URL         string    `json:"url"`
+	Path        string    `json:"path"`
+	Bytes       int64     `json:"bytes"`
+	ContentType string    `json:"content_type,omitempty"`
+	Depth       int       `json:"depth"`
+	Interface   string    `json:"interface,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Publisher sends encoded events to a broker
+type Publisher interface {
+	Publish(payload []byte) error
+	Close() error
+}
+
+// NewPublisher creates a publisher from a connection string:
+//
+//	nats://host:4222/subject        NATS subject
+//	kafka+http://host:8082/topic    Kafka topic via the Kafka REST Proxy
+//	kafka+https://host:8082/topic
+func NewPublisher(connString string) (Publisher, error) {
+	u, err := url.Parse(connString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publisher URL: %w", err)
+	}
+
+	target := strings.Trim(u.Path, "/")
+	if target == "" {
+		return nil, fmt.Errorf("publisher URL %q has no subject/topic", connString)
+	}
+
+	switch u.Scheme {
+	case "nats", "tls":
+		server := *u
+		server.Path = ""
+		return newNATSPublisher(server.String(), target)
+	case "kafka+http", "kafka+https":
+		base := strings.TrimPrefix(u.Scheme, "kafka+") + "://" + u.Host
+		return newKafkaRESTPublisher(base, target), nil
+	default:
+		return nil, fmt.Errorf("unsupported publisher scheme %q (use nats:// or kafka+http://)", u.Scheme)
+	}
+}
+
+// Attach publishes an event for every download completed by manager
+func Attach(manager *downloader.Manager, p Publisher) {
+	manager.OnDownloadComplete(func(meta downloader.DownloadMeta) {
+		payload, err := json.Marshal(DownloadEvent{
+			URL:         meta.URL,
+			Path:        meta.Path,
+			Bytes:       meta.Bytes,
+			ContentType: meta.ContentType,
+			Depth:       meta.Depth,
+			Interface:   meta.Interface,
+			CompletedAt: meta.CompletedAt,
+		})
+		if err != nil {
+			logger.Errorf("❌ Failed to encode download event: %v\n", err)
+			return
+		}
+		if err := p.Publish(payload); err != nil {
+			logger.Errorf("❌ Failed to publish download event for %s: %v\n", meta.URL, err)
+		}
+	})
+}
+
+// natsPublisher publishes to a NATS subject
+type natsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func newNATSPublisher(server, subject string) (*natsPublisher, error) {
+	conn, err := nats.Connect(server, nats.Name("url_crawler"))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	return &natsPublisher{conn: conn, subject: subject}, nil
+}
+
+func (p *natsPublisher) Publish(payload []byte) error {
+	return p.conn.Publish(p.subject, payload)
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+// kafkaRESTPublisher produces to a Kafka topic through the Kafka REST Proxy
+type kafkaRESTPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newKafkaRESTPublisher(base, topic string) *kafkaRESTPublisher {
+	return &kafkaRESTPublisher{
+		endpoint: base + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *kafkaRESTPublisher) Publish(payload []byte) error {
+	body, err := json.Marshal(map[string]any{
+		"records": []map[string]json.RawMessage{{"value": payload}},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Post(p.endpoint, "application/vnd.kafka.json.v2+json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *kafkaRESTPublisher) Close() error {
+	return nil
+}
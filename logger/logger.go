@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jeb/url_crawler/utils"
+)
+
+// Output modes shared by every package that prints progress.
+// The zero state matches the historical behavior: everything is printed
+// with emoji decorations and no color.
+var (
+	mu     sync.Mutex
+	output io.Writer // nil means "current os.Stdout"
+	quiet  bool
+	plain  bool
+	color  bool
+)
+
+const (
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+// SetOutput redirects all log output; nil restores os.Stdout
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	output = w
+	mu.Unlock()
+}
+
+// SetQuiet suppresses everything except errors and the final summary
+func SetQuiet(enabled bool) {
+	mu.Lock()
+	quiet = enabled
+	mu.Unlock()
+}
+
+// SetPlain strips emoji decorations and disables color
+func SetPlain(enabled bool) {
+	mu.Lock()
+	plain = enabled
+	mu.Unlock()
+}
+
+// SetColor enables ANSI colors for warnings and errors
+func SetColor(enabled bool) {
+	mu.Lock()
+	color = enabled
+	mu.Unlock()
+}
+
+// AutoColor enables color only when stdout is a terminal
+func AutoColor() {
+	SetColor(utils.IsTerminal(os.Stdout))
+}
+
+// IsQuiet reports whether quiet mode is active
+func IsQuiet() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return quiet
+}
+
+// Infof prints routine progress output (hidden in quiet mode)
+func Infof(format string, args ...any) {
+	write(false, "", format, args...)
+}
+
+// Warnf prints a warning (hidden in quiet mode)
+func Warnf(format string, args ...any) {
+	write(false, ansiYellow, format, args...)
+}
+
+// Errorf prints an error (always shown)
+func Errorf(format string, args ...any) {
+	write(true, ansiRed, format, args...)
+}
+
+// Summaryf prints final-report output (always shown)
+func Summaryf(format string, args ...any) {
+	write(true, "", format, args...)
+}
+
+// Promptf prints interactive prompts and menus (always shown)
+func Promptf(format string, args ...any) {
+	write(true, "", format, args...)
+}
+
+func write(always bool, colorCode, format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if quiet && !always {
+		return
+	}
+
+	msg := fmt.Sprintf(format, args...)
+	if plain {
+		msg = StripDecorations(msg)
+	} else if color && colorCode != "" {
+		body := strings.TrimRight(msg, "\n")
+		msg = colorCode + body + ansiReset + msg[len(body):]
+	}
+
+	w := output
+	if w == nil {
+		w = os.Stdout
+	}
+	io.WriteString(w, msg)
+}
+
+// StripDecorations removes emoji (and the space following each) from s
+func StripDecorations(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	skipSpace := false
+	for _, r := range s {
+		if isDecoration(r) {
+			skipSpace = true
+			continue
+		}
+		if skipSpace && r == ' ' {
+			skipSpace = false
+			continue
+		}
+		skipSpace = false
+		b.WriteRune(r)
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isDecoration reports whether r is an emoji or emoji modifier
+func isDecoration(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, etc.
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols and dingbats
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // misc technical (⏱ ⏳)
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // arrows and stars
+		return true
+	case r == 0xFE0F || r == 0x200D: // variation selector, zero-width joiner
+		return true
+	}
+	return false
+}
@@ -2,20 +2,240 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jeb/url_crawler/checkpoint"
+	"github.com/jeb/url_crawler/config"
 	"github.com/jeb/url_crawler/crawler"
 	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/events"
+	"github.com/jeb/url_crawler/logger"
 	"github.com/jeb/url_crawler/monitor"
 	"github.com/jeb/url_crawler/network"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/statsdb"
 	"github.com/jeb/url_crawler/system"
+	"github.com/jeb/url_crawler/tokenizer"
+	"github.com/jeb/url_crawler/utils"
+	"github.com/jeb/url_crawler/warc"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tokenize":
+			os.Exit(runTokenize(os.Args[2:]))
+		}
+	}
+
+	tui := flag.Bool("tui", false, "show a live in-place dashboard instead of line logging (TTY only)")
+	timeseriesPath := flag.String("timeseries", "", "append a CSV row per second to this file: workers, queue depth, downloads/s, Mbps, heap")
+	quiet := flag.Bool("quiet", false, "only print errors and the final summary")
+	plain := flag.Bool("plain", false, "strip emoji and color from output")
+	noEmoji := flag.Bool("no-emoji", false, "alias for -plain")
+	mode := flag.String("mode", "crawl", "crawl (follow pages and documents) or documents (document hunt: depth 5, 2000 pages per host, documents detected on every page, HEAD probes); explicit flags override the mode")
+	docTypes := flag.String("doc-types", "pdf", "comma-separated file extensions downloaded as documents, e.g. pdf,docx,xlsx")
+	maxDepth := flag.Int("max-depth", config.MaxDepth, "max link hops from the seeds (-mode documents defaults to 5)")
+	sizeRules := flag.String("size-rules", "", "route pages by size instead of the fast/slow thresholds: comma-separated MIN-MAX=path rules, first match wins, e.g. 40KB-200KB=slow,*=fast (path: fast, slow or skip)")
+	fastDocs := flag.Bool("fast-docs", false, "also detect and download documents linked from fast-path pages")
+	schemes := flag.String("schemes", strings.Join(tokenizer.DefaultSchemes, ","), "comma-separated link schemes to follow; links with others (data:, blob:, tel:, ftp:...) are dropped")
+	dedupSlash := flag.Bool("dedup-slash", false, "treat /dir and /dir/ as the same page")
+	dedupIndex := flag.Bool("dedup-index", false, "treat /dir/index.html, default.aspx and similar as the same page as /dir/")
+	dedupStrategy := flag.String("dedup", "url", "what makes a fetched page a duplicate whose links are not followed: url (only its URL), content (same body as another page, on any host) or canonical (same rel=canonical URL as another page)")
+	scope := flag.String("scope", "any", "which pages to crawl: any, host (start host only), prefix (paths starting with the start path), subtree (start URL's directory) or domain (start URL's registered domain and its subdomains)")
+	var excludes stringList
+	flag.Var(&excludes, "exclude", "skip discovered URLs matching this regexp (repeatable; adds to the built-in logout/calendar traps)")
+	noDefaultExcludes := flag.Bool("no-default-excludes", false, "do not skip logout, calendar and ?date= URLs by default")
+	maxURLLength := flag.Int("max-url-length", config.MaxURLLength, "skip discovered URLs longer than this (0 = unlimited)")
+	maxLinks := flag.Int("max-links", config.MaxLinksPerPage, "follow at most this many links from one page, dropping the rest (0 = unlimited)")
+	paginationPriority := flag.Int("pagination-priority", 0, "visit a page's rel=next page this many priority levels ahead of its other links, at the same depth, so paginated listings are followed to the end (0 = follow it like any link)")
+	hostPages := flag.Int("host-pages", 0, "max pages crawled per host; further URLs on that host are skipped (0 = unlimited)")
+	streamPages := flag.String("stream-pages", "0", "stream HTML pages whose Content-Length is at least this, e.g. 2MB, through the fast path instead of buffering them (0 = never)")
+	refetchTruncated := flag.Bool("refetch-truncated", false, "refetch pages cut off at the 5MB page limit in full so their trailing links are found")
+	scanScripts := flag.Bool("scan-scripts", false, "also extract URLs from inline <script> JSON/JS (may add false positives)")
+	visitedTSV := flag.Bool("visited-tsv", false, "write the visited log as url, depth and timestamp columns (TSV)")
+	cacheDir := flag.String("cache-dir", "", "keep the page cache in this directory between runs (default: fresh .colly_cache)")
+	cacheMaxAge := flag.Duration("cache-max-age", 0, "refetch cached pages older than this, e.g. 24h (0 = never)")
+	filenames := flag.String("filenames", "url", "how to name saved files: url (from the URL path) or hash (SHA-256 of the URL plus extension)")
+	noSniff := flag.Bool("no-sniff", false, "do not infer a missing file extension from Content-Type or the file's first bytes")
+	captureHeaders := flag.Bool("capture-headers", false, "save each downloaded file's response status and headers as <file>.headers.json")
+	conditional := flag.Bool("conditional", false, "re-request files already on disk with If-Modified-Since and keep them when the server answers 304 Not Modified")
+	verify := flag.Bool("verify", false, "re-read each saved file and check its SHA-256, and any Content-MD5/Digest header; mismatches are removed and retried")
+	manifestPath := flag.String("manifest", "", "record saved file, URL, size, TTFB and transfer time as TSV in this file (default with -filenames hash: <target-dir>/manifest.tsv)")
+	minSize := flag.String("min-size", "0", "skip documents smaller than this, e.g. 1KB (0 = no minimum)")
+	maxSize := flag.String("max-size", "0", "skip documents larger than this, e.g. 500MB; counted while downloading, so it also holds without a Content-Length (0 = the 50GB built-in cap)")
+	successCodes := flag.String("success-codes", "200", "response codes saved as successful downloads, e.g. 200,206 or 2xx")
+	overwrite := flag.String("overwrite", "overwrite", "when a file already exists: overwrite, skip or rename")
+	perHostRate := flag.Float64("per-host-rate", 0, "max download requests per second to any one host, across all NICs (0 = unlimited)")
+	var extraDirs stringList
+	flag.Var(&extraDirs, "extra-dir", "another directory to save files in, e.g. on a second disk (repeatable)")
+	dirPolicy := flag.String("dir-policy", "round-robin", "how files are spread over target and extra dirs: round-robin, host or free-space")
+	remountCmd := flag.String("remount-cmd", "", "shell command run while the target directory is unwritable, e.g. to remount a network share")
+	downloadRate := flag.Float64("download-rate", 0, "max document downloads per second across all hosts and NICs (0 = unlimited)")
+	crawlRate := flag.Float64("crawl-rate", 0, "max page requests per second across all hosts (0 = unlimited)")
+	retryBase := flag.Duration("retry-base", config.RetryBackoff, "base delay before retrying a failed download; doubles per attempt, with full jitter")
+	breakerWindow := flag.Int("breaker-window", 0, "open a host's circuit breaker based on its last N downloads: while open its tasks are deferred, then one probes the host (0 disables)")
+	breakerRate := flag.Float64("breaker-failure-rate", config.BreakerFailureRate, "share of a host's last -breaker-window downloads that must fail (network errors, 5xx, 429) to open its breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", config.BreakerCooldown, "how long an open circuit breaker defers a host's tasks before probing it")
+	retryCap := flag.Duration("retry-cap", config.RetryBackoffCap, "longest delay before retrying a failed download")
+	downloadDelay := flag.Duration("download-delay", 0, "min delay between downloads from one host, e.g. 500ms (alternative to -per-host-rate)")
+	var hostRates stringList
+	var nicRates stringList
+	var sourceIPs stringList
+	flag.Var(&sourceIPs, "source-ips", "addresses on one network interface to rotate connections over, as name=ip1,ip2,... e.g. aliases of one NIC (repeatable)")
+	flag.Var(&nicRates, "nic-rate", "download rate for one network interface as name=rps, replacing the default sized from its workers (repeatable)")
+	flag.Var(&hostRates, "host-rate", "download rate for one host as host=rps, e.g. files.example.gov=0.5 (repeatable)")
+	checkEgress := flag.String("check-egress", "", "before crawling, fetch this URL through each interface to confirm it egresses with its own source IP; the URL must return the caller's IP as plain text (e.g. https://api.ipify.org)")
+	strictBind := flag.Bool("strict-bind", false, "exit if a selected interface can't be bound to its IP, instead of falling back to the default route")
+	maxInflight := flag.Int("max-inflight", 0, "max HTTP requests in flight at once across crawling and downloading (0 = unlimited)")
+	maxHosts := flag.Int("max-hosts", 0, "max distinct hosts downloaded from at once; tasks for further hosts wait (0 = unlimited)")
+	priorityEvery := flag.Int("priority-every", 1, "check the retry/overflow queue first on 1 in N worker passes (1 = always, 0 = only when a worker's own queue is empty)")
+	hostQueues := flag.Bool("host-queues", false, "shard download queues by host so one slow host cannot starve the rest")
+	schemeRewrite := flag.String("scheme-rewrite", "off", "rewrite discovered links' scheme before queueing them: off, https (upgrade http links) or seed (use the scheme of the seed on the same host); a rewritten link that fails is tried as linked")
+	pdfLinks := flag.Bool("pdf-links", false, "follow links found in downloaded PDFs (annotations, table of contents, text), within the usual depth and scope")
+	robots := flag.Bool("robots", false, "obey robots.txt, robots meta tags and X-Robots-Tag (nofollow pages' links are not followed)")
+	headProbe := flag.Bool("head-probe", false, "send HEAD requests to extension-less links and download those served as documents")
+	traceRouting := flag.Bool("trace-routing", false, "log which routing rule sent each page to the fast or slow path")
+	statsDBPath := flag.String("stats-db", "", "log pages and downloads into this SQLite database (requires a -tags sqlite build)")
+	warcDir := flag.String("warc", "", "also archive page and document responses as rotating .warc.gz files in this directory")
+	profile := flag.String("profile", "beast", "concurrency preset: gentle (polite, for third-party sites), balanced, or beast (aggressive); default and slow are beast with normal or relaxed timeouts")
+	connectTimeout := flag.Duration("connect-timeout", 0, "TCP connect timeout (overrides -profile)")
+	tlsTimeout := flag.Duration("tls-timeout", 0, "TLS handshake timeout (overrides -profile)")
+	headerTimeout := flag.Duration("header-timeout", 0, "time to wait for response headers (overrides -profile)")
+	requestTimeout := flag.Duration("request-timeout", 0, "whole-request timeout, including the body (overrides -profile)")
+	checkpointPath := flag.String("checkpoint", "", "periodically save the frontier and download queue to this file")
+	checkpointInterval := flag.Duration("checkpoint-interval", time.Minute, "how often to write the -checkpoint file")
+	resume := flag.Bool("resume", false, "resume from the -checkpoint file instead of starting from the seeds")
+	reportPath := flag.String("report", "", "write the effective configuration and final statistics as JSON to this file")
+	tokenCmd := flag.String("token-cmd", "", "shell command printing a bearer token, run again whenever the token is older than -token-ttl or rejected with 401")
+	tokenTTL := flag.Duration("token-ttl", config.BearerTokenTTL, "how long a token from -token-cmd is reused")
+	var tokenHosts stringList
+	flag.Var(&tokenHosts, "token-host", "host that receives the bearer token, subdomains included (repeatable; default: the start URL's host)")
+	httpAddr := flag.String("http", "", "serve /stats, /healthz and /readyz on this address, e.g. :8080")
+	publish := flag.String("publish", "", "publish download events as JSON (nats://host:4222/subject or kafka+http://rest-proxy:8082/topic)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [start-url] [target-dir]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s tokenize [flags] <url>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	logger.SetQuiet(*quiet)
+	logger.SetPlain(*plain || *noEmoji)
+	logger.AutoColor()
+
+	overwritePolicy, err := downloader.ParseOverwritePolicy(*overwrite)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	filenameMode, err := downloader.ParseFilenameMode(*filenames)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	outputDirPolicy, err := downloader.ParseDirPolicy(*dirPolicy)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	crawlScope, err := crawler.ParseScope(*scope)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	linkSchemeRewrite, err := crawler.ParseSchemeRewrite(*schemeRewrite)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	pageDedup, err := crawler.ParseDedupStrategy(*dedupStrategy)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	successCodeList, err := downloader.ParseStatusCodes(*successCodes)
+	if err != nil {
+		logger.Errorf("❌ -success-codes: %v\n", err)
+		return
+	}
+	minSizeBytes, err := utils.ParseBytes(*minSize)
+	if err != nil {
+		logger.Errorf("❌ -min-size: %v\n", err)
+		return
+	}
+	streamPagesBytes, err := utils.ParseBytes(*streamPages)
+	if err != nil {
+		logger.Errorf("❌ -stream-pages: %v\n", err)
+		return
+	}
+	maxSizeBytes, err := utils.ParseBytes(*maxSize)
+	if err != nil {
+		logger.Errorf("❌ -max-size: %v\n", err)
+		return
+	}
+
+	routingRules, err := tokenizer.ParseSizeRules(*sizeRules)
+	if err != nil {
+		logger.Errorf("❌ -size-rules: %v\n", err)
+		return
+	}
+	runMode, err := config.LookupMode(*mode)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	if !explicit["max-depth"] {
+		*maxDepth = runMode.MaxDepth
+	}
+	if !explicit["host-pages"] {
+		*hostPages = runMode.HostPages
+	}
+	if !explicit["fast-docs"] {
+		*fastDocs = runMode.FastPathDocs
+	}
+	if !explicit["head-probe"] {
+		*headProbe = runMode.HeadProbe
+	}
+
+	runProfile, err := config.LookupProfile(*profile)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	timeouts, err := network.TimeoutProfile(runProfile.Timeouts)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	if *connectTimeout > 0 {
+		timeouts.Connect = *connectTimeout
+	}
+	if *tlsTimeout > 0 {
+		timeouts.TLSHandshake = *tlsTimeout
+	}
+	if *headerTimeout > 0 {
+		timeouts.ResponseHeader = *headerTimeout
+	}
+	if *requestTimeout > 0 {
+		timeouts.Request = *requestTimeout
+	}
+	network.SetTimeouts(timeouts)
+	network.SetMaxInflight(*maxInflight)
+
 	// BEAST MODE SYSTEM CONFIGURATION
 	monitor.SetupBeastMode()
 
@@ -24,21 +244,21 @@ func main() {
 	// Detect and configure network interfaces
 	networkInterfaces, err := network.DetectNetworkInterfaces()
 	if err != nil {
-		fmt.Printf("❌ Failed to detect network interfaces: %v\n", err)
+		logger.Errorf("❌ Failed to detect network interfaces: %v\n", err)
 		return
 	}
 
 	// Let user select which interfaces to use
 	selectedInterfaces := network.SelectNetworkInterfaces(networkInterfaces)
 	if len(selectedInterfaces) == 0 {
-		fmt.Println("❌ No network interfaces selected")
+		logger.Errorf("❌ No network interfaces selected\n")
 		return
 	}
 
 	// Configure selected interfaces
 	networkInterfaces, err = network.ConfigureSelectedInterfaces(networkInterfaces, selectedInterfaces)
 	if err != nil {
-		fmt.Printf("❌ Failed to configure interfaces: %v\n", err)
+		logger.Errorf("❌ Failed to configure interfaces: %v\n", err)
 		return
 	}
 
@@ -46,31 +266,59 @@ func main() {
 	system.IncreaseFileDescriptorLimit()
 	system.OptimizeNetworkSettings()
 
-	// Get user input
+	// Get user input: positional arguments first, then interactive prompts
 	var startURL, targetDir string
-	fmt.Println("\nEnter the starting URL to crawl:")
-	fmt.Scanln(&startURL)
-
-	fmt.Println("Enter the target directory to save files:")
-	fmt.Scanln(&targetDir)
+	if args := flag.Args(); len(args) > 0 {
+		startURL = args[0]
+		if len(args) > 1 {
+			targetDir = args[1]
+		}
+	}
+	if startURL == "" {
+		logger.Promptf("\nEnter the starting URL to crawl:\n")
+		startURL = utils.ReadLine()
+	}
+	if targetDir == "" {
+		logger.Promptf("Enter the target directory to save files:\n")
+		targetDir = utils.ReadLine()
+	}
+	if targetDir == "" {
+		logger.Errorf("❌ No target directory given\n")
+		return
+	}
 
 	// URL validation
-	parsedStart, err := url.Parse(startURL)
-	if err != nil || parsedStart.Scheme == "" || parsedStart.Host == "" {
-		fmt.Printf("❌ Invalid URL: %s\n", startURL)
+	startURL, err = utils.ValidateStartURL(startURL)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
 		return
 	}
-	if parsedStart.Scheme != "http" && parsedStart.Scheme != "https" {
-		parsedStart.Scheme = "https"
-		startURL = parsedStart.String()
+
+	// Optional bearer-token authentication, never sent to other hosts
+	if *tokenCmd != "" {
+		if len(tokenHosts) == 0 {
+			tokenHosts = append(tokenHosts, utils.HostOf(startURL))
+		}
+		network.SetBearerAuth(&network.BearerAuth{
+			Token: func() (string, error) {
+				out, err := exec.Command("sh", "-c", *tokenCmd).Output()
+				if err != nil {
+					return "", fmt.Errorf("token command: %w", err)
+				}
+				return string(out), nil
+			},
+			TTL:   *tokenTTL,
+			Hosts: tokenHosts,
+		})
+		logger.Infof("🔑 Bearer token from -token-cmd for %s\n", strings.Join(tokenHosts, ", "))
 	}
 
 	// Create target directory
 	if _, err := os.Stat(targetDir); os.IsNotExist(err) {
-		fmt.Printf("📁 Creating directory: %s\n", targetDir)
+		logger.Infof("📁 Creating directory: %s\n", targetDir)
 		err = os.MkdirAll(targetDir, 0755)
 		if err != nil {
-			fmt.Printf("❌ Failed to create directory: %v\n", err)
+			logger.Errorf("❌ Failed to create directory: %v\n", err)
 			return
 		}
 	}
@@ -80,12 +328,161 @@ func main() {
 	logFilePath := fmt.Sprintf("visitedURLs_%s.txt", timestamp)
 	downloadLogPath := fmt.Sprintf("downloads_%s.txt", timestamp)
 
+	for _, si := range sourceIPs {
+		name, ips, ok := strings.Cut(si, "=")
+		if !ok || ips == "" {
+			logger.Errorf("❌ Invalid -source-ips %q (want name=ip1,ip2)\n", si)
+			return
+		}
+		if !network.SetSourceIPs(networkInterfaces, name, strings.Split(ips, ",")) {
+			logger.Errorf("❌ -source-ips: %s is not a selected interface\n", name)
+			return
+		}
+	}
+
 	// Initialize multi-NIC system
-	networkInterfaces = network.InitializeMultiNICSystem(networkInterfaces)
+	network.SetStrictBinding(*strictBind)
+	networkInterfaces, err = network.InitializeMultiNICSystem(networkInterfaces)
+	if err != nil {
+		logger.Errorf("❌ %v\n", err)
+		return
+	}
+	if *checkEgress != "" {
+		results := network.CheckEgress(context.Background(), networkInterfaces, *checkEgress)
+		if !network.ReportEgress(results) && *strictBind {
+			logger.Errorf("❌ Egress check failed with -strict-bind set\n")
+			return
+		}
+	}
 
 	// Create download manager
 	downloadManager := downloader.NewManager(networkInterfaces, targetDir, downloadLogPath)
 
+	if len(extraDirs) > 0 {
+		outputDirs := append([]string{targetDir}, extraDirs...)
+		for _, dir := range extraDirs {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				logger.Errorf("❌ Failed to create directory: %v\n", err)
+				return
+			}
+		}
+		downloadManager.SetOutputDirs(outputDirs, outputDirPolicy)
+		logger.Infof("📁 Saving across %d directories (%s)\n", len(outputDirs), *dirPolicy)
+	}
+	downloadManager.SetFilenameMode(filenameMode)
+	downloadManager.SetRetryBackoff(*retryBase, *retryCap)
+	downloadManager.SetCircuitBreaker(downloader.BreakerConfig{Window: *breakerWindow, FailureRate: *breakerRate, Cooldown: *breakerCooldown})
+	downloadManager.SetContentSniffing(!*noSniff)
+	downloadManager.SetVerifyDownloads(*verify)
+	downloadManager.SetCaptureHeaders(*captureHeaders)
+	downloadManager.SetConditionalRequests(*conditional)
+	if *manifestPath == "" && filenameMode == downloader.HashBased {
+		*manifestPath = filepath.Join(targetDir, "manifest.tsv")
+	}
+	if *manifestPath != "" {
+		if err := downloadManager.SetManifest(*manifestPath); err != nil {
+			logger.Errorf("❌ Failed to open manifest: %v\n", err)
+			return
+		}
+	}
+	downloadManager.SetHostSharding(*hostQueues)
+	downloadManager.SetPriorityPreference(*priorityEvery)
+	downloadManager.SetOverwritePolicy(overwritePolicy)
+	downloadManager.SetSizeFilter(minSizeBytes, maxSizeBytes)
+	downloadManager.SetSuccessCodes(successCodeList)
+	downloadManager.SetWorkerLimits(runProfile.InitialWorkers, runProfile.MaxWorkers)
+	hostRate := runProfile.PerHostRate
+	if *perHostRate > 0 {
+		hostRate = *perHostRate
+	}
+	if *downloadDelay > 0 {
+		hostRate = float64(time.Second) / float64(*downloadDelay)
+	}
+	downloadManager.SetPerHostRate(hostRate)
+	hostRateOverrides := make(map[string]string)
+	for _, hr := range hostRates {
+		host, rps, ok := strings.Cut(hr, "=")
+		value, err := strconv.ParseFloat(rps, 64)
+		if !ok || err != nil {
+			logger.Errorf("❌ Invalid -host-rate %q (want host=rps)\n", hr)
+			return
+		}
+		downloadManager.SetHostRate(host, value)
+		hostRateOverrides[host] = rps
+	}
+	downloadManager.SetDownloadRate(*downloadRate)
+	for _, nr := range nicRates {
+		name, rps, ok := strings.Cut(nr, "=")
+		value, err := strconv.ParseFloat(rps, 64)
+		if !ok || err != nil {
+			logger.Errorf("❌ Invalid -nic-rate %q (want name=rps)\n", nr)
+			return
+		}
+		if !downloadManager.SetInterfaceRate(name, value) {
+			logger.Errorf("❌ -nic-rate: %s is not a selected interface\n", name)
+			return
+		}
+	}
+	downloadManager.SetMaxActiveHosts(*maxHosts)
+	if *remountCmd != "" {
+		downloadManager.SetStorageRecovery(func() error {
+			return exec.Command("sh", "-c", *remountCmd).Run()
+		})
+	}
+
+	// Optional WARC archiving
+	var warcWriter *warc.Writer
+	if *warcDir != "" {
+		warcWriter, err = warc.NewWriter(*warcDir, "crawl-"+timestamp, config.WARCMaxFileSize)
+		if err != nil {
+			logger.Errorf("❌ Failed to set up WARC output: %v\n", err)
+			return
+		}
+		defer warcWriter.Close()
+		downloadManager.SetWARCWriter(warcWriter)
+	}
+
+	// Optional SQLite statistics
+	var statsDB *statsdb.Recorder
+	if *statsDBPath != "" {
+		statsDB, err = statsdb.Open(*statsDBPath)
+		if err != nil {
+			logger.Errorf("❌ Failed to open stats database: %v\n", err)
+			return
+		}
+		defer statsDB.Close()
+		downloadManager.OnDownloadComplete(func(meta downloader.DownloadMeta) {
+			statsDB.RecordDownload(statsdb.DownloadRecord{
+				URL:       meta.URL,
+				Path:      meta.Path,
+				Bytes:     meta.Bytes,
+				Status:    meta.StatusCode,
+				Interface: meta.Interface,
+				Outcome:   "ok",
+				Timestamp: meta.CompletedAt,
+			})
+		})
+		downloadManager.OnDownloadFailed(func(meta downloader.DownloadMeta, err error) {
+			statsDB.RecordDownload(statsdb.DownloadRecord{
+				URL:       meta.URL,
+				Status:    meta.StatusCode,
+				Interface: meta.Interface,
+				Outcome:   "failed",
+			})
+		})
+	}
+
+	// Optional download event publishing
+	if *publish != "" {
+		publisher, err := events.NewPublisher(*publish)
+		if err != nil {
+			logger.Errorf("❌ Failed to set up event publisher: %v\n", err)
+			return
+		}
+		defer publisher.Close()
+		events.Attach(downloadManager, publisher)
+	}
+
 	// Start download workers
 	downloadManager.StartWorkers()
 
@@ -94,23 +491,184 @@ func main() {
 
 	// Create and start monitor system
 	monitorSystem := monitor.NewMonitor(downloadManager, networkInterfaces, shutdownChan)
+	if *tui && !monitorSystem.EnableDashboard() {
+		logger.Warnf("⚠️ -tui requires a terminal, using line logging\n")
+	}
+	if *timeseriesPath != "" {
+		if err := monitorSystem.SetTimeseriesFile(*timeseriesPath); err != nil {
+			logger.Errorf("❌ Failed to open timeseries file: %v\n", err)
+			return
+		}
+	}
 	monitorSystem.StartMonitoring(16) // 16 concurrent scalers for ultra-fast response
+	if *httpAddr != "" {
+		if err := monitorSystem.StartHTTPServer(*httpAddr); err != nil {
+			logger.Errorf("❌ Failed to start monitoring server: %v\n", err)
+			return
+		}
+	}
 
 	// Create crawler
 	webCrawler := crawler.NewCrawlerTwoTier(startURL, logFilePath, downloadManager)
+	monitorSystem.TrackURLMaps(webCrawler)
+	monitorSystem.TrackCrawl(webCrawler)
+	if warcWriter != nil {
+		webCrawler.SetWARCWriter(warcWriter)
+	}
+	webCrawler.SetTimeouts(timeouts)
+	webCrawler.SetMaxDepth(*maxDepth)
+	webCrawler.SetDocumentExtensions(strings.Split(*docTypes, ","))
+	webCrawler.SetFastPathDocuments(*fastDocs)
+	webCrawler.SetSizeRules(routingRules)
+	webCrawler.SetScanInlineJSON(*scanScripts)
+	webCrawler.SetRefetchTruncated(*refetchTruncated)
+	webCrawler.SetStreamLargePages(streamPagesBytes)
+	webCrawler.SetMaxURLLength(*maxURLLength)
+	webCrawler.SetMaxLinksPerPage(*maxLinks)
+	webCrawler.SetPaginationPriority(*paginationPriority)
+	webCrawler.SetPerHostPageBudget(*hostPages)
+	webCrawler.SetVisitedLogTSV(*visitedTSV)
+	if *cacheDir != "" {
+		webCrawler.SetCache(*cacheDir, *cacheMaxAge)
+	}
+	if statsDB != nil {
+		webCrawler.SetStatsDB(statsDB)
+	}
+	webCrawler.SetHeadProbe(*headProbe)
+	webCrawler.SetFollowPDFLinks(*pdfLinks)
+	webCrawler.SetRespectRobots(*robots)
+	webCrawler.SetScope(crawlScope)
+	webCrawler.SetSchemeRewrite(linkSchemeRewrite)
+	webCrawler.SetCrawlLimits(runProfile.CrawlParallelism, runProfile.CrawlDelay)
+	webCrawler.SetGlobalCrawlRate(*crawlRate)
+	dedupRules := crawler.DedupRules{TrailingSlash: *dedupSlash, Strategy: pageDedup}
+	if *dedupIndex {
+		dedupRules.DefaultDocs = crawler.DefaultDocuments
+	}
+	webCrawler.SetDedupRules(dedupRules)
+	webCrawler.SetAllowedSchemes(strings.Split(*schemes, ","))
+	if *noDefaultExcludes {
+		webCrawler.ClearExcludePatterns()
+	}
+	for _, p := range excludes {
+		if err := webCrawler.AddExcludePattern(p); err != nil {
+			logger.Errorf("❌ %v\n", err)
+			return
+		}
+	}
+	if *traceRouting {
+		webCrawler.EnableDecisionTrace(0, func(u *url.URL, size int, decision tokenizer.PathDecision, reason string) {
+			logger.Infof("🔀 %s (%s, %s): %s\n", decision, reason, utils.FormatBytes(int64(size)), u)
+		})
+	}
+
+	if *resume {
+		if *checkpointPath == "" {
+			logger.Errorf("❌ -resume needs -checkpoint\n")
+			return
+		}
+		state, err := checkpoint.Load(*checkpointPath)
+		if err != nil {
+			logger.Errorf("❌ %v\n", err)
+			return
+		}
+		state.Restore(webCrawler, downloadManager)
+		logger.Infof("♻️ Resuming from %s: %d visited, %d pending pages, %d queued downloads\n",
+			*checkpointPath, len(state.Visited), len(state.Frontier), len(state.Downloads))
+	}
+	if *checkpointPath != "" {
+		panics.Go("checkpoint", "", func() {
+			checkpoint.Run(*checkpointPath, *checkpointInterval, startURL, webCrawler, downloadManager, shutdownChan)
+		})
+	}
 
 	// UNLEASH THE MULTI-NIC BEAST!
-	monitor.PrintStartupInfo(startURL, targetDir, networkInterfaces)
+	monitor.PrintStartupInfo(startURL, targetDir, *maxDepth, runProfile, networkInterfaces)
+	effective := runConfig{
+		StartURL:         startURL,
+		OutputDirs:       append([]string{targetDir}, extraDirs...),
+		DirPolicy:        *dirPolicy,
+		Profile:          runProfile.Name,
+		Mode:             runMode.Name,
+		DocTypes:         *docTypes,
+		MaxDepth:         *maxDepth,
+		CrawlParallelism: runProfile.CrawlParallelism,
+		CrawlDelay:       runProfile.CrawlDelay.String(),
+		CrawlRate:        *crawlRate,
+		Scope:            *scope,
+		SchemeRewrite:    *schemeRewrite,
+		DefaultExcludes:  !*noDefaultExcludes,
+		Excludes:         excludes,
+		MaxURLLength:     *maxURLLength,
+		MaxLinksPerPage:  *maxLinks,
+		PaginationBoost:  *paginationPriority,
+		HostPageBudget:   *hostPages,
+		StreamPages:      streamPagesBytes,
+		DedupSlash:       *dedupSlash,
+		DedupIndex:       *dedupIndex,
+		DedupStrategy:    pageDedup.String(),
+		Schemes:          *schemes,
+		FastPathDocs:     *fastDocs,
+		SizeRules:        *sizeRules,
+		PDFLinks:         *pdfLinks,
+		Robots:           *robots,
+		InitialWorkers:   runProfile.InitialWorkers,
+		MaxWorkers:       runProfile.MaxWorkers,
+		PerHostRate:      hostRate,
+		HostRates:        hostRateOverrides,
+		DownloadRate:     *downloadRate,
+		MaxActiveHosts:   *maxHosts,
+		MaxInflight:      *maxInflight,
+		TokenHosts:       tokenHosts,
+		PriorityEvery:    *priorityEvery,
+		RetryBase:        retryBase.String(),
+		BreakerWindow:    *breakerWindow,
+		BreakerRate:      *breakerRate,
+		BreakerCooldown:  breakerCooldown.String(),
+		RetryCap:         retryCap.String(),
+		Overwrite:        *overwrite,
+		Filenames:        *filenames,
+		Verify:           *verify,
+		CaptureHeaders:   *captureHeaders,
+		Conditional:      *conditional,
+		SuccessCodes:     *successCodes,
+		MinSize:          minSizeBytes,
+		MaxSize:          maxSizeBytes,
+		ConnectTimeout:   timeouts.Connect.String(),
+		TLSTimeout:       timeouts.TLSHandshake.String(),
+		HeaderTimeout:    timeouts.ResponseHeader.String(),
+		RequestTimeout:   timeouts.Request.String(),
+	}
+	effective.InterfaceRates = make(map[string]float64)
+	nicLimits := downloadManager.GetInterfaceRates()
+	for i, iface := range networkInterfaces {
+		effective.Interfaces = append(effective.Interfaces, iface.Name+iface.BindingNote())
+		effective.InterfaceRates[iface.Name] = nicLimits[i]
+		if ips := iface.SourceIPs(); len(ips) > 1 && !iface.Unbound {
+			if effective.SourceIPs == nil {
+				effective.SourceIPs = make(map[string][]string)
+			}
+			effective.SourceIPs[iface.Name] = ips
+		}
+	}
+	effective.print()
+	startedAt := time.Now()
 
 	err = webCrawler.Start()
 	if err != nil {
-		fmt.Printf("❌ Failed to start crawl: %v\n", err)
+		logger.Errorf("❌ Failed to start crawl: %v\n", err)
 		return
 	}
 
 	// Wait for crawling to complete
 	webCrawler.Wait()
 
+	if *checkpointPath != "" {
+		if err := checkpoint.Capture(startURL, webCrawler, downloadManager).Save(*checkpointPath); err != nil {
+			logger.Errorf("❌ Failed to save checkpoint: %v\n", err)
+		}
+	}
+
 	// Shutdown sequence
 	close(shutdownChan)
 	monitorSystem.Wait()
@@ -118,4 +676,54 @@ func main() {
 
 	// Print final statistics
 	monitor.PrintFinalStats(downloadManager, networkInterfaces)
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, effective, startedAt, downloadManager, webCrawler); err != nil {
+			logger.Errorf("❌ Failed to write report: %v\n", err)
+		} else {
+			logger.Summaryf("📄 Report saved to %s\n", *reportPath)
+		}
+	}
+
+	// Save documents that never made it into a queue so they can be re-run
+	droppedPath := fmt.Sprintf("dropped_%s.txt", timestamp)
+	if n, err := downloadManager.WriteDroppedDownloads(droppedPath); err != nil {
+		logger.Errorf("❌ Failed to write dropped downloads: %v\n", err)
+	} else if n > 0 {
+		logger.Summaryf("⚠️ %d dropped downloads saved to %s\n", n, droppedPath)
+	}
+
+	// Save why downloads failed, so a re-run can target the fixable ones
+	failuresPath := fmt.Sprintf("failures_%s.tsv", timestamp)
+	if n, err := downloadManager.WriteFailures(failuresPath); err != nil {
+		logger.Errorf("❌ Failed to write failures report: %v\n", err)
+	} else if n > 0 {
+		classes := downloadManager.GetFailureClasses()
+		var reasons []string
+		for _, class := range sortedKeys(classes) {
+			reasons = append(reasons, fmt.Sprintf("%s: %d", class, classes[class]))
+		}
+		logger.Summaryf("❌ %d failed downloads (%s) saved to %s\n", n, strings.Join(reasons, ", "), failuresPath)
+	}
+
+	// Save recovered panics with their stacks, for a bug report
+	if n := panics.Count(); n > 0 {
+		panicsPath := fmt.Sprintf("panics_%s.json", timestamp)
+		if err := panics.WriteReport(panicsPath); err != nil {
+			logger.Errorf("❌ Failed to write panic report: %v\n", err)
+		} else {
+			logger.Summaryf("🛑 %d panics recovered, details saved to %s\n", n, panicsPath)
+		}
+	}
+}
+
+// stringList collects the values of a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// workerCeiling lowers the scaler's worker limit while hosts push back
+// (429s, 5xx, network errors) and raises it again once they recover.
+// All scaler goroutines share one instance.
+type workerCeiling struct {
+	mu         sync.Mutex
+	limit      int
+	lastCheck  time.Time
+	lastCounts map[int]int64
+}
+
+func newWorkerCeiling() *workerCeiling {
+	return &workerCeiling{}
+}
+
+// current returns the ceiling, re-evaluating it once per window
+func (w *workerCeiling) current(dm *downloader.Manager) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The manager's limit is read late so it can be set after NewMonitor
+	maxWorkers := dm.GetMaxWorkers()
+	if w.limit == 0 || w.limit > maxWorkers {
+		w.limit = maxWorkers
+	}
+
+	if time.Since(w.lastCheck) < config.ErrorCeilingWindow {
+		return w.limit
+	}
+	w.lastCheck = time.Now()
+
+	counts := dm.GetStatusDistribution()
+	var total, errors, throttled int64
+	for code, n := range counts {
+		delta := n - w.lastCounts[code]
+		total += delta
+		switch {
+		case code == http.StatusTooManyRequests:
+			throttled += delta
+			errors += delta
+		case code == downloader.StatusNetworkError || code >= 500:
+			errors += delta
+		}
+	}
+	w.lastCounts = counts
+
+	if total < config.ErrorCeilingMinSamples {
+		return w.limit
+	}
+
+	throttleRate := float64(throttled) / float64(total)
+	errorRate := float64(errors) / float64(total)
+
+	switch {
+	case throttleRate > config.ErrorCeiling429Rate || errorRate > config.ErrorCeilingErrorRate:
+		lowered := max(int(float64(w.limit)*config.ErrorCeilingBackoff), min(config.ErrorCeilingFloor, maxWorkers))
+		if lowered < w.limit {
+			logger.Warnf("⚠️ Worker ceiling lowered %d → %d (429: %.1f%%, errors: %.1f%%)\n",
+				w.limit, lowered, throttleRate*100, errorRate*100)
+			w.limit = lowered
+		}
+	case errorRate < config.ErrorCeilingErrorRate/2 && throttleRate < config.ErrorCeiling429Rate/2:
+		raised := min(w.limit+config.ScaleUpAmount, maxWorkers)
+		if raised > w.limit {
+			logger.Infof("📈 Worker ceiling raised %d → %d\n", w.limit, raised)
+			w.limit = raised
+		}
+	}
+
+	return w.limit
+}
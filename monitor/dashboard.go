@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/utils"
+)
+
+const (
+	dashboardRefresh   = 1 * time.Second
+	dashboardLogLines  = 8
+	dashboardErrLines  = 5
+	dashboardBarWidth  = 30
+	ansiClearAndHome   = "\033[H\033[2J"
+	ansiHideCursor     = "\033[?25l"
+	ansiShowCursor     = "\033[?25h"
+	dashboardLineLimit = 1024 * 1024
+)
+
+// dashboard renders a compact, in-place terminal view of the crawl.
+// While active it captures the shared logger and the standard library logger
+// so the usual line-by-line output becomes a "recent events" pane.
+type dashboard struct {
+	out        *os.File // the real terminal
+	pipeReader *os.File
+	pipeWriter *os.File
+	readerDone chan struct{}
+
+	mu         sync.Mutex
+	recentLogs []string
+	recentErrs []string
+
+	lastSuccess int64
+	lastBytes   int64
+	lastTick    time.Time
+}
+
+// EnableDashboard switches the monitor to the in-place terminal dashboard.
+// It returns false, leaving line logging in place, when stdout is not a TTY.
+func (m *Monitor) EnableDashboard() bool {
+	if !utils.IsTerminal(os.Stdout) {
+		return false
+	}
+	m.dashboard = &dashboard{out: os.Stdout}
+	return true
+}
+
+// start redirects the loggers into the dashboard
+func (d *dashboard) start() error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	d.pipeReader, d.pipeWriter = r, w
+	d.readerDone = make(chan struct{})
+	d.lastTick = time.Now()
+
+	logger.SetOutput(w)
+	log.SetOutput(w)
+	fmt.Fprint(d.out, ansiHideCursor)
+
+	panics.Go("dashboard", "", func() { d.captureOutput(r) })
+	return nil
+}
+
+// stop restores the loggers
+func (d *dashboard) stop() {
+	logger.SetOutput(nil)
+	log.SetOutput(os.Stderr)
+	d.pipeWriter.Close()
+	<-d.readerDone
+	d.pipeReader.Close()
+	fmt.Fprint(d.out, ansiShowCursor+"\n")
+}
+
+// captureOutput collects redirected output lines into the recent panes
+func (d *dashboard) captureOutput(r io.Reader) {
+	defer close(d.readerDone)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), dashboardLineLimit)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		d.mu.Lock()
+		d.recentLogs = appendRecent(d.recentLogs, line, dashboardLogLines)
+		if isErrorLine(line) {
+			d.recentErrs = appendRecent(d.recentErrs, line, dashboardErrLines)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// render draws one frame of the dashboard
+func (d *dashboard) render(m *Monitor) {
+	attempts, success, failed, bytes, elapsed := m.downloadManager.GetStats()
+	workers := m.downloadManager.GetActiveWorkers()
+	totalQueued, totalCapacity := m.downloadManager.GetQueueStatus()
+
+	now := time.Now()
+	window := now.Sub(d.lastTick)
+	throughput := perSecond(float64(success-d.lastSuccess), window)
+	mbps := perSecond(float64(bytes-d.lastBytes)*8/1024/1024, window)
+	d.lastSuccess, d.lastBytes, d.lastTick = success, bytes, now
+
+	var b strings.Builder
+	b.WriteString(ansiClearAndHome)
+	fmt.Fprintf(&b, "MULTI-NIC CRAWLER — %s elapsed\n\n", elapsed.Truncate(time.Second))
+	fmt.Fprintf(&b, "Workers:    %d\n", workers)
+	fmt.Fprintf(&b, "Queue:      %s %d/%d\n", progressBar(totalQueued, totalCapacity), totalQueued, totalCapacity)
+	fmt.Fprintf(&b, "Downloads:  %d attempts, %d success, %d failed\n", attempts, success, failed)
+	fmt.Fprintf(&b, "Throughput: %.1f dl/s, %.1f Mbps (%s total)\n", throughput, mbps, utils.FormatBytes(bytes))
+	if s, ok := m.crawlStats(); ok {
+		fmt.Fprintf(&b, "Crawl:      %d active/%s, %d queued | %d pages, %d failed, %.1f pages/s\n",
+			s.Active, parallelismLimit(s.Parallelism), s.Queued, s.Fetched, s.Failed, s.Rate())
+	}
+	b.WriteString("\n")
+
+	b.WriteString("Interfaces:\n")
+	downloadQueues := m.downloadManager.GetDownloadQueues()
+	for i, iface := range m.networkInterfaces {
+		if i >= len(downloadQueues) {
+			break
+		}
+		queueLen, queueCap := len(downloadQueues[i]), cap(downloadQueues[i])
+		fmt.Fprintf(&b, "  %-12s %s %d/%d\n", iface.Name, progressBar(queueLen, queueCap), queueLen, queueCap)
+	}
+
+	d.mu.Lock()
+	b.WriteString("\nRecent errors:\n")
+	for _, line := range d.recentErrs {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	b.WriteString("\nRecent events:\n")
+	for _, line := range d.recentLogs {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	d.mu.Unlock()
+
+	fmt.Fprint(d.out, b.String())
+}
+
+// dashboardMonitor refreshes the dashboard until shutdown
+func (m *Monitor) dashboardMonitor() {
+	defer m.wg.Done()
+
+	if err := m.dashboard.start(); err != nil {
+		logger.Warnf("⚠️ Dashboard unavailable, falling back to line logging: %v\n", err)
+		m.dashboard = nil
+		m.wg.Add(2)
+		go m.performanceMonitor()
+		go m.networkMonitor()
+		return
+	}
+	defer m.dashboard.stop()
+
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.shutdownChan:
+			m.dashboard.render(m)
+			return
+		case <-ticker.C:
+			m.dashboard.render(m)
+		}
+	}
+}
+
+// progressBar renders value/total as a fixed-width bar
+func progressBar(value, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = value * dashboardBarWidth / total
+	}
+	filled = max(0, min(filled, dashboardBarWidth))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", dashboardBarWidth-filled) + "]"
+}
+
+// appendRecent appends line, keeping at most limit entries
+func appendRecent(lines []string, line string, limit int) []string {
+	lines = append(lines, line)
+	if len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return lines
+}
+
+// isErrorLine reports whether a captured line looks like an error or warning
+func isErrorLine(line string) bool {
+	return strings.Contains(line, "❌") ||
+		strings.Contains(line, "⚠") ||
+		strings.Contains(line, "🛑") ||
+		strings.Contains(strings.ToLower(line), "error")
+}
@@ -2,23 +2,56 @@ package monitor
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/crawler"
 	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
 	"github.com/jeb/url_crawler/network"
 	"github.com/jeb/url_crawler/utils"
 )
 
+// maxDomainStatsRows caps the per-domain breakdown in the final report
+const maxDomainStatsRows = 25
+
 // Monitor manages system monitoring and auto-scaling
 type Monitor struct {
 	downloadManager   *downloader.Manager
 	networkInterfaces []network.NetworkInterface
 	shutdownChan      chan struct{}
 	wg                sync.WaitGroup
+	dashboard         *dashboard  // nil unless EnableDashboard succeeded
+	timeseries        *timeseries // nil unless SetTimeseriesFile
+	ceiling           *workerCeiling
+	urlMapsMu         sync.Mutex
+	urlMaps           []URLMapSource
+	crawlMu           sync.Mutex
+	crawl             CrawlSource // nil until TrackCrawl
+	lastCrawl         crawlTick   // Only touched by performanceMonitor
+	peakGoroutines    int         // Only touched by memoryMonitor
+}
+
+// URLMapSource reports the estimated size of its URL bookkeeping maps
+type URLMapSource interface {
+	URLMapSizes() []utils.MapSize
+}
+
+// CrawlSource reports the page-fetching side of the crawl
+type CrawlSource interface {
+	GetCrawlStats() crawler.CrawlStats
+}
+
+// crawlTick is the crawl counter at the last report, for the recent rate
+type crawlTick struct {
+	fetched int64
+	at      time.Time
 }
 
 // NewMonitor creates a new monitor instance
@@ -27,9 +60,40 @@ func NewMonitor(downloadManager *downloader.Manager, networkInterfaces []network
 		downloadManager:   downloadManager,
 		networkInterfaces: networkInterfaces,
 		shutdownChan:      shutdownChan,
+		ceiling:           newWorkerCeiling(),
+		urlMaps:           []URLMapSource{downloadManager},
 	}
 }
 
+// TrackURLMaps adds src (typically the crawler) to the URL map sizes
+// reported by the memory monitor
+func (m *Monitor) TrackURLMaps(src URLMapSource) {
+	m.urlMapsMu.Lock()
+	m.urlMaps = append(m.urlMaps, src)
+	m.urlMapsMu.Unlock()
+}
+
+// TrackCrawl adds src's page-fetching statistics to the performance
+// output, next to the download statistics
+func (m *Monitor) TrackCrawl(src CrawlSource) {
+	m.crawlMu.Lock()
+	m.crawl = src
+	m.crawlMu.Unlock()
+}
+
+// crawlStats returns the tracked crawl's statistics; ok is false before
+// TrackCrawl
+func (m *Monitor) crawlStats() (s crawler.CrawlStats, ok bool) {
+	m.crawlMu.Lock()
+	src := m.crawl
+	m.crawlMu.Unlock()
+
+	if src == nil {
+		return s, false
+	}
+	return src.GetCrawlStats(), true
+}
+
 // StartMonitoring starts all monitoring goroutines
 func (m *Monitor) StartMonitoring(scalerCount int) {
 	// Start multiple scalers for ultra-fast response
@@ -39,10 +103,21 @@ func (m *Monitor) StartMonitoring(scalerCount int) {
 	}
 
 	m.wg.Add(1)
-	go m.performanceMonitor()
+	go m.memoryMonitor()
+
+	if m.timeseries != nil {
+		m.wg.Add(1)
+		go m.timeseriesMonitor()
+	}
+
+	if m.dashboard != nil {
+		m.wg.Add(1)
+		go m.dashboardMonitor()
+		return
+	}
 
 	m.wg.Add(1)
-	go m.memoryMonitor()
+	go m.performanceMonitor()
 
 	m.wg.Add(1)
 	go m.networkMonitor()
@@ -72,10 +147,14 @@ func (m *Monitor) downloadScaler() {
 // checkAndScaleMultiNIC checks queue utilization and scales workers
 func (m *Monitor) checkAndScaleMultiNIC() {
 	totalQueued, totalCapacity := m.downloadManager.GetQueueStatus()
+	if totalCapacity == 0 {
+		return
+	}
 	utilization := float64(totalQueued) / float64(totalCapacity)
 	currentWorkers := m.downloadManager.GetActiveWorkers()
+	maxWorkers := m.ceiling.current(m.downloadManager)
 
-	if utilization > config.QueueGrowthThreshold && currentWorkers < config.MaxDownloadWorkers {
+	if utilization > config.QueueGrowthThreshold && currentWorkers < int64(maxWorkers) {
 		// Determine scale amount based on utilization
 		scaleAmount := config.ScaleUpAmount
 		if utilization > 0.8 {
@@ -84,10 +163,10 @@ func (m *Monitor) checkAndScaleMultiNIC() {
 			scaleAmount = config.ScaleUpAmount * 2 // Double scaling when very full
 		}
 
-		newWorkersTotal := min(scaleAmount, config.MaxDownloadWorkers-int(currentWorkers))
+		newWorkersTotal := min(scaleAmount, maxWorkers-int(currentWorkers))
 		if newWorkersTotal > 0 {
 			m.downloadManager.AddWorkers(newWorkersTotal)
-			fmt.Printf("📈 Multi-NIC scaled: +%d workers across %d interfaces (util: %.1f%%)\n",
+			logger.Infof("📈 Multi-NIC scaled: +%d workers across %d interfaces (util: %.1f%%)\n",
 				newWorkersTotal, len(m.networkInterfaces), utilization*100)
 		}
 	}
@@ -96,11 +175,12 @@ func (m *Monitor) checkAndScaleMultiNIC() {
 // ForceScaleUp performs emergency scaling
 func (m *Monitor) ForceScaleUp() {
 	currentWorkers := m.downloadManager.GetActiveWorkers()
-	if currentWorkers < config.MaxDownloadWorkers {
-		newWorkersTotal := min(config.ScaleUpAmount*3, config.MaxDownloadWorkers-int(currentWorkers))
+	maxWorkers := m.ceiling.current(m.downloadManager)
+	if currentWorkers < int64(maxWorkers) {
+		newWorkersTotal := min(config.ScaleUpAmount*3, maxWorkers-int(currentWorkers))
 		if newWorkersTotal > 0 {
 			m.downloadManager.AddWorkers(newWorkersTotal)
-			fmt.Printf("🚀 EMERGENCY Multi-NIC scale: +%d workers (now %d)\n",
+			logger.Infof("🚀 EMERGENCY Multi-NIC scale: +%d workers (now %d)\n",
 				newWorkersTotal, currentWorkers+int64(newWorkersTotal))
 		}
 	}
@@ -130,12 +210,34 @@ func (m *Monitor) printPerformanceStats() {
 
 	if attempts > 0 {
 		successRate := float64(success) / float64(attempts) * 100
-		throughput := float64(success) / elapsed.Seconds()
-		mbps := float64(bytes) * 8 / elapsed.Seconds() / 1024 / 1024 // Mbps
+		throughput := perSecond(float64(success), elapsed)
+		mbps := perSecond(float64(bytes)*8/1024/1024, elapsed) // Mbps
 
-		fmt.Printf("🔥 MULTI-NIC: %d workers, %d queued | %d attempts, %d success, %d failed (%.1f%%) | %.1f dl/s, %.1f Mbps | %s\n",
+		logger.Infof("🔥 MULTI-NIC: %d workers, %d queued | %d attempts, %d success, %d failed (%.1f%%) | %.1f dl/s, %.1f Mbps | %s\n",
 			workers, totalQueued, attempts, success, failed, successRate, throughput, mbps, utils.FormatBytes(bytes))
 	}
+
+	if s, ok := m.crawlStats(); ok {
+		now := time.Now()
+		recent := s.Rate()
+		if !m.lastCrawl.at.IsZero() {
+			recent = perSecond(float64(s.Fetched-m.lastCrawl.fetched), now.Sub(m.lastCrawl.at))
+		}
+		m.lastCrawl = crawlTick{fetched: s.Fetched, at: now}
+
+		if s.Fetched > 0 || s.Active > 0 {
+			logger.Infof("🕷️ CRAWL: %d active/%s, %d queued | %d pages, %d failed | %.1f pages/s (%.1f avg)\n",
+				s.Active, parallelismLimit(s.Parallelism), s.Queued, s.Fetched, s.Failed, recent, s.Rate())
+		}
+	}
+}
+
+// parallelismLimit formats a crawl parallelism, 0 meaning not known
+func parallelismLimit(n int) string {
+	if n <= 0 {
+		return "?"
+	}
+	return fmt.Sprint(n)
 }
 
 // memoryMonitor monitors memory usage and triggers GC when needed
@@ -155,17 +257,65 @@ func (m *Monitor) memoryMonitor() {
 			allocGB := float64(memStats.Alloc) / 1024 / 1024 / 1024
 			sysGB := float64(memStats.Sys) / 1024 / 1024 / 1024
 
-			fmt.Printf("🧠 Memory: %.1fGB allocated, %.1fGB system (target: %dGB), GC: %d\n",
+			logger.Infof("🧠 Memory: %.1fGB allocated, %.1fGB system (target: %dGB), GC: %d\n",
 				allocGB, sysGB, config.TargetMemoryUsageGB, memStats.NumGC)
 
 			if allocGB > float64(config.TargetMemoryUsageGB)*0.95 {
-				fmt.Printf("🧹 Triggering GC (approaching %dGB limit)\n", config.TargetMemoryUsageGB)
+				logger.Infof("🧹 Triggering GC (approaching %dGB limit)\n", config.TargetMemoryUsageGB)
 				runtime.GC()
 			}
+
+			m.reportURLMaps()
+			m.reportGoroutines()
 		}
 	}
 }
 
+// reportURLMaps logs the estimated size of the URL maps and warns when
+// together they pass config.URLMapWarnGB
+func (m *Monitor) reportURLMaps() {
+	m.urlMapsMu.Lock()
+	sources := append([]URLMapSource(nil), m.urlMaps...)
+	m.urlMapsMu.Unlock()
+
+	var sizes []utils.MapSize
+	for _, src := range sources {
+		sizes = append(sizes, src.URLMapSizes()...)
+	}
+
+	var total int64
+	parts := make([]string, 0, len(sizes))
+	for _, s := range sizes {
+		total += s.Bytes
+		parts = append(parts, fmt.Sprintf("%s %d (%s)", s.Name, s.Entries, utils.FormatBytes(s.Bytes)))
+	}
+
+	logger.Infof("🗂️ URL maps: %s, ~%s total\n", strings.Join(parts, ", "), utils.FormatBytes(total))
+
+	if total > int64(config.URLMapWarnGB)*1024*1024*1024 {
+		logger.Warnf("⚠️ URL maps hold ~%s, above the %dGB warning threshold; consider a lower -max-pages or depth\n",
+			utils.FormatBytes(total), config.URLMapWarnGB)
+	}
+}
+
+// reportGoroutines logs the goroutine count and warns when it is far above
+// what the active download workers account for, which usually means a leak
+func (m *Monitor) reportGoroutines() {
+	count := runtime.NumGoroutine()
+	if count > m.peakGoroutines {
+		m.peakGoroutines = count
+	}
+	workers := int(m.downloadManager.GetActiveWorkers())
+
+	logger.Infof("🧵 Goroutines: %d (peak %d, %d active workers)\n", count, m.peakGoroutines, workers)
+
+	limit := workers*config.GoroutinesPerWorker + config.GoroutineWarnMargin
+	if count > limit {
+		logger.Warnf("⚠️ %d goroutines for %d active workers (expected at most ~%d); possible goroutine leak\n",
+			count, workers, limit)
+	}
+}
+
 // networkMonitor displays network interface statistics
 func (m *Monitor) networkMonitor() {
 	defer m.wg.Done()
@@ -184,53 +334,219 @@ func (m *Monitor) networkMonitor() {
 
 // printNetworkStats prints network interface statistics
 func (m *Monitor) printNetworkStats() {
-	fmt.Printf("🌐 Network Status:\n")
+	logger.Infof("🌐 Network Status:\n")
 	downloadQueues := m.downloadManager.GetDownloadQueues()
 	for i, iface := range m.networkInterfaces {
 		queueLen := len(downloadQueues[i])
 		queueCap := cap(downloadQueues[i])
-		utilization := float64(queueLen) / float64(queueCap) * 100
-		fmt.Printf("   %s (%s): Queue %d/%d (%.1f%%), %d clients\n",
-			iface.Name, iface.Speed, queueLen, queueCap, utilization, len(iface.Clients))
+		utilization := 0.0
+		if queueCap > 0 {
+			utilization = float64(queueLen) / float64(queueCap) * 100
+		}
+		logger.Infof("   %s (%s)%s: Queue %d/%d (%.1f%%), %d clients\n",
+			iface.Name, iface.Speed, iface.BindingNote(), queueLen, queueCap, utilization, len(iface.Clients))
 	}
 }
 
 // PrintStartupInfo displays startup information
-func PrintStartupInfo(startURL, targetDir string, networkInterfaces []network.NetworkInterface) {
-	fmt.Printf("\n🔥🔥🔥 MULTI-NIC BEAST UNLEASHED! 🔥🔥🔥\n")
-	fmt.Printf("🎯 Target: %s (max depth %d)\n", startURL, config.MaxDepth)
-	fmt.Printf("📁 Output: %s\n", targetDir)
-	fmt.Printf("👥 Workers: %d initial → %d max\n", config.InitialDownloadWorkers, config.MaxDownloadWorkers)
-	fmt.Printf("🌐 Interfaces: %d active\n", len(networkInterfaces))
+func PrintStartupInfo(startURL, targetDir string, maxDepth int, profile config.Profile, networkInterfaces []network.NetworkInterface) {
+	logger.Infof("\n🔥🔥🔥 MULTI-NIC BEAST UNLEASHED! 🔥🔥🔥\n")
+	logger.Infof("🎯 Target: %s (max depth %d)\n", startURL, maxDepth)
+	logger.Infof("📁 Output: %s\n", targetDir)
+	logger.Infof("🎛️ Profile: %s\n", profile.Name)
+	logger.Infof("👥 Workers: %d initial → %d max\n", profile.InitialWorkers, profile.MaxWorkers)
+	logger.Infof("🌐 Interfaces: %d active\n", len(networkInterfaces))
 	for _, iface := range networkInterfaces {
-		fmt.Printf("   • %s (%s) - %s - %d workers\n",
-			iface.Name, iface.IP, iface.Speed, iface.WorkerCount)
+		logger.Infof("   • %s (%s)%s - %s - %d workers\n",
+			iface.Name, iface.IP, iface.BindingNote(), iface.Speed, iface.WorkerCount)
 	}
-	fmt.Printf("⚡ Crawl delay: %v (INSANE MODE)\n", config.PoliteDelay)
-	fmt.Printf("💾 Buffer size: %dMB per download\n", config.DownloadBufferSize/1024/1024)
-	fmt.Printf("📦 Total queue capacity: %d items\n\n", config.MaxQueueSize)
+	logger.Infof("⚡ Crawl delay: %v, %d parallel requests\n", profile.CrawlDelay, profile.CrawlParallelism)
+	logger.Infof("💾 Buffer size: %dMB per download\n", config.DownloadBufferSize/1024/1024)
+	logger.Infof("📦 Total queue capacity: %d items\n\n", config.MaxQueueSize)
 }
 
 // PrintFinalStats displays final statistics
 func PrintFinalStats(downloadManager *downloader.Manager, networkInterfaces []network.NetworkInterface) {
 	attempts, success, failed, bytes, elapsed := downloadManager.GetStats()
 
-	fmt.Printf("\n🔥🔥🔥 MULTI-NIC BEAST MODE COMPLETE! 🔥🔥🔥\n")
-	fmt.Printf("⏱️ Total time: %v\n", elapsed)
-	fmt.Printf("📊 Downloads: %d attempts, %d success, %d failed\n", attempts, success, failed)
-	fmt.Printf("💾 Data downloaded: %s\n", utils.FormatBytes(bytes))
-	fmt.Printf("⚡ Average throughput: %.2f downloads/sec\n", float64(success)/elapsed.Seconds())
-	fmt.Printf("🌐 Average bandwidth: %.2f Mbps\n", float64(bytes)*8/elapsed.Seconds()/1024/1024)
-	fmt.Printf("💪 Peak workers: %d across %d interfaces\n", downloadManager.GetActiveWorkers(), len(networkInterfaces))
-	fmt.Printf("🧠 Final memory: %s\n", utils.FormatMemory(utils.GetMemStats()))
+	logger.Summaryf("\n🔥🔥🔥 MULTI-NIC BEAST MODE COMPLETE! 🔥🔥🔥\n")
+	logger.Summaryf("⏱️ Total time: %v\n", elapsed)
+	logger.Summaryf("📊 Downloads: %d attempts, %d success, %d failed\n", attempts, success, failed)
+	if skipped := downloadManager.GetSkippedCount(); skipped > 0 {
+		logger.Summaryf("⏭️ Skipped (file already exists): %d\n", skipped)
+	}
+	if unchanged := downloadManager.GetNotModifiedCount(); unchanged > 0 {
+		logger.Summaryf("♻️ Not modified since the saved copy: %d\n", unchanged)
+	}
+	if trips, _ := downloadManager.GetCircuitBreakerStats(); trips > 0 {
+		logger.Summaryf("🔌 Host circuit breakers opened: %d times\n", trips)
+	}
+	if drops := downloadManager.GetPostProcessDrops(); drops > 0 {
+		logger.Summaryf("🧺 Skipped post-processing (queue full): %d\n", drops)
+	}
+	if filtered := downloadManager.GetSizeFilteredCount(); filtered > 0 {
+		logger.Summaryf("📐 Skipped (outside size range): %d\n", filtered)
+	}
+	if verified, bad := downloadManager.GetVerifyStats(); verified+bad > 0 {
+		logger.Summaryf("🔏 Verified: %d files passed, %d failed (removed and retried)\n", verified, bad)
+	}
+	logger.Summaryf("💾 Data downloaded: %s\n", utils.FormatBytes(bytes))
+	logger.Summaryf("⚡ Average throughput: %.2f downloads/sec\n", perSecond(float64(success), elapsed))
+	logger.Summaryf("🌐 Average bandwidth: %.2f Mbps\n", perSecond(float64(bytes)*8/1024/1024, elapsed))
+	logger.Summaryf("💪 Peak workers: %d across %d interfaces\n", downloadManager.GetActiveWorkers(), len(networkInterfaces))
+	logger.Summaryf("🧠 Final memory: %s\n", utils.FormatMemory(utils.GetMemStats()))
 
-	fmt.Printf("\n🌐 Per-Interface Stats:\n")
+	logger.Summaryf("\n🌐 Per-Interface Stats:\n")
 	for _, iface := range networkInterfaces {
-		fmt.Printf("   %s (%s): %s - %d workers configured\n",
-			iface.Name, iface.IP, iface.Speed, iface.WorkerCount)
+		logger.Summaryf("   %s (%s)%s: %s - %d workers configured\n",
+			iface.Name, iface.IP, iface.BindingNote(), iface.Speed, iface.WorkerCount)
+	}
+
+	printTimingStats(downloadManager.GetTimingStats())
+	printStatusDistribution(downloadManager.GetStatusDistribution())
+	printContentTypes(downloadManager.GetContentTypeDistribution())
+	printDomainStats(downloadManager.GetDomainStats())
+	printHostRates(downloadManager.GetHostRequestRates())
+}
+
+// printTimingStats prints TTFB and transfer-time percentiles
+func printTimingStats(timing downloader.TimingStats) {
+	if timing.Samples == 0 {
+		return
+	}
+
+	logger.Summaryf("\n⏱️ Download Timing (last %d downloads):\n", timing.Samples)
+	for _, row := range []struct {
+		label string
+		p     downloader.Percentiles
+	}{{"TTFB", timing.TTFB}, {"Transfer", timing.Transfer}} {
+		logger.Summaryf("   %-8s p50 %v | p90 %v | p99 %v | max %v\n", row.label,
+			row.p.P50.Round(time.Millisecond), row.p.P90.Round(time.Millisecond),
+			row.p.P99.Round(time.Millisecond), row.p.Max.Round(time.Millisecond))
 	}
 }
 
+// printStatusDistribution prints the HTTP status code histogram
+func printStatusDistribution(distribution map[int]int64) {
+	if len(distribution) == 0 {
+		return
+	}
+
+	codes := make([]int, 0, len(distribution))
+	var serverErrors int64
+	for code, n := range distribution {
+		codes = append(codes, code)
+		if code >= 500 {
+			serverErrors += n
+		}
+	}
+	sort.Ints(codes)
+
+	logger.Summaryf("\n📶 HTTP Status Distribution:\n")
+	for _, code := range codes {
+		label := fmt.Sprintf("%d %s", code, http.StatusText(code))
+		if code == downloader.StatusNetworkError {
+			label = "network-error"
+		}
+		logger.Summaryf("   %s: %d\n", label, distribution[code])
+	}
+	if serverErrors > 0 {
+		logger.Summaryf("   5xx total: %d\n", serverErrors)
+	}
+}
+
+// printContentTypes prints the media types seen, most common first
+func printContentTypes(distribution map[string]int64) {
+	if len(distribution) == 0 {
+		return
+	}
+
+	types := make([]string, 0, len(distribution))
+	for mediaType := range distribution {
+		types = append(types, mediaType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if distribution[types[i]] != distribution[types[j]] {
+			return distribution[types[i]] > distribution[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	logger.Summaryf("\n🗂️ Content Types:\n")
+	for _, mediaType := range types {
+		logger.Summaryf("   %s: %d\n", mediaType, distribution[mediaType])
+	}
+}
+
+// printDomainStats prints the per-host breakdown, busiest hosts first
+func printDomainStats(domainStats map[string]downloader.DomainStat) {
+	if len(domainStats) == 0 {
+		return
+	}
+
+	hosts := make([]string, 0, len(domainStats))
+	for host := range domainStats {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		a, b := domainStats[hosts[i]], domainStats[hosts[j]]
+		if a.PagesCrawled != b.PagesCrawled {
+			return a.PagesCrawled > b.PagesCrawled
+		}
+		if a.BytesDownloaded != b.BytesDownloaded {
+			return a.BytesDownloaded > b.BytesDownloaded
+		}
+		return hosts[i] < hosts[j]
+	})
+
+	logger.Summaryf("\n🏠 Per-Domain Stats (%d hosts):\n", len(hosts))
+	for i, host := range hosts {
+		if i == maxDomainStatsRows {
+			logger.Summaryf("   ... %d more hosts\n", len(hosts)-maxDomainStatsRows)
+			break
+		}
+		s := domainStats[host]
+		logger.Summaryf("   %s: %d pages, %d docs, %s, %d errors\n",
+			host, s.PagesCrawled, s.DocumentsFound, utils.FormatBytes(s.BytesDownloaded), s.Errors)
+	}
+}
+
+// printHostRates prints the achieved download rate per host against its
+// target, listing every host that went over it
+func printHostRates(rates []downloader.HostRate) {
+	if len(rates) == 0 {
+		return
+	}
+
+	var exceeded []downloader.HostRate
+	for _, r := range rates {
+		if r.Exceeded {
+			exceeded = append(exceeded, r)
+		}
+	}
+
+	logger.Summaryf("\n🐢 Download Request Rate per Host:\n")
+	for i, r := range rates {
+		if i == maxDomainStatsRows {
+			logger.Summaryf("   ... %d more hosts\n", len(rates)-maxDomainStatsRows)
+			break
+		}
+		logger.Summaryf("   %s: %d requests, avg %.2f/s, peak %.0f/s, target %s\n",
+			r.Host, r.Requests, r.AvgRate, r.PeakRate, formatTarget(r.Target))
+	}
+	for _, r := range exceeded {
+		logger.Summaryf("   ⚠️ %s exceeded its target: peak %.0f/s vs %s\n", r.Host, r.PeakRate, formatTarget(r.Target))
+	}
+}
+
+// formatTarget renders a per-host rate target, 0 being unlimited
+func formatTarget(rps float64) string {
+	if rps == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%.2f/s", rps)
+}
+
 // SetupBeastMode configures system for maximum performance
 func SetupBeastMode() {
 	runtime.GOMAXPROCS(runtime.NumCPU() * 4) // Even more OS threads for networking
@@ -243,6 +559,15 @@ func SetupBeastMode() {
 	}
 }
 
+// perSecond returns value/elapsed in seconds, or 0 when no time has elapsed
+func perSecond(value float64, elapsed time.Duration) float64 {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return value / seconds
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+)
+
+// statsSnapshot is the JSON body served at /stats
+type statsSnapshot struct {
+	Since           time.Time       `json:"since"`
+	ElapsedSeconds  float64         `json:"elapsed_seconds"`
+	Attempts        int64           `json:"attempts"`
+	Success         int64           `json:"success"`
+	Failed          int64           `json:"failed"`
+	Skipped         int64           `json:"skipped"`
+	BytesDownloaded int64           `json:"bytes_downloaded"`
+	ActiveWorkers   int64           `json:"active_workers"`
+	Queued          int             `json:"queued"`
+	QueueCapacity   int             `json:"queue_capacity"`
+	StatusCodes     map[int]int64   `json:"status_codes"`
+	Interfaces      []interfaceStat `json:"interfaces"`
+	Crawl           *crawlStat      `json:"crawl,omitempty"`
+}
+
+// crawlStat is the page-fetching side, since the crawl started (stats
+// resets only cover downloads)
+type crawlStat struct {
+	Fetched     int64   `json:"pages_fetched"`
+	Failed      int64   `json:"pages_failed"`
+	Active      int64   `json:"active_requests"`
+	Queued      int64   `json:"queued"`
+	Parallelism int     `json:"parallelism"`
+	PagesPerSec float64 `json:"pages_per_second"`
+}
+
+type interfaceStat struct {
+	Name     string `json:"name"`
+	IP       string `json:"ip"`
+	Active   bool   `json:"active"`
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+}
+
+// StartHTTPServer serves monitoring endpoints on addr until shutdown:
+//
+//	/stats        current crawl statistics as JSON
+//	/stats/reset  POST: start a new statistics window, returning the one it closed
+//	/healthz      200 while the process is serving
+//	/readyz       200 once download workers are running on an active interface, 503 before
+func (m *Monitor) StartHTTPServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", m.handleStats)
+	mux.HandleFunc("/stats/reset", m.handleStatsReset)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", m.handleReady)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	panics.Go("monitoring server", "", func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("❌ Monitoring server stopped: %v\n", err)
+		}
+	})
+	panics.Go("monitoring server", "", func() {
+		<-m.shutdownChan
+		server.Close()
+	})
+
+	logger.Infof("📡 Monitoring endpoints on http://%s (/stats, /stats/reset, /healthz, /readyz)\n", listener.Addr())
+	return nil
+}
+
+func (m *Monitor) handleStats(w http.ResponseWriter, r *http.Request) {
+	m.writeStats(w, m.downloadManager.Snapshot())
+}
+
+// handleStatsReset closes the current statistics window, so /stats then
+// covers only what happens from now on
+func (m *Monitor) handleStatsReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	closed := m.downloadManager.ResetStats()
+	logger.Infof("📊 Statistics reset after %v\n", closed.Elapsed.Round(time.Second))
+	m.writeStats(w, closed)
+}
+
+// writeStats serves stats, plus the live queue and interface state, as JSON
+func (m *Monitor) writeStats(w http.ResponseWriter, stats downloader.Stats) {
+	queued, capacity := m.downloadManager.GetQueueStatus()
+
+	snapshot := statsSnapshot{
+		Since:           stats.Since,
+		ElapsedSeconds:  stats.Elapsed.Seconds(),
+		Attempts:        stats.Attempts,
+		Success:         stats.Success,
+		Failed:          stats.Failed,
+		Skipped:         stats.Skipped,
+		BytesDownloaded: stats.Bytes,
+		ActiveWorkers:   m.downloadManager.GetActiveWorkers(),
+		Queued:          queued,
+		QueueCapacity:   capacity,
+		StatusCodes:     stats.StatusCodes,
+	}
+
+	queues := m.downloadManager.GetDownloadQueues()
+	for i, iface := range m.networkInterfaces {
+		stat := interfaceStat{Name: iface.Name, IP: iface.IP, Active: iface.IsActive}
+		if i < len(queues) {
+			stat.Queued, stat.Capacity = len(queues[i]), cap(queues[i])
+		}
+		snapshot.Interfaces = append(snapshot.Interfaces, stat)
+	}
+
+	if s, ok := m.crawlStats(); ok {
+		snapshot.Crawl = &crawlStat{
+			Fetched:     s.Fetched,
+			Failed:      s.Failed,
+			Active:      s.Active,
+			Queued:      s.Queued,
+			Parallelism: s.Parallelism,
+			PagesPerSec: s.Rate(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+func (m *Monitor) handleReady(w http.ResponseWriter, r *http.Request) {
+	if m.downloadManager.GetActiveWorkers() == 0 {
+		http.Error(w, "no download workers running", http.StatusServiceUnavailable)
+		return
+	}
+	for _, iface := range m.networkInterfaces {
+		if iface.IsActive {
+			w.Write([]byte("ready\n"))
+			return
+		}
+	}
+	http.Error(w, "no network interface up", http.StatusServiceUnavailable)
+}
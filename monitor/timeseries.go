@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// timeseriesInterval is how often a timeseries row is written
+const timeseriesInterval = time.Second
+
+// timeseriesHeader names the timeseries CSV columns
+var timeseriesHeader = []string{"timestamp", "active_workers", "queue_depth", "downloads_per_sec", "mbps", "heap_bytes"}
+
+// timeseries appends one row of download statistics per interval
+type timeseries struct {
+	file *os.File
+	w    *csv.Writer
+
+	// Counters at the previous row, so rates cover one interval
+	lastSuccess int64
+	lastBytes   int64
+	lastAt      time.Time
+}
+
+// SetTimeseriesFile records a CSV row per second at path: active workers,
+// queue depth, downloads/s and Mbps over that second, and heap in use. An
+// existing file is appended to. Call before StartMonitoring.
+func (m *Monitor) SetTimeseriesFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	ts := &timeseries{file: f, w: csv.NewWriter(f)}
+	if info.Size() == 0 {
+		ts.w.Write(timeseriesHeader)
+	}
+	_, ts.lastSuccess, _, ts.lastBytes, _ = m.downloadManager.GetStats()
+	ts.lastAt = time.Now()
+	m.timeseries = ts
+	return nil
+}
+
+// timeseriesMonitor writes timeseries rows until shutdown
+func (m *Monitor) timeseriesMonitor() {
+	defer m.wg.Done()
+	defer m.timeseries.close()
+	ticker := time.NewTicker(timeseriesInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.shutdownChan:
+			m.timeseries.record(m)
+			return
+		case <-ticker.C:
+			m.timeseries.record(m)
+		}
+	}
+}
+
+// record writes the current statistics as one row
+func (ts *timeseries) record(m *Monitor) {
+	_, success, _, bytes, _ := m.downloadManager.GetStats()
+	queued, _ := m.downloadManager.GetQueueStatus()
+	now := time.Now()
+	elapsed := now.Sub(ts.lastAt)
+
+	ts.w.Write([]string{
+		now.Format("2006-01-02T15:04:05.000Z07:00"),
+		strconv.FormatInt(m.downloadManager.GetActiveWorkers(), 10),
+		strconv.Itoa(queued),
+		fmt.Sprintf("%.2f", perSecond(float64(success-ts.lastSuccess), elapsed)),
+		fmt.Sprintf("%.2f", perSecond(float64(bytes-ts.lastBytes)*8/1024/1024, elapsed)),
+		strconv.FormatUint(utils.GetMemStats().HeapAlloc, 10),
+	})
+	ts.w.Flush()
+	if err := ts.w.Error(); err != nil {
+		logger.Errorf("❌ Timeseries: %v\n", err)
+	}
+
+	ts.lastSuccess, ts.lastBytes, ts.lastAt = success, bytes, now
+}
+
+func (ts *timeseries) close() {
+	ts.w.Flush()
+	ts.file.Close()
+}
@@ -0,0 +1,125 @@
+package network
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BearerAuth sends "Authorization: Bearer <token>" to a set of hosts,
+// fetching a fresh token whenever the cached one is older than TTL or a
+// server answers 401
+type BearerAuth struct {
+	Token func() (string, error) // Fetches a new token
+	TTL   time.Duration          // How long a fetched token is reused
+	Hosts []string               // Hosts, and their subdomains, that receive the token
+}
+
+// bearerState caches the current token; the lock is held while fetching so
+// concurrent requests wait for one refresh instead of each starting their own
+type bearerState struct {
+	mu      sync.Mutex
+	auth    *BearerAuth
+	token   string
+	expires time.Time
+}
+
+var bearer bearerState
+
+// SetBearerAuth enables bearer-token authentication for requests made
+// through Authorize-wrapped transports. nil disables it. Call before any
+// requests are made.
+func SetBearerAuth(auth *BearerAuth) {
+	bearer.mu.Lock()
+	defer bearer.mu.Unlock()
+
+	bearer.auth = auth
+	bearer.token = ""
+	bearer.expires = time.Time{}
+}
+
+// authorization returns the header value for host, or "" if host gets none
+func (b *bearerState) authorization(host string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.auth == nil || !hostMatches(host, b.auth.Hosts) {
+		return "", nil
+	}
+	if b.token == "" || time.Now().After(b.expires) {
+		token, err := b.auth.Token()
+		if err != nil {
+			return "", err
+		}
+		b.token = strings.TrimSpace(token)
+		b.expires = time.Now().Add(b.auth.TTL)
+	}
+	return "Bearer " + b.token, nil
+}
+
+// invalidate drops the cached token if it is still the one that was rejected
+func (b *bearerState) invalidate(rejected string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if "Bearer "+b.token == rejected {
+		b.token = ""
+	}
+}
+
+// hostMatches reports whether host is one of hosts or a subdomain of one
+func hostMatches(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize wraps rt so requests to SetBearerAuth hosts carry the current
+// token. A 401 on a request without a body is retried once with a new token.
+func Authorize(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return authTransport{next: rt}
+}
+
+type authTransport struct {
+	next http.RoundTripper
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	value, err := bearer.authorization(req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	resp, err := t.next.RoundTrip(withAuthorization(req, value))
+	replayable := req.Body == nil || req.Body == http.NoBody
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !replayable {
+		return resp, err
+	}
+
+	// The token may have been revoked or expired early
+	bearer.invalidate(value)
+	if value, err = bearer.authorization(req.URL.Hostname()); err != nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.next.RoundTrip(withAuthorization(req, value))
+}
+
+// withAuthorization returns a copy of req with the Authorization header set
+func withAuthorization(req *http.Request, value string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", value)
+	return req
+}
@@ -0,0 +1,54 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/logger"
+)
+
+// strictBinding makes InitializeMultiNICSystem fail when an interface's
+// address can't be bound, instead of falling back to the default route
+var strictBinding atomic.Bool
+
+// SetStrictBinding chooses what happens when an interface can't be bound to
+// its IP: true fails InitializeMultiNICSystem, false (the default) falls
+// back to the default route with a warning and marks the interface Unbound
+func SetStrictBinding(strict bool) {
+	strictBinding.Store(strict)
+}
+
+// checkBinding verifies that iface has an address this host can bind to
+func checkBinding(iface NetworkInterface) error {
+	if iface.IP == "" {
+		return fmt.Errorf("%s has no IPv4 address", iface.Name)
+	}
+	addr, err := net.ResolveIPAddr("ip", iface.IP)
+	if err != nil {
+		return fmt.Errorf("resolving %s for %s: %w", iface.IP, iface.Name, err)
+	}
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(addr.IP.String(), "0"))
+	if err != nil {
+		return fmt.Errorf("binding %s for %s: %w", iface.IP, iface.Name, err)
+	}
+	return conn.Close()
+}
+
+// bindOrFallback checks iface's binding, marking it Unbound when that fails
+// and the fallback is allowed
+func bindOrFallback(iface *NetworkInterface) error {
+	err := checkBinding(*iface)
+	if err == nil {
+		return nil
+	}
+	if strictBinding.Load() {
+		return fmt.Errorf("interface binding failed: %w", err)
+	}
+
+	iface.Unbound = true
+	logger.Warnf("\n⚠️⚠️⚠️ NOT BOUND: %v\n", err)
+	logger.Warnf("⚠️ Requests assigned to %s will leave through the default route, not this NIC\n", iface.Name)
+	logger.Warnf("⚠️ Use -strict-bind to stop instead\n\n")
+	return nil
+}
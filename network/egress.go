@@ -0,0 +1,104 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+)
+
+// EgressResult is what a reflector saw when one interface connected to it
+type EgressResult struct {
+	Interface  string
+	LocalIP    string // The IP the interface's clients bind to
+	ObservedIP string // The source IP the reflector reported
+	Err        error
+}
+
+// CheckEgress asks reflectorURL, through each interface's own client, which
+// source IP the request arrived from. The reflector must answer with the
+// caller's IP as plain text, like https://api.ipify.org does.
+func CheckEgress(ctx context.Context, interfaces []NetworkInterface, reflectorURL string) []EgressResult {
+	results := make([]EgressResult, 0, len(interfaces))
+	for _, iface := range interfaces {
+		result := EgressResult{Interface: iface.Name, LocalIP: iface.IP}
+		if len(iface.Clients) == 0 {
+			result.Err = fmt.Errorf("no HTTP clients")
+		} else {
+			result.ObservedIP, result.Err = observeSourceIP(ctx, iface.Clients[0], reflectorURL)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// observeSourceIP makes one request to the reflector and parses its answer
+func observeSourceIP(ctx context.Context, client *http.Client, reflectorURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.EgressCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reflectorURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("reflector answered %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return "", fmt.Errorf("reflector answer %q is not an IP address", strings.TrimSpace(string(body)))
+	}
+	return ip.String(), nil
+}
+
+// ReportEgress logs each interface's observed source IP and warns when
+// binding isn't taking effect: interfaces that share a source IP are all
+// leaving through the same route. It returns false if anything was wrong.
+func ReportEgress(results []EgressResult) bool {
+	byObserved := make(map[string][]string)
+	for _, r := range results {
+		if r.Err == nil {
+			byObserved[r.ObservedIP] = append(byObserved[r.ObservedIP], r.Interface)
+		}
+	}
+
+	ok := true
+	logger.Infof("🛰️ Egress check:\n")
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			ok = false
+			logger.Warnf("⚠️ %s (%s): egress check failed: %v\n", r.Interface, r.LocalIP, r.Err)
+		case len(byObserved[r.ObservedIP]) > 1:
+			ok = false
+			var others []string
+			for _, name := range byObserved[r.ObservedIP] {
+				if name != r.Interface {
+					others = append(others, name)
+				}
+			}
+			logger.Warnf("⚠️ %s (%s) egresses as %s, same as %s; binding is not effective, check source-based routing\n",
+				r.Interface, r.LocalIP, r.ObservedIP, strings.Join(others, ", "))
+		case r.ObservedIP == r.LocalIP:
+			logger.Infof("   ✅ %s (%s) egresses as itself\n", r.Interface, r.LocalIP)
+		default:
+			logger.Infof("   ✅ %s (%s) egresses as %s (NAT)\n", r.Interface, r.LocalIP, r.ObservedIP)
+		}
+	}
+	return ok
+}
@@ -0,0 +1,107 @@
+package network
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// inflight bounds the number of HTTP requests in progress at once, across
+// page fetches, downloads and probes. A request holds its slot until the
+// response body is closed, since that's when its connection frees up.
+type inflight struct {
+	slots chan struct{}
+}
+
+var (
+	inflightMu    sync.RWMutex
+	inflightLimit *inflight // nil means unlimited
+)
+
+// SetMaxInflight caps simultaneous outbound HTTP requests at n, regardless
+// of worker counts and connection limits. Zero or negative removes the cap.
+// Call before any requests are made.
+func SetMaxInflight(n int) {
+	inflightMu.Lock()
+	defer inflightMu.Unlock()
+
+	if n <= 0 {
+		inflightLimit = nil
+		return
+	}
+	inflightLimit = &inflight{slots: make(chan struct{}, n)}
+}
+
+// InflightRequests returns how many requests hold a slot and the cap,
+// or 0, 0 when there is no cap
+func InflightRequests() (inUse, limit int) {
+	l := currentInflight()
+	if l == nil {
+		return 0, 0
+	}
+	return len(l.slots), cap(l.slots)
+}
+
+// AcquireInflight waits for a request slot. Call release once the response
+// body has been read and closed.
+func AcquireInflight(ctx context.Context) (release func(), err error) {
+	l := currentInflight()
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return sync.OnceFunc(func() { <-l.slots }), nil
+	case <-ctx.Done():
+		return func() {}, ctx.Err()
+	}
+}
+
+// LimitInflight wraps rt so each request waits for a slot and gives it back
+// when its response body is closed. Requests are also authorized, see
+// Authorize.
+func LimitInflight(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return inflightTransport{next: Authorize(rt)}
+}
+
+type inflightTransport struct {
+	next http.RoundTripper
+}
+
+func (t inflightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := AcquireInflight(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody frees its request slot when closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+func currentInflight() *inflight {
+	inflightMu.RLock()
+	defer inflightMu.RUnlock()
+	return inflightLimit
+}
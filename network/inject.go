@@ -0,0 +1,30 @@
+package network
+
+import "net/http"
+
+// NewInterfaceWithClients builds an active interface that uses the given
+// clients instead of interface-bound ones. InitializeMultiNICSystem leaves
+// its clients alone, so it can stand in for real hardware, e.g. pointed at
+// an httptest.Server.
+func NewInterfaceWithClients(name string, clients []*http.Client) NetworkInterface {
+	return NetworkInterface{
+		Name:        name,
+		IsActive:    true,
+		Speed:       "unknown",
+		WorkerCount: len(clients),
+		Clients:     clients,
+	}
+}
+
+// NewInterfaceWithTransport builds an interface with clientCount clients
+// sharing transport, such as a mock or recording RoundTripper
+func NewInterfaceWithTransport(name string, transport http.RoundTripper, clientCount int) NetworkInterface {
+	if clientCount < 1 {
+		clientCount = 1
+	}
+	clients := make([]*http.Client, clientCount)
+	for i := range clients {
+		clients[i] = &http.Client{Transport: Authorize(transport), Timeout: CurrentTimeouts().Request}
+	}
+	return NewInterfaceWithClients(name, clients)
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/utils"
 )
 
 // NetworkInterface represents a network interface with its configuration
@@ -20,11 +22,13 @@ type NetworkInterface struct {
 	Speed       string
 	WorkerCount int
 	Clients     []*http.Client
+	Unbound     bool     // Binding to IP failed; requests use the default route
+	IPs         []string // Addresses on this NIC to rotate connections over; see SetSourceIPs
 }
 
 // DetectNetworkInterfaces discovers available network interfaces
 func DetectNetworkInterfaces() ([]NetworkInterface, error) {
-	fmt.Println("\n🔍 Detecting network interfaces...")
+	logger.Infof("\n🔍 Detecting network interfaces...\n")
 
 	interfaces, err := net.Interfaces()
 	if err != nil {
@@ -46,13 +50,17 @@ func DetectNetworkInterfaces() ([]NetworkInterface, error) {
 			continue
 		}
 
-		// Get first valid IP
+		// Use the first valid IP; further ones are listed as aliases
 		var ip string
+		var aliases []string
 		for _, addr := range addrs {
 			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
 				if ipnet.IP.To4() != nil {
-					ip = ipnet.IP.String()
-					break
+					if ip == "" {
+						ip = ipnet.IP.String()
+					} else {
+						aliases = append(aliases, ipnet.IP.String())
+					}
 				}
 			}
 		}
@@ -78,7 +86,10 @@ func DetectNetworkInterfaces() ([]NetworkInterface, error) {
 			status = "UP"
 		}
 
-		fmt.Printf("🌐 Found: %s (%s) - %s - %s\n", iface.Name, ip, status, speed)
+		logger.Infof("🌐 Found: %s (%s) - %s - %s\n", iface.Name, ip, status, speed)
+		if len(aliases) > 0 {
+			logger.Infof("   aliases: %s\n", strings.Join(aliases, ", "))
+		}
 	}
 
 	return networkInterfaces, nil
@@ -112,8 +123,8 @@ func getInterfaceSpeed(ifname string) string {
 
 // SelectNetworkInterfaces lets user choose which interfaces to use
 func SelectNetworkInterfaces(networkInterfaces []NetworkInterface) []int {
-	fmt.Println("\n🎯 Select network interfaces for crawling:")
-	fmt.Println("Available interfaces:")
+	logger.Promptf("\n🎯 Select network interfaces for crawling:\n")
+	logger.Promptf("Available interfaces:\n")
 
 	activeCount := 0
 	for i, iface := range networkInterfaces {
@@ -122,21 +133,20 @@ func SelectNetworkInterfaces(networkInterfaces []NetworkInterface) []int {
 			status = "✅"
 			activeCount++
 		}
-		fmt.Printf("%d) %s %s (%s) - %s - %s\n",
+		logger.Promptf("%d) %s %s (%s) - %s - %s\n",
 			i+1, status, iface.Name, iface.IP, iface.Speed,
 			map[bool]string{true: "ACTIVE", false: "INACTIVE"}[iface.IsActive])
 	}
 
 	if activeCount == 0 {
-		fmt.Println("❌ No active interfaces found!")
+		logger.Promptf("❌ No active interfaces found!\n")
 		return nil
 	}
 
-	fmt.Printf("\nRecommendation: Use all active high-speed interfaces for maximum performance\n")
-	fmt.Printf("Enter interface numbers (comma-separated, e.g., 1,2,3) or 'all' for all active: ")
+	logger.Promptf("\nRecommendation: Use all active high-speed interfaces for maximum performance\n")
+	logger.Promptf("Enter interface numbers (comma-separated, e.g., 1,2,3) or 'all' for all active: ")
 
-	var input string
-	fmt.Scanln(&input)
+	input := strings.TrimSpace(utils.ReadLine())
 
 	if input == "all" {
 		var selected []int
@@ -158,7 +168,7 @@ func SelectNetworkInterfaces(networkInterfaces []NetworkInterface) []int {
 				if networkInterfaces[idx].IsActive {
 					selected = append(selected, idx)
 				} else {
-					fmt.Printf("⚠️ Interface %s is not active, skipping\n", networkInterfaces[idx].Name)
+					logger.Warnf("⚠️ Interface %s is not active, skipping\n", networkInterfaces[idx].Name)
 				}
 			}
 		}
@@ -169,7 +179,7 @@ func SelectNetworkInterfaces(networkInterfaces []NetworkInterface) []int {
 
 // ConfigureSelectedInterfaces sets up the selected network interfaces
 func ConfigureSelectedInterfaces(networkInterfaces []NetworkInterface, selected []int) ([]NetworkInterface, error) {
-	fmt.Println("\n⚙️ Configuring selected interfaces...")
+	logger.Infof("\n⚙️ Configuring selected interfaces...\n")
 
 	var activeInterfaces []NetworkInterface
 	totalBandwidth := 0
@@ -193,59 +203,71 @@ func ConfigureSelectedInterfaces(networkInterfaces []NetworkInterface, selected
 		iface.WorkerCount = workers
 		activeInterfaces = append(activeInterfaces, iface)
 
-		fmt.Printf("✅ %s (%s) - %s - %d workers\n",
+		logger.Infof("✅ %s (%s) - %s - %d workers\n",
 			iface.Name, iface.IP, iface.Speed, workers)
 	}
 
-	fmt.Printf("🚀 Total bandwidth: %d Mbps across %d interfaces\n",
+	logger.Infof("🚀 Total bandwidth: %d Mbps across %d interfaces\n",
 		totalBandwidth, len(activeInterfaces))
 
 	return activeInterfaces, nil
 }
 
-// CreateInterfaceClient creates an HTTP client bound to a specific interface
+// CreateInterfaceClient creates an HTTP client bound to a specific interface.
+// Unbound interfaces get a client that uses the default route.
 func CreateInterfaceClient(iface NetworkInterface, numInterfaces int) *http.Client {
-	// Create custom dialer that binds to specific interface
-	localAddr, err := net.ResolveIPAddr("ip", iface.IP)
-	if err != nil {
-		fmt.Printf("⚠️ Warning: Could not resolve IP %s for %s\n", iface.IP, iface.Name)
-		localAddr = nil
-	}
-
+	timeouts := CurrentTimeouts()
 	dialer := &net.Dialer{
-		Timeout:   config.ConnectionTimeout,
+		Timeout:   timeouts.Connect,
 		KeepAlive: config.KeepAliveTimeout,
 	}
 
-	// Bind to local interface IP if possible
-	if localAddr != nil {
-		dialer.LocalAddr = &net.TCPAddr{IP: localAddr.IP}
+	// Bind to local interface IP, or rotate over its source IPs
+	dial := dialer.DialContext
+	if ips := iface.SourceIPs(); len(ips) > 1 && !iface.Unbound {
+		dial = newRotatingDialer(dialer, ips).DialContext
+	} else if ip := net.ParseIP(iface.IP); ip != nil && !iface.Unbound {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
 	}
 
 	transport := &http.Transport{
-		DialContext:           dialer.DialContext,
+		DialContext:           dial,
 		MaxIdleConns:          config.MaxConnectionsTotal / numInterfaces / 64,
 		MaxIdleConnsPerHost:   config.MaxConnectionsPerHost / numInterfaces / 64,
 		MaxConnsPerHost:       config.MaxConnectionsPerHost / numInterfaces / 64,
 		IdleConnTimeout:       config.KeepAliveTimeout,
-		TLSHandshakeTimeout:   5 * time.Second,
-		ResponseHeaderTimeout: 15 * time.Second,
+		TLSHandshakeTimeout:   timeouts.TLSHandshake,
+		ResponseHeaderTimeout: timeouts.ResponseHeader,
 		ExpectContinueTimeout: 1 * time.Second,
 		DisableCompression:    false,
 		ForceAttemptHTTP2:     true,
 	}
 
 	return &http.Client{
-		Timeout:   config.RequestTimeout,
-		Transport: transport,
+		Timeout:   timeouts.Request,
+		Transport: Authorize(transport),
 	}
 }
 
-// InitializeMultiNICSystem sets up queues and HTTP clients for each interface
-func InitializeMultiNICSystem(networkInterfaces []NetworkInterface) []NetworkInterface {
-	fmt.Println("\n🔧 Initializing multi-NIC system...")
+// InitializeMultiNICSystem sets up queues and HTTP clients for each interface.
+// Interfaces that already carry clients are left as they are. It fails if an
+// interface can't be bound to its IP and SetStrictBinding is on.
+func InitializeMultiNICSystem(networkInterfaces []NetworkInterface) ([]NetworkInterface, error) {
+	logger.Infof("\n🔧 Initializing multi-NIC system...\n")
 
 	for i := range networkInterfaces {
+		// Keep caller-supplied clients (see NewInterfaceWithClients)
+		if len(networkInterfaces[i].Clients) > 0 {
+			logger.Infof("🌐 Interface %s: %d injected HTTP clients\n",
+				networkInterfaces[i].Name, len(networkInterfaces[i].Clients))
+			continue
+		}
+
+		checkSourceIPs(&networkInterfaces[i])
+		if err := bindOrFallback(&networkInterfaces[i]); err != nil {
+			return nil, err
+		}
+
 		// Create HTTP clients for this interface
 		clientCount := 64 // 64 clients per interface
 		clients := make([]*http.Client, clientCount)
@@ -256,21 +278,36 @@ func InitializeMultiNICSystem(networkInterfaces []NetworkInterface) []NetworkInt
 
 		networkInterfaces[i].Clients = clients
 
-		fmt.Printf("🌐 Interface %s: %d HTTP clients\n",
-			networkInterfaces[i].Name, clientCount)
+		logger.Infof("🌐 Interface %s: %d HTTP clients%s\n",
+			networkInterfaces[i].Name, clientCount, networkInterfaces[i].BindingNote())
+		if ips := networkInterfaces[i].SourceIPs(); len(ips) > 1 && !networkInterfaces[i].Unbound {
+			logger.Infof("🔁 %s rotates connections over %s\n", networkInterfaces[i].Name, strings.Join(ips, ", "))
+		}
 	}
 
-	return networkInterfaces
+	return networkInterfaces, nil
+}
+
+// BindingNote returns " (UNBOUND)" for an interface that fell back to the
+// default route, for appending to its stats line, and "" otherwise
+func (iface NetworkInterface) BindingNote() string {
+	if iface.Unbound {
+		return " (UNBOUND)"
+	}
+	return ""
 }
 
 // PrintNetworkStats displays network interface statistics
 func PrintNetworkStats(networkInterfaces []NetworkInterface, downloadQueues []chan interface{}) {
-	fmt.Printf("🌐 Network Status:\n")
+	logger.Infof("🌐 Network Status:\n")
 	for i, iface := range networkInterfaces {
 		queueLen := len(downloadQueues[i])
 		queueCap := cap(downloadQueues[i])
-		utilization := float64(queueLen) / float64(queueCap) * 100
-		fmt.Printf("   %s (%s): Queue %d/%d (%.1f%%), %d clients\n",
+		utilization := 0.0
+		if queueCap > 0 {
+			utilization = float64(queueLen) / float64(queueCap) * 100
+		}
+		logger.Infof("   %s (%s): Queue %d/%d (%.1f%%), %d clients\n",
 			iface.Name, iface.Speed, queueLen, queueCap, utilization, len(iface.Clients))
 	}
 }
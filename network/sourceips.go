@@ -0,0 +1,94 @@
+package network
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	"github.com/jeb/url_crawler/logger"
+)
+
+// nextSourceIP picks the source address for each new connection. It is
+// shared by every client, so an interface's addresses are used evenly
+// however its 64 clients take turns.
+var nextSourceIP atomic.Uint64
+
+// SetSourceIPs gives the interface called name a list of addresses on the
+// same NIC, e.g. aliases of one card. Its connections rotate their source
+// address over the list, so per-IP limits on the far side apply to each
+// address rather than the card. The first address becomes the interface's
+// IP. It reports false if no interface has that name. Call before
+// InitializeMultiNICSystem.
+func SetSourceIPs(interfaces []NetworkInterface, name string, ips []string) bool {
+	for i := range interfaces {
+		if interfaces[i].Name != name {
+			continue
+		}
+		interfaces[i].IPs = ips
+		if len(ips) > 0 {
+			interfaces[i].IP = ips[0]
+		}
+		return true
+	}
+	return false
+}
+
+// SourceIPs returns the addresses iface's connections are made from: its IPs
+// when it has more than one, otherwise just its IP
+func (iface NetworkInterface) SourceIPs() []string {
+	if len(iface.IPs) > 1 {
+		return iface.IPs
+	}
+	if iface.IP == "" {
+		return nil
+	}
+	return []string{iface.IP}
+}
+
+// checkSourceIPs drops the addresses in iface.IPs this host can't bind to,
+// with a warning, and makes IP the first one left
+func checkSourceIPs(iface *NetworkInterface) {
+	if len(iface.IPs) == 0 {
+		return
+	}
+	var usable []string
+	for _, ip := range iface.IPs {
+		probe := *iface
+		probe.IP = ip
+		if err := checkBinding(probe); err != nil {
+			logger.Warnf("⚠️ Not rotating through %s: %v\n", ip, err)
+			continue
+		}
+		usable = append(usable, ip)
+	}
+	iface.IPs = usable
+	if len(usable) > 0 {
+		iface.IP = usable[0]
+	}
+}
+
+// rotatingDialer dials each connection from the next of several local
+// addresses
+type rotatingDialer struct {
+	dialers []*net.Dialer
+}
+
+// newRotatingDialer copies base once per address in ips, bound to it
+func newRotatingDialer(base *net.Dialer, ips []string) *rotatingDialer {
+	d := &rotatingDialer{}
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			continue
+		}
+		bound := *base
+		bound.LocalAddr = &net.TCPAddr{IP: ip}
+		d.dialers = append(d.dialers, &bound)
+	}
+	return d
+}
+
+func (d *rotatingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	i := (nextSourceIP.Add(1) - 1) % uint64(len(d.dialers))
+	return d.dialers[i].DialContext(ctx, network, addr)
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+)
+
+// Timeouts bounds each phase of an HTTP request made by the interface clients.
+//
+// Short timeouts free workers quickly from dead or overloaded hosts, which is
+// what keeps throughput high on a fast crawl. The cost is that slow servers
+// (many government and academic sites take well over 15s to first byte) are
+// dropped as failures. Longer timeouts rescue those, but every stuck request
+// holds a worker and a connection for longer, so more workers are needed for
+// the same throughput.
+type Timeouts struct {
+	Connect        time.Duration // TCP connection establishment
+	TLSHandshake   time.Duration // TLS negotiation after connecting
+	ResponseHeader time.Duration // Waiting for response headers after sending the request
+	Request        time.Duration // Whole request, including reading the body
+}
+
+// DefaultTimeouts returns the timeouts from config, tuned for fast targets
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Connect:        config.ConnectionTimeout,
+		TLSHandshake:   config.TLSHandshakeTimeout,
+		ResponseHeader: config.ResponseHeaderTimeout,
+		Request:        config.RequestTimeout,
+	}
+}
+
+// timeoutProfiles are the named presets accepted by TimeoutProfile
+var timeoutProfiles = map[string]Timeouts{
+	"default": DefaultTimeouts(),
+	"slow": {
+		Connect:        15 * time.Second,
+		TLSHandshake:   20 * time.Second,
+		ResponseHeader: 90 * time.Second,
+		Request:        5 * time.Minute,
+	},
+}
+
+// TimeoutProfile returns the preset called name ("default" or "slow")
+func TimeoutProfile(name string) (Timeouts, error) {
+	t, ok := timeoutProfiles[name]
+	if !ok {
+		return Timeouts{}, fmt.Errorf("unknown timeout profile %q (use default or slow)", name)
+	}
+	return t, nil
+}
+
+var (
+	timeoutsMu sync.RWMutex
+	timeouts   = DefaultTimeouts()
+)
+
+// SetTimeouts sets the timeouts used by clients created afterwards.
+// Zero fields keep their default value.
+func SetTimeouts(t Timeouts) {
+	def := DefaultTimeouts()
+	if t.Connect <= 0 {
+		t.Connect = def.Connect
+	}
+	if t.TLSHandshake <= 0 {
+		t.TLSHandshake = def.TLSHandshake
+	}
+	if t.ResponseHeader <= 0 {
+		t.ResponseHeader = def.ResponseHeader
+	}
+	if t.Request <= 0 {
+		t.Request = def.Request
+	}
+
+	timeoutsMu.Lock()
+	timeouts = t
+	timeoutsMu.Unlock()
+}
+
+// CurrentTimeouts returns the timeouts new clients are created with
+func CurrentTimeouts() Timeouts {
+	timeoutsMu.RLock()
+	defer timeoutsMu.RUnlock()
+	return timeouts
+}
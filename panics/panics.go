@@ -0,0 +1,123 @@
+// Package panics keeps one bad page or document from taking down a crawl.
+// Goroutines started through Go, or with Recover deferred, recover their
+// panics; each is recorded with its stack and the URL being handled, for
+// the end-of-run report.
+package panics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/jeb/url_crawler/logger"
+)
+
+// maxReports caps the panics kept in full; later ones are only counted
+const maxReports = 1000
+
+// maxLoggedStacks is how many panics have their stack logged as they happen
+const maxLoggedStacks = 3
+
+// Report describes one recovered panic
+type Report struct {
+	Time  time.Time `json:"time"`
+	Where string    `json:"where"` // Goroutine or callback that panicked
+	URL   string    `json:"url,omitempty"`
+	Value string    `json:"value"`
+	Stack string    `json:"stack"`
+}
+
+var (
+	mu      sync.Mutex
+	reports []Report
+	total   int
+)
+
+// Record saves a value recovered in where while handling url (may be
+// empty) and logs it. Call it from the deferred function that recovered.
+func Record(where, url string, value any) {
+	r := Report{
+		Time:  time.Now(),
+		Where: where,
+		URL:   url,
+		Value: fmt.Sprint(value),
+		Stack: string(debug.Stack()),
+	}
+
+	mu.Lock()
+	total++
+	n := total
+	if len(reports) < maxReports {
+		reports = append(reports, r)
+	}
+	mu.Unlock()
+
+	if url != "" {
+		logger.Errorf("🛑 PANIC #%d in %s (%s): %v\n", n, where, url, value)
+	} else {
+		logger.Errorf("🛑 PANIC #%d in %s: %v\n", n, where, value)
+	}
+	if n <= maxLoggedStacks {
+		logger.Errorf("   Stack:\n%s\n", r.Stack)
+	}
+}
+
+// Recover recovers a panic in the calling goroutine and records it. It
+// must be deferred directly: defer panics.Recover("where", url).
+func Recover(where, url string) {
+	if value := recover(); value != nil {
+		Record(where, url, value)
+	}
+}
+
+// Go runs fn in a new goroutine that records a panic instead of crashing
+func Go(where, url string, fn func()) {
+	go func() {
+		defer Recover(where, url)
+		fn()
+	}()
+}
+
+// Count returns how many panics have been recovered
+func Count() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return total
+}
+
+// Reports returns the recovered panics, oldest first, up to the first 1000
+func Reports() []Report {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Report(nil), reports...)
+}
+
+// Since returns the recorded panics that happened at or after t
+func Since(t time.Time) []Report {
+	mu.Lock()
+	defer mu.Unlock()
+	var since []Report
+	for _, r := range reports {
+		if !r.Time.Before(t) {
+			since = append(since, r)
+		}
+	}
+	return since
+}
+
+// WriteReport writes the recovered panics to path as JSON
+func WriteReport(path string) error {
+	mu.Lock()
+	data, err := json.MarshalIndent(struct {
+		Total  int      `json:"total"`
+		Panics []Report `json:"panics"`
+	}{total, reports}, "", "  ")
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
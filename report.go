@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeb/url_crawler/crawler"
+	"github.com/jeb/url_crawler/downloader"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// runConfig is the configuration a run actually used, after the profile,
+// flags and interactive prompts have all been applied
+type runConfig struct {
+	StartURL   string   `json:"start_url"`
+	OutputDirs []string `json:"output_dirs"`
+	DirPolicy  string   `json:"dir_policy"`
+	Interfaces []string `json:"interfaces"`
+
+	Profile          string   `json:"profile"`
+	Mode             string   `json:"mode"`
+	DocTypes         string   `json:"doc_types"`
+	MaxDepth         int      `json:"max_depth"`
+	CrawlParallelism int      `json:"crawl_parallelism"`
+	CrawlDelay       string   `json:"crawl_delay"`
+	CrawlRate        float64  `json:"crawl_rate"`
+	Scope            string   `json:"scope"`
+	SchemeRewrite    string   `json:"scheme_rewrite"`
+	DefaultExcludes  bool     `json:"default_excludes"`
+	Excludes         []string `json:"excludes,omitempty"`
+	MaxURLLength     int      `json:"max_url_length"`
+	MaxLinksPerPage  int      `json:"max_links_per_page"`
+	PaginationBoost  int      `json:"pagination_priority"`
+	HostPageBudget   int      `json:"host_page_budget"`
+	StreamPages      int64    `json:"stream_pages"`
+	DedupSlash       bool     `json:"dedup_slash"`
+	DedupIndex       bool     `json:"dedup_index"`
+	DedupStrategy    string   `json:"dedup_strategy"`
+	Schemes          string   `json:"schemes"`
+	FastPathDocs     bool     `json:"fast_path_documents"`
+	SizeRules        string   `json:"size_rules,omitempty"`
+	PDFLinks         bool     `json:"pdf_links"`
+	Robots           bool     `json:"robots"`
+
+	InitialWorkers  int                 `json:"initial_workers"`
+	MaxWorkers      int                 `json:"max_workers"`
+	PerHostRate     float64             `json:"per_host_rate"`
+	HostRates       map[string]string   `json:"host_rates,omitempty"`
+	DownloadRate    float64             `json:"download_rate"`
+	InterfaceRates  map[string]float64  `json:"interface_rates"`
+	SourceIPs       map[string][]string `json:"source_ips,omitempty"`
+	MaxActiveHosts  int                 `json:"max_active_hosts"`
+	MaxInflight     int                 `json:"max_inflight"`
+	PriorityEvery   int                 `json:"priority_every"`
+	RetryBase       string              `json:"retry_base"`
+	RetryCap        string              `json:"retry_cap"`
+	BreakerWindow   int                 `json:"breaker_window"`
+	BreakerRate     float64             `json:"breaker_failure_rate"`
+	BreakerCooldown string              `json:"breaker_cooldown"`
+	Overwrite       string              `json:"overwrite"`
+	Filenames       string              `json:"filenames"`
+	Verify          bool                `json:"verify"`
+	CaptureHeaders  bool                `json:"capture_headers"`
+	Conditional     bool                `json:"conditional"`
+	SuccessCodes    string              `json:"success_codes"`
+	MinSize         int64               `json:"min_size"`
+	MaxSize         int64               `json:"max_size"`
+	TokenHosts      []string            `json:"token_hosts,omitempty"`
+
+	ConnectTimeout string `json:"connect_timeout"`
+	TLSTimeout     string `json:"tls_timeout"`
+	HeaderTimeout  string `json:"header_timeout"`
+	RequestTimeout string `json:"request_timeout"`
+}
+
+// print logs the configuration at INFO level, one setting per line
+func (c runConfig) print() {
+	rateOrUnlimited := func(rate float64) any {
+		if rate <= 0 {
+			return "unlimited"
+		}
+		return rate
+	}
+	excludes := "none"
+	if len(c.Excludes) > 0 {
+		excludes = strings.Join(c.Excludes, ", ")
+	}
+
+	logger.Infof("⚙️ Effective configuration:\n")
+	logger.Infof("   start URL:          %s\n", c.StartURL)
+	logger.Infof("   output dirs:        %s (%s)\n", strings.Join(c.OutputDirs, ", "), c.DirPolicy)
+	logger.Infof("   interfaces:         %s\n", strings.Join(c.Interfaces, ", "))
+	logger.Infof("   profile:            %s\n", c.Profile)
+	logger.Infof("   mode:               %s (documents: %s)\n", c.Mode, c.DocTypes)
+	logger.Infof("   max depth:          %d\n", c.MaxDepth)
+	logger.Infof("   page requests:      %d parallel, %s delay\n", c.CrawlParallelism, c.CrawlDelay)
+	logger.Infof("   crawl rate:         %v/s\n", rateOrUnlimited(c.CrawlRate))
+	logger.Infof("   scope:              %s\n", c.Scope)
+	logger.Infof("   scheme rewrite:     %s\n", c.SchemeRewrite)
+	logger.Infof("   default excludes:   %t\n", c.DefaultExcludes)
+	logger.Infof("   excludes:           %s\n", excludes)
+	logger.Infof("   max URL length:     %d\n", c.MaxURLLength)
+	logger.Infof("   max links/page:     %d\n", c.MaxLinksPerPage)
+	logger.Infof("   pagination boost:   %d\n", c.PaginationBoost)
+	logger.Infof("   pages per host:     %d\n", c.HostPageBudget)
+	logger.Infof("   streamed pages:     %s\n", sizeRange(c.StreamPages, 0))
+	logger.Infof("   dedup:              %s slash=%t index=%t\n", c.DedupStrategy, c.DedupSlash, c.DedupIndex)
+	logger.Infof("   link schemes:       %s\n", c.Schemes)
+	logger.Infof("   fast-path docs:     %t\n", c.FastPathDocs)
+	if c.SizeRules != "" {
+		logger.Infof("   size rules:         %s\n", c.SizeRules)
+	}
+	logger.Infof("   PDF links:          %t\n", c.PDFLinks)
+	logger.Infof("   robots:             %t\n", c.Robots)
+	logger.Infof("   download workers:   %d → %d\n", c.InitialWorkers, c.MaxWorkers)
+	logger.Infof("   per-host rate:      %v/s\n", rateOrUnlimited(c.PerHostRate))
+	for _, host := range sortedKeys(c.HostRates) {
+		logger.Infof("     %s: %s/s\n", host, c.HostRates[host])
+	}
+	logger.Infof("   download rate:      %v/s\n", rateOrUnlimited(c.DownloadRate))
+	logger.Infof("   per-NIC rate:\n")
+	for _, name := range sortedKeys(c.InterfaceRates) {
+		logger.Infof("     %s: %v/s\n", name, rateOrUnlimited(c.InterfaceRates[name]))
+	}
+	if len(c.SourceIPs) > 0 {
+		logger.Infof("   source IPs:\n")
+		for _, name := range sortedKeys(c.SourceIPs) {
+			logger.Infof("     %s: %s\n", name, strings.Join(c.SourceIPs[name], ", "))
+		}
+	}
+	logger.Infof("   max active hosts:   %d\n", c.MaxActiveHosts)
+	logger.Infof("   max in-flight:      %d\n", c.MaxInflight)
+	if c.PriorityEvery > 0 {
+		logger.Infof("   priority queue:     first on 1 in %d passes\n", c.PriorityEvery)
+	} else {
+		logger.Infof("   priority queue:     only when idle\n")
+	}
+	logger.Infof("   retry backoff:      %s base, %s cap\n", c.RetryBase, c.RetryCap)
+	if c.BreakerWindow > 0 {
+		logger.Infof("   circuit breaker:    %.0f%% of %d failed, %s cooldown\n", c.BreakerRate*100, c.BreakerWindow, c.BreakerCooldown)
+	} else {
+		logger.Infof("   circuit breaker:    off\n")
+	}
+	logger.Infof("   overwrite:          %s\n", c.Overwrite)
+	logger.Infof("   filenames:          %s\n", c.Filenames)
+	logger.Infof("   verify downloads:   %t\n", c.Verify)
+	logger.Infof("   capture headers:    %t\n", c.CaptureHeaders)
+	logger.Infof("   conditional GET:    %t\n", c.Conditional)
+	logger.Infof("   size filter:        %s\n", sizeRange(c.MinSize, c.MaxSize))
+	logger.Infof("   success codes:      %s\n", c.SuccessCodes)
+	if len(c.TokenHosts) > 0 {
+		logger.Infof("   bearer token for:   %s\n", strings.Join(c.TokenHosts, ", "))
+	}
+	logger.Infof("   timeouts:           connect %s, TLS %s, headers %s, request %s\n",
+		c.ConnectTimeout, c.TLSTimeout, c.HeaderTimeout, c.RequestTimeout)
+}
+
+// finalReport is the JSON written by -report when the crawl finishes
+type finalReport struct {
+	Config       runConfig                        `json:"config"`
+	StartedAt    time.Time                        `json:"started_at"`
+	Elapsed      string                           `json:"elapsed"`
+	Attempts     int64                            `json:"download_attempts"`
+	Downloaded   int64                            `json:"downloaded"`
+	Failed       int64                            `json:"failed"`
+	Skipped      int64                            `json:"skipped"`
+	SizeFiltered int64                            `json:"size_filtered"`
+	Bytes        int64                            `json:"bytes_downloaded"`
+	StatusCodes  map[int]int64                    `json:"status_codes"`
+	ContentTypes map[string]int64                 `json:"content_types"`
+	Domains      map[string]downloader.DomainStat `json:"domains"`
+	DepthCounts  []int64                          `json:"depth_counts"`
+	Timing       downloader.TimingStats           `json:"timing"`
+	HostRates    []downloader.HostRate            `json:"host_rates"`
+	Panics       []panics.Report                  `json:"panics,omitempty"`
+}
+
+// writeReport saves the final report for a finished crawl as JSON at path
+func writeReport(path string, cfg runConfig, startedAt time.Time, dm *downloader.Manager, wc *crawler.CrawlerTwoTier) error {
+	attempts, success, failed, bytes, _ := dm.GetStats()
+	report := finalReport{
+		Config:       cfg,
+		StartedAt:    startedAt,
+		Elapsed:      time.Since(startedAt).Round(time.Millisecond).String(),
+		Attempts:     attempts,
+		Downloaded:   success,
+		Failed:       failed,
+		Skipped:      dm.GetSkippedCount(),
+		SizeFiltered: dm.GetSizeFilteredCount(),
+		Bytes:        bytes,
+		StatusCodes:  dm.GetStatusDistribution(),
+		ContentTypes: dm.GetContentTypeDistribution(),
+		Domains:      dm.GetDomainStats(),
+		DepthCounts:  wc.GetDepthDistribution(),
+		Timing:       dm.GetTimingStats(),
+		HostRates:    dm.GetHostRequestRates(),
+		Panics:       panics.Reports(),
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// sizeRange describes a SetSizeFilter range for the startup summary
+func sizeRange(minBytes, maxBytes int64) string {
+	switch {
+	case minBytes == 0 && maxBytes == 0:
+		return "none"
+	case maxBytes == 0:
+		return "at least " + utils.FormatBytes(minBytes)
+	case minBytes == 0:
+		return "at most " + utils.FormatBytes(maxBytes)
+	}
+	return utils.FormatBytes(minBytes) + " – " + utils.FormatBytes(maxBytes)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,5 @@
+//go:build sqlite
+
+package statsdb
+
+import _ "modernc.org/sqlite"
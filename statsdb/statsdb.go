@@ -0,0 +1,214 @@
+// Package statsdb logs crawled pages and downloads into a SQLite database
+// for ad-hoc analysis after a crawl.
+//
+// The SQLite driver (modernc.org/sqlite, no cgo) is only linked in when
+// building with the "sqlite" tag:
+//
+//	go get modernc.org/sqlite
+//	go build -tags sqlite
+//
+// Without it, Open reports that SQLite support is unavailable.
+package statsdb
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
+	"github.com/jeb/url_crawler/panics"
+)
+
+// driverName is the database/sql name registered by modernc.org/sqlite
+const driverName = "sqlite"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS pages (
+	url           TEXT NOT NULL,
+	depth         INTEGER,
+	status        INTEGER,
+	size          INTEGER,
+	path_decision TEXT,
+	links         INTEGER,
+	docs          INTEGER,
+	timestamp     DATETIME
+);
+CREATE TABLE IF NOT EXISTS downloads (
+	url       TEXT NOT NULL,
+	path      TEXT,
+	bytes     INTEGER,
+	status    INTEGER,
+	interface TEXT,
+	outcome   TEXT,
+	timestamp DATETIME
+);`
+
+// PageRecord is one crawled page
+type PageRecord struct {
+	URL          string
+	Depth        int
+	Status       int
+	Size         int
+	PathDecision string
+	Links        int
+	Docs         int
+	Timestamp    time.Time
+}
+
+// DownloadRecord is one finished download, successful or not
+type DownloadRecord struct {
+	URL       string
+	Path      string
+	Bytes     int64
+	Status    int
+	Interface string
+	Outcome   string // "ok" or "failed"
+	Timestamp time.Time
+}
+
+// Recorder batches records into the database from a single goroutine,
+// so crawl and download workers never contend on SQLite locks
+type Recorder struct {
+	db        *sql.DB
+	pages     chan PageRecord
+	downloads chan DownloadRecord
+	done      chan struct{}
+}
+
+// Open creates (or appends to) the database at path
+func Open(path string) (*Recorder, error) {
+	if !slices.Contains(sql.Drivers(), driverName) {
+		return nil, errors.New("statsdb: built without SQLite support (go get modernc.org/sqlite, then build with -tags sqlite)")
+	}
+
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	r := &Recorder{
+		db:        db,
+		pages:     make(chan PageRecord, config.StatsDBQueueSize),
+		downloads: make(chan DownloadRecord, config.StatsDBQueueSize),
+		done:      make(chan struct{}),
+	}
+	panics.Go("stats db writer", "", r.writer)
+	return r, nil
+}
+
+// RecordPage queues a page record
+func (r *Recorder) RecordPage(p PageRecord) {
+	if p.Timestamp.IsZero() {
+		p.Timestamp = time.Now()
+	}
+	r.pages <- p
+}
+
+// RecordDownload queues a download record
+func (r *Recorder) RecordDownload(d DownloadRecord) {
+	if d.Timestamp.IsZero() {
+		d.Timestamp = time.Now()
+	}
+	r.downloads <- d
+}
+
+// Close flushes pending records and closes the database.
+// Record* must not be called after Close.
+func (r *Recorder) Close() error {
+	close(r.pages)
+	close(r.downloads)
+	<-r.done
+	return r.db.Close()
+}
+
+// writer collects records and flushes them in batches
+func (r *Recorder) writer() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(config.StatsDBFlushInterval)
+	defer ticker.Stop()
+
+	pageCh, downloadCh := r.pages, r.downloads
+	var pages []PageRecord
+	var downloads []DownloadRecord
+
+	for pageCh != nil || downloadCh != nil {
+		flushNow := false
+
+		select {
+		case p, ok := <-pageCh:
+			if !ok {
+				pageCh = nil
+				continue
+			}
+			pages = append(pages, p)
+		case d, ok := <-downloadCh:
+			if !ok {
+				downloadCh = nil
+				continue
+			}
+			downloads = append(downloads, d)
+		case <-ticker.C:
+			flushNow = true
+		}
+
+		if flushNow || len(pages)+len(downloads) >= config.StatsDBBatchSize {
+			r.flush(pages, downloads)
+			pages, downloads = pages[:0], downloads[:0]
+		}
+	}
+
+	r.flush(pages, downloads)
+}
+
+// flush writes one batch in a single transaction
+func (r *Recorder) flush(pages []PageRecord, downloads []DownloadRecord) {
+	if len(pages) == 0 && len(downloads) == 0 {
+		return
+	}
+
+	if err := r.insert(pages, downloads); err != nil {
+		logger.Errorf("❌ Stats DB write failed (%d pages, %d downloads lost): %v\n",
+			len(pages), len(downloads), err)
+	}
+}
+
+func (r *Recorder) insert(pages []PageRecord, downloads []DownloadRecord) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	pageStmt, err := tx.Prepare(`INSERT INTO pages (url, depth, status, size, path_decision, links, docs, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer pageStmt.Close()
+	for _, p := range pages {
+		if _, err := pageStmt.Exec(p.URL, p.Depth, p.Status, p.Size, p.PathDecision, p.Links, p.Docs, p.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	downloadStmt, err := tx.Prepare(`INSERT INTO downloads (url, path, bytes, status, interface, outcome, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer downloadStmt.Close()
+	for _, d := range downloads {
+		if _, err := downloadStmt.Exec(d.URL, d.Path, d.Bytes, d.Status, d.Interface, d.Outcome, d.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,10 +1,10 @@
 package system
 
 import (
-	"fmt"
 	"syscall"
 
 	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/logger"
 )
 
 // IncreaseFileDescriptorLimit increases system file descriptor limits
@@ -12,24 +12,24 @@ func IncreaseFileDescriptorLimit() {
 	var rLimit syscall.Rlimit
 	err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rLimit)
 	if err != nil {
-		fmt.Printf("⚠️ Could not get file descriptor limit: %v\n", err)
+		logger.Warnf("⚠️ Could not get file descriptor limit: %v\n", err)
 		return
 	}
 
-	fmt.Printf("📁 Current FD limit: %d\n", rLimit.Cur)
+	logger.Infof("📁 Current FD limit: %d\n", rLimit.Cur)
 
 	rLimit.Cur = rLimit.Max
 	err = syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rLimit)
 	if err != nil {
-		fmt.Printf("⚠️ Could not increase FD limit: %v\n", err)
+		logger.Warnf("⚠️ Could not increase FD limit: %v\n", err)
 	} else {
-		fmt.Printf("📁 Increased FD limit to: %d\n", rLimit.Cur)
+		logger.Infof("📁 Increased FD limit to: %d\n", rLimit.Cur)
 	}
 }
 
 // OptimizeNetworkSettings displays recommended network optimizations
 func OptimizeNetworkSettings() {
-	fmt.Println("🔧 Optimizing network settings...")
+	logger.Infof("🔧 Optimizing network settings...\n")
 
 	// These would require root privileges, so we'll just report what should be done
 	optimizations := []string{
@@ -42,16 +42,16 @@ func OptimizeNetworkSettings() {
 		"net.ipv4.tcp_congestion_control = bbr",
 	}
 
-	fmt.Println("💡 For optimal performance, run as root:")
+	logger.Infof("💡 For optimal performance, run as root:\n")
 	for _, opt := range optimizations {
-		fmt.Printf("   sysctl -w %s\n", opt)
+		logger.Infof("   sysctl -w %s\n", opt)
 	}
 }
 
 // PrintSystemInfo displays system configuration information
 func PrintSystemInfo(numCPU int) {
-	fmt.Printf("🔥🔥🔥 MULTI-NIC BEAST MODE ACTIVATED! 🔥🔥🔥\n")
-	fmt.Printf("🖥️ System: AMD Ryzen 9 5950X (%d cores) with 128GB RAM\n", numCPU)
-	fmt.Printf("⚡ GOMAXPROCS: %d\n", numCPU*4)
-	fmt.Printf("💾 Memory target: %dGB\n", config.TargetMemoryUsageGB)
+	logger.Infof("🔥🔥🔥 MULTI-NIC BEAST MODE ACTIVATED! 🔥🔥🔥\n")
+	logger.Infof("🖥️ System: AMD Ryzen 9 5950X (%d cores) with 128GB RAM\n", numCPU)
+	logger.Infof("⚡ GOMAXPROCS: %d\n", numCPU*4)
+	logger.Infof("💾 Memory target: %dGB\n", config.TargetMemoryUsageGB)
 }
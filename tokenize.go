@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jeb/url_crawler/config"
+	"github.com/jeb/url_crawler/tokenizer"
+	"github.com/jeb/url_crawler/utils"
+)
+
+// runTokenize implements "tokenize <url>": fetch one page, route it through
+// the coordinator and print what each stage found, without crawling
+func runTokenize(args []string) int {
+	fs := flag.NewFlagSet("tokenize", flag.ExitOnError)
+	docs := fs.String("docs", ".pdf", "comma-separated document extensions")
+	force := fs.String("path", "", "force the fast, slow or sitemap path instead of letting the coordinator decide")
+	fastDocs := fs.Bool("fast-docs", false, "detect documents on the fast path too")
+	scanScripts := fs.Bool("scan-scripts", false, "also extract URLs from inline <script> JSON/JS")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s tokenize [flags] <url>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	pageURL, err := url.Parse(fs.Arg(0))
+	if err != nil || pageURL.Host == "" {
+		fmt.Fprintf(os.Stderr, "invalid URL %q\n", fs.Arg(0))
+		return 2
+	}
+
+	req, err := http.NewRequest(http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	req.Header.Set("User-Agent", config.UserAgent)
+
+	fetchStart := time.Now()
+	resp, err := (&http.Client{Timeout: config.RequestTimeout}).Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, config.MaxPageSize))
+	resp.Body.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fetchTime := time.Since(fetchStart)
+
+	// Links resolve against the final URL after redirects
+	pageURL = resp.Request.URL
+	contentType := resp.Header.Get("Content-Type")
+	extensions := strings.Split(*docs, ",")
+
+	coordinator := tokenizer.NewCoordinator()
+	coordinator.SetFastPathDocDetection(*fastDocs)
+	coordinator.SetScanInlineJSON(*scanScripts)
+	coordinator.EnableDecisionTrace(1, nil)
+
+	decision := coordinator.DecideWithContentType(pageURL, len(body), contentType)
+	reason := coordinator.DecisionTrace()[0].Reason
+	switch *force {
+	case "":
+	case "fast":
+		decision, reason = tokenizer.FastPath, "forced"
+	case "slow":
+		decision, reason = tokenizer.SlowPath, "forced"
+	case "sitemap":
+		decision, reason = tokenizer.SitemapPath, "forced"
+	default:
+		fmt.Fprintf(os.Stderr, "unknown path %q (use fast, slow or sitemap)\n", *force)
+		return 2
+	}
+
+	fmt.Printf("URL:          %s\n", pageURL)
+	fmt.Printf("Status:       %s\n", resp.Status)
+	fmt.Printf("Content-Type: %s\n", contentType)
+	fmt.Printf("Size:         %s (fetched in %v)\n", utils.FormatBytes(int64(len(body))), fetchTime.Round(time.Millisecond))
+	if len(body) >= config.MaxPageSize {
+		fmt.Printf("              truncated at the %s page limit\n", utils.FormatBytes(config.MaxPageSize))
+	}
+	fmt.Printf("Path:         %s (%s)\n", decision, reason)
+
+	var urls, documents []string
+	start := time.Now()
+	switch decision {
+	case tokenizer.FastPath:
+		result := coordinator.ProcessFastPath(body, pageURL, extensions)
+		urls, documents = result.URLs, result.Documents
+	case tokenizer.SlowPath:
+		result := coordinator.ProcessSlowPath(body, pageURL, extensions)
+		urls = result.URLs
+		for _, doc := range result.Documents {
+			documents = append(documents, fmt.Sprintf("%s  %q", doc.URL, strings.TrimSpace(doc.Title)))
+		}
+	case tokenizer.SitemapPath:
+		var children []string
+		urls, children = coordinator.ProcessSitemap(body, pageURL)
+		for _, child := range children {
+			fmt.Printf("Child sitemap: %s\n", child)
+		}
+		for _, u := range urls {
+			if utils.IsDocumentURL(u, extensions) {
+				documents = append(documents, u)
+			}
+		}
+	}
+	fmt.Printf("Tokenized in: %v\n", time.Since(start))
+
+	fmt.Printf("\nURLs (%d):\n", len(urls))
+	for _, u := range urls {
+		fmt.Printf("  %s\n", u)
+	}
+	fmt.Printf("\nDocuments (%d):\n", len(documents))
+	for _, d := range documents {
+		fmt.Printf("  %s\n", d)
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+package tokenizer
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	linkTag      = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	linkCanonRel = regexp.MustCompile(`(?is)\brel\s*=\s*["']?canonical["'\s/>]`)
+	linkHref     = regexp.MustCompile(`(?is)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// CanonicalLink returns the href of the page's <link rel="canonical">,
+// unresolved, or "" if it has none. Like RobotsMeta it scans the head
+// without building a DOM.
+func CanonicalLink(htmlBytes []byte) string {
+	head := htmlBytes
+	if end := bytes.Index(bytes.ToLower(head[:min(len(head), robotsMetaScanLimit)]), []byte("</head")); end >= 0 {
+		head = head[:end]
+	} else if len(head) > robotsMetaScanLimit {
+		head = head[:robotsMetaScanLimit]
+	}
+
+	for _, tag := range linkTag.FindAll(head, -1) {
+		if !linkCanonRel.Match(tag) {
+			continue
+		}
+		if m := linkHref.FindSubmatch(tag); m != nil {
+			href := bytes.Join(m[1:], nil) // Only one alternative matched
+			return strings.TrimSpace(html.UnescapeString(string(href)))
+		}
+	}
+	return ""
+}
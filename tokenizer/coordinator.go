@@ -1,8 +1,12 @@
 package tokenizer
 
 import (
+	"io"
+	"math"
+	"mime"
 	"net/url"
 	"strings"
+	"sync"
 	"sync/atomic"
 )
 
@@ -12,20 +16,56 @@ type PathDecision int
 const (
 	FastPath PathDecision = iota
 	SlowPath
+	SkipPath    // Not HTML: no links are extracted
+	SitemapPath // XML sitemap: parsed with ProcessSitemap
 )
 
+// String returns "fast", "slow", "skip" or "sitemap"
+func (d PathDecision) String() string {
+	switch d {
+	case FastPath:
+		return "fast"
+	case SkipPath:
+		return "skip"
+	case SitemapPath:
+		return "sitemap"
+	default:
+		return "slow"
+	}
+}
+
 // Coordinator routes pages between fast and slow tokenization paths
 type Coordinator struct {
 	fastPath *FastPathTokenizer
 	slowPath *SlowPathTokenizer
 
-	// Routing metrics
+	// Routing metrics. Updates hold statsMu shared, Snapshot and ResetStats
+	// hold it exclusively.
+	statsMu       sync.RWMutex
 	fastPathCount atomic.Uint64
 	slowPathCount atomic.Uint64
+	skippedCount  atomic.Uint64
+	sitemapCount  atomic.Uint64
+	sizes         sizeHistogram
 
 	// Heuristics thresholds
 	fastPathSizeLimit int // Bytes - pages under this go fast
 	slowPathSizeLimit int // Bytes - pages over this go slow
+
+	// Size bands replacing the thresholds above (nil: use the thresholds)
+	sizeRules []SizeRule
+
+	// Fast-path document detection (off by default)
+	fastPathDocs bool
+
+	// Scan inline <script> content for URLs (off by default)
+	scanInlineJSON bool
+
+	// Links taken per page across both paths (0: unlimited)
+	maxLinks int
+
+	// Optional routing trace (nil when disabled)
+	trace *decisionTrace
 }
 
 // NewCoordinator creates a new two-tier coordinator
@@ -40,15 +80,83 @@ func NewCoordinator() *Coordinator {
 
 // Decide determines which path to use based on URL and page characteristics
 func (c *Coordinator) Decide(pageURL *url.URL, bodySize int) PathDecision {
+	decision, reason := c.decide(pageURL, bodySize)
+	return c.record(pageURL, bodySize, decision, reason)
+}
+
+// DecideWithContentType is Decide with the response's Content-Type taken
+// into account. XHTML always takes the slow path and RSS and Atom feeds the
+// fast path. Plain XML, other XML types at a sitemap-looking URL and
+// gzipped .xml.gz responses go to the sitemap parser; other non-HTML
+// content is skipped so it never reaches the href scanner. An empty content
+// type falls back to Decide.
+func (c *Coordinator) DecideWithContentType(pageURL *url.URL, bodySize int, contentType string) PathDecision {
+	decision, reason := c.decide(pageURL, bodySize)
+
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		switch {
+		case err != nil:
+			decision, reason = SkipPath, "unparseable content type"
+		case mediaType == "text/html":
+			// URL and size heuristics decide
+		case mediaType == "application/xhtml+xml":
+			decision, reason = SlowPath, "XHTML content type"
+		case mediaType == "application/rss+xml" || mediaType == "application/atom+xml":
+			decision, reason = FastPath, "feed content type"
+		case mediaType == "application/xml" || mediaType == "text/xml":
+			decision, reason = SitemapPath, "XML content type"
+		case strings.HasSuffix(mediaType, "+xml") && strings.Contains(strings.ToLower(pageURL.Path), "sitemap"):
+			decision, reason = SitemapPath, "XML content type at a sitemap URL"
+		case strings.HasSuffix(strings.ToLower(pageURL.Path), ".xml.gz"):
+			decision, reason = SitemapPath, "gzipped XML sitemap"
+		default:
+			decision, reason = SkipPath, "non-HTML content type"
+		}
+	}
+
+	return c.record(pageURL, bodySize, decision, reason)
+}
+
+// record counts and traces a routing decision
+func (c *Coordinator) record(pageURL *url.URL, bodySize int, decision PathDecision, reason string) PathDecision {
+	c.statsMu.RLock()
+	switch decision {
+	case FastPath:
+		c.fastPathCount.Add(1)
+	case SlowPath:
+		c.slowPathCount.Add(1)
+	case SkipPath:
+		c.skippedCount.Add(1)
+	}
+	c.sizes.record(decision, bodySize)
+	c.statsMu.RUnlock()
+
+	if c.trace != nil {
+		c.trace.record(pageURL, bodySize, decision, reason)
+	}
+
+	return decision
+}
+
+// decide applies the routing heuristics and names the rule that matched
+func (c *Coordinator) decide(pageURL *url.URL, bodySize int) (PathDecision, string) {
 	urlStr := pageURL.String()
 	urlLower := strings.ToLower(urlStr)
+	sizeRules := c.sizeRules != nil
+
+	// Configured size bands take precedence over everything else
+	if sizeRules {
+		if decision, reason, ok := c.decideBySize(bodySize); ok {
+			return decision, reason
+		}
+	}
 
 	// FORCE SLOW PATH conditions (need full parsing)
 
 	// 1. Large pages likely have important content
-	if bodySize > c.slowPathSizeLimit {
-		c.slowPathCount.Add(1)
-		return SlowPath
+	if !sizeRules && bodySize > c.slowPathSizeLimit {
+		return SlowPath, "size above slow-path limit"
 	}
 
 	// 2. Document repository URLs
@@ -57,22 +165,19 @@ func (c *Coordinator) Decide(pageURL *url.URL, bodySize int) PathDecision {
 		strings.Contains(urlLower, "/publication") ||
 		strings.Contains(urlLower, "/research") ||
 		strings.Contains(urlLower, "/library") {
-		c.slowPathCount.Add(1)
-		return SlowPath
+		return SlowPath, "document repository URL"
 	}
 
 	// 3. Query parameters indicate dynamic content
 	if pageURL.RawQuery != "" {
-		c.slowPathCount.Add(1)
-		return SlowPath
+		return SlowPath, "query parameters"
 	}
 
 	// FORCE FAST PATH conditions (link-heavy navigation)
 
 	// 1. Small pages are usually navigation
-	if bodySize < c.fastPathSizeLimit {
-		c.fastPathCount.Add(1)
-		return FastPath
+	if !sizeRules && bodySize < c.fastPathSizeLimit {
+		return FastPath, "size below fast-path limit"
 	}
 
 	// 2. Known navigation patterns
@@ -82,20 +187,17 @@ func (c *Coordinator) Decide(pageURL *url.URL, bodySize int) PathDecision {
 		strings.Contains(urlLower, "/tag") ||
 		strings.Contains(urlLower, "/index") ||
 		strings.Contains(urlLower, "/list") {
-		c.fastPathCount.Add(1)
-		return FastPath
+		return FastPath, "navigation URL"
 	}
 
 	// 3. URL depth heuristic - shallow paths are often indexes
 	pathParts := strings.Split(pageURL.Path, "/")
 	if len(pathParts) <= 3 { // e.g., /section/ or /section/index
-		c.fastPathCount.Add(1)
-		return FastPath
+		return FastPath, "shallow path"
 	}
 
 	// DEFAULT: Medium-sized content pages go to slow path for accuracy
-	c.slowPathCount.Add(1)
-	return SlowPath
+	return SlowPath, "default"
 }
 
 // GetRoutingStats returns fast vs slow path usage
@@ -111,6 +213,16 @@ func (c *Coordinator) GetRoutingStats() (fastCount uint64, slowCount uint64, fas
 	return fastCount, slowCount, fastPercent
 }
 
+// GetSkippedCount returns how many responses were skipped as non-HTML
+func (c *Coordinator) GetSkippedCount() uint64 {
+	return c.skippedCount.Load()
+}
+
+// GetSitemapCount returns how many sitemaps were parsed
+func (c *Coordinator) GetSitemapCount() uint64 {
+	return c.sitemapCount.Load()
+}
+
 // GetFastPathStats returns fast-path tokenizer statistics
 func (c *Coordinator) GetFastPathStats() (pages uint64, avgLatencyUs uint64, totalLinks uint64) {
 	return c.fastPath.GetStats()
@@ -121,14 +233,81 @@ func (c *Coordinator) GetSlowPathStats() (pages uint64, avgLatencyUs uint64, tot
 	return c.slowPath.GetStats()
 }
 
-// ProcessFastPath processes a page through the fast tokenizer
-func (c *Coordinator) ProcessFastPath(htmlBytes []byte, baseURL *url.URL) *FastPathResult {
-	return c.fastPath.ExtractLinks(htmlBytes, baseURL)
+// ProcessFastPath processes a page through the fast tokenizer.
+// When fast-path document detection is enabled, links matching docExtensions
+// are also reported in result.Documents.
+func (c *Coordinator) ProcessFastPath(htmlBytes []byte, baseURL *url.URL, docExtensions []string) *FastPathResult {
+	c.statsMu.RLock()
+	result := c.fastPath.ExtractLinks(htmlBytes, baseURL)
+	c.statsMu.RUnlock()
+
+	if c.fastPathDocs {
+		for _, u := range result.URLs {
+			if isDocument(u, docExtensions) {
+				result.Documents = append(result.Documents, u)
+			}
+		}
+	}
+
+	if c.scanInlineJSON {
+		urls, docs := scanInlineScripts(htmlBytes, baseURL, docExtensions)
+		if room := c.linkRoom(result.LinkCount); len(urls) > room {
+			urls, result.Capped = urls[:room], true
+		}
+		result.URLs = append(result.URLs, urls...)
+		result.LinkCount += len(urls)
+		result.Documents = append(result.Documents, docs...)
+	}
+
+	return result
+}
+
+// ProcessFastPathStream processes a page read from r through the fast
+// tokenizer without buffering it, for pages too large to hold in memory,
+// and returns how many bytes it read.
+// The page is counted as routed to the fast path once its size is known.
+// Inline script scanning needs the whole page and is skipped.
+func (c *Coordinator) ProcessFastPathStream(r io.Reader, baseURL *url.URL, docExtensions []string) (*FastPathResult, int64, error) {
+	c.statsMu.RLock()
+	result, n, err := c.fastPath.ExtractLinksStream(r, baseURL)
+	c.statsMu.RUnlock()
+	c.record(baseURL, int(n), FastPath, "streamed large page")
+
+	if c.fastPathDocs {
+		for _, u := range result.URLs {
+			if isDocument(u, docExtensions) {
+				result.Documents = append(result.Documents, u)
+			}
+		}
+	}
+
+	return result, n, err
 }
 
 // ProcessSlowPath processes a page through the slow tokenizer
 func (c *Coordinator) ProcessSlowPath(htmlBytes []byte, baseURL *url.URL, docExtensions []string) *SlowPathResult {
-	return c.slowPath.AnalyzeDocument(htmlBytes, baseURL, docExtensions)
+	c.statsMu.RLock()
+	result := c.slowPath.AnalyzeDocument(htmlBytes, baseURL, docExtensions)
+	c.statsMu.RUnlock()
+
+	if c.scanInlineJSON {
+		urls, docs := scanInlineScripts(htmlBytes, baseURL, docExtensions)
+		if room := c.linkRoom(result.LinkCount); len(urls) > room {
+			urls, result.Capped = urls[:room], true
+		}
+		result.URLs = append(result.URLs, urls...)
+		result.LinkCount += len(urls)
+		for _, d := range docs {
+			result.Documents = append(result.Documents, DocumentInfo{
+				URL:       d,
+				Extension: getExtension(d),
+				Context:   "inline script",
+			})
+		}
+		result.DocCount += len(docs)
+	}
+
+	return result
 }
 
 // SetFastPathSizeLimit adjusts the fast-path size threshold
@@ -136,15 +315,106 @@ func (c *Coordinator) SetFastPathSizeLimit(bytes int) {
 	c.fastPathSizeLimit = bytes
 }
 
+// SetFastPathDocDetection enables document detection on fast-path pages
+func (c *Coordinator) SetFastPathDocDetection(enabled bool) {
+	c.fastPathDocs = enabled
+}
+
+// SetScanInlineJSON also extracts links from strings inside <script>
+// elements (inline JSON state, JS config) on both paths. Off by default:
+// only quoted absolute URLs and quoted paths to documents are taken, but
+// scripts can still yield links no user would follow.
+func (c *Coordinator) SetScanInlineJSON(enabled bool) {
+	c.scanInlineJSON = enabled
+}
+
+// SetMaxLinksPerPage stops taking links from a page after n, on both
+// paths and counting inline script links; zero means no limit. Result
+// Capped fields report the pages that hit it.
+func (c *Coordinator) SetMaxLinksPerPage(n int) {
+	c.maxLinks = max(n, 0)
+	c.fastPath.SetMaxLinks(n)
+	c.slowPath.SetMaxLinks(n)
+}
+
+// linkRoom returns how many more links a page with count links may add
+func (c *Coordinator) linkRoom(count int) int {
+	if c.maxLinks == 0 {
+		return math.MaxInt
+	}
+	return max(c.maxLinks-count, 0)
+}
+
+// SetContextWindow configures the slow-path document context
+// (default: 200 bytes of the link's parent text)
+func (c *Coordinator) SetContextWindow(length int, scope ContextScope) {
+	c.slowPath.SetContextWindow(length, scope)
+}
+
+// SetAllowedSchemes sets which link schemes both paths keep (default:
+// DefaultSchemes). Relative links are always kept, since they take the
+// page's own scheme.
+func (c *Coordinator) SetAllowedSchemes(schemes []string) {
+	c.fastPath.SetAllowedSchemes(schemes)
+	c.slowPath.SetAllowedSchemes(schemes)
+}
+
 // SetSlowPathSizeLimit adjusts the slow-path size threshold
 func (c *Coordinator) SetSlowPathSizeLimit(bytes int) {
 	c.slowPathSizeLimit = bytes
 }
 
-// ResetStats clears all metrics
-func (c *Coordinator) ResetStats() {
+// TokenizerStats is a point-in-time copy of the coordinator's metrics
+type TokenizerStats struct {
+	FastPages     uint64
+	FastAvgUs     uint64
+	FastLinks     uint64
+	SlowPages     uint64
+	SlowAvgUs     uint64
+	SlowLinks     uint64
+	SlowDocs      uint64
+	FastRouted    uint64
+	SlowRouted    uint64
+	Skipped       uint64
+	Sitemaps      uint64
+	SizeHistogram []SizeBucket
+}
+
+// Snapshot returns a coherent copy of the metrics: no page is counted in
+// one figure but not yet in another
+func (c *Coordinator) Snapshot() TokenizerStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	return c.snapshotLocked()
+}
+
+func (c *Coordinator) snapshotLocked() TokenizerStats {
+	var s TokenizerStats
+	s.FastPages, s.FastAvgUs, s.FastLinks = c.fastPath.GetStats()
+	s.SlowPages, s.SlowAvgUs, s.SlowLinks, s.SlowDocs = c.slowPath.GetStats()
+	s.FastRouted = c.fastPathCount.Load()
+	s.SlowRouted = c.slowPathCount.Load()
+	s.Skipped = c.skippedCount.Load()
+	s.Sitemaps = c.sitemapCount.Load()
+	s.SizeHistogram = c.GetSizeHistogram()
+	return s
+}
+
+// ResetStats clears all metrics and returns their values just before, so a
+// long crawl can be measured in windows. Pages being tokenized finish
+// first and are counted in the closed window.
+func (c *Coordinator) ResetStats() TokenizerStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	closed := c.snapshotLocked()
 	c.fastPath.ResetStats()
 	c.slowPath.ResetStats()
 	c.fastPathCount.Store(0)
 	c.slowPathCount.Store(0)
+	c.skippedCount.Store(0)
+	c.sitemapCount.Store(0)
+	c.sizes.reset()
+	return closed
 }
@@ -0,0 +1,55 @@
+package tokenizer
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestDecideWithContentType(t *testing.T) {
+	tests := []struct {
+		url         string
+		contentType string
+		want        PathDecision
+	}{
+		{"https://example.com/sitemap.xml", "application/xml", SitemapPath},
+		{"https://example.com/sitemap.xml", "text/xml; charset=utf-8", SitemapPath},
+		{"https://example.com/sitemap_index.xml", "application/sitemap+xml", SitemapPath},
+		{"https://example.com/sitemap.xml.gz", "application/octet-stream", SitemapPath},
+		{"https://example.com/feed", "application/rss+xml", FastPath},
+		{"https://example.com/feed.atom", "application/atom+xml; charset=utf-8", FastPath},
+		{"https://example.com/logo.svg", "image/svg+xml", SkipPath},
+		{"https://example.com/page.xhtml", "application/xhtml+xml", SlowPath},
+		{"https://example.com/file.pdf", "application/pdf", SkipPath},
+		{"https://example.com/page", "text/html", FastPath},
+	}
+	c := NewCoordinator()
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := c.DecideWithContentType(u, 1024, tt.contentType); got != tt.want {
+			t.Errorf("DecideWithContentType(%s, %q) = %v, want %v", tt.url, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestRSSFeedLinksReachFastPath(t *testing.T) {
+	feed := []byte(`<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<atom:link href="https://example.com/feed" rel="self"/>
+<item><title>Post</title>
+<description><![CDATA[See <a href="https://example.com/posts/1">the post</a>]]></description></item>
+</channel></rss>`)
+	c := NewCoordinator()
+	base, _ := url.Parse("https://example.com/feed")
+
+	if got := c.DecideWithContentType(base, len(feed), "application/rss+xml"); got != FastPath {
+		t.Fatalf("RSS feed routed to %v, want %v", got, FastPath)
+	}
+	result := c.ProcessFastPath(feed, base, []string{".pdf"})
+	found := make(map[string]bool)
+	for _, u := range result.URLs {
+		found[u] = true
+	}
+	if !found["https://example.com/posts/1"] {
+		t.Errorf("feed links = %v, want https://example.com/posts/1", result.URLs)
+	}
+}
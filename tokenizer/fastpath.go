@@ -1,8 +1,8 @@
 package tokenizer
 
 import (
-	"bytes"
 	"net/url"
+	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -15,13 +15,18 @@ type FastPathTokenizer struct {
 	pagesProcessed atomic.Uint64
 	totalLatencyUs atomic.Uint64
 	linksExtracted atomic.Uint64
+
+	schemes  schemeSet // Link schemes to keep (nil: DefaultSchemes)
+	maxLinks int       // Links kept per page (0: unlimited)
 }
 
 // FastPathResult contains extracted URLs without metadata
 type FastPathResult struct {
 	URLs         []string
+	Documents    []string // Document URLs, only filled when fast-path doc detection is on
 	ProcessingUs uint64
 	LinkCount    int
+	Capped       bool // Links past the per-page limit were dropped
 }
 
 // NewFastPathTokenizer creates a new fast-path tokenizer
@@ -35,6 +40,7 @@ func (f *FastPathTokenizer) ExtractLinks(htmlBytes []byte, baseURL *url.URL) *Fa
 
 	var urls []string
 	linkCount := 0
+	capped := false
 
 	// Fast byte-level scan for href attributes
 	i := 0
@@ -67,9 +73,11 @@ func (f *FastPathTokenizer) ExtractLinks(htmlBytes []byte, baseURL *url.URL) *Fa
 			if i > urlStart {
 				rawURL := string(htmlBytes[urlStart:i])
 
-				if len(rawURL) > 0 && rawURL[0] != '#' &&
-					!bytes.HasPrefix([]byte(rawURL), []byte("javascript:")) &&
-					!bytes.HasPrefix([]byte(rawURL), []byte("mailto:")) {
+				if len(rawURL) > 0 && rawURL[0] != '#' && f.schemes.allows(rawURL) {
+					if f.maxLinks > 0 && linkCount >= f.maxLinks {
+						capped = true
+						break
+					}
 
 					absURL := makeAbsolute(rawURL, baseURL)
 					if absURL != "" {
@@ -92,9 +100,21 @@ func (f *FastPathTokenizer) ExtractLinks(htmlBytes []byte, baseURL *url.URL) *Fa
 		URLs:         urls,
 		ProcessingUs: elapsedUs,
 		LinkCount:    linkCount,
+		Capped:       capped,
 	}
 }
 
+// SetAllowedSchemes sets which link schemes are kept; relative links are
+// always kept
+func (f *FastPathTokenizer) SetAllowedSchemes(schemes []string) {
+	f.schemes = newSchemeSet(schemes)
+}
+
+// SetMaxLinks stops taking links from a page after n; zero means no limit
+func (f *FastPathTokenizer) SetMaxLinks(n int) {
+	f.maxLinks = max(n, 0)
+}
+
 func matchesHref(b []byte) bool {
 	if len(b) < 5 {
 		return false
@@ -106,8 +126,10 @@ func matchesHref(b []byte) bool {
 		b[4] == '='
 }
 
+// makeAbsolute resolves rawURL against base, returning "" if it can't be parsed
 func makeAbsolute(rawURL string, base *url.URL) string {
-	if len(rawURL) > 7 && (rawURL[0:7] == "http://" || rawURL[0:7] == "https:/") {
+	rawURL = repairScheme(rawURL, base)
+	if len(rawURL) > 8 && (rawURL[0:7] == "http://" || rawURL[0:8] == "https://") {
 		return rawURL
 	}
 
@@ -119,11 +141,33 @@ func makeAbsolute(rawURL string, base *url.URL) string {
 		return base.Scheme + "://" + base.Host + rawURL
 	}
 
-	baseStr := base.String()
-	if baseStr[len(baseStr)-1] == '/' {
-		return baseStr + rawURL
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// repairScheme rewrites the malformed http(s) links browsers still follow:
+// "https:/host/p" and "https:host/p" become "https://host/p". "http:p"
+// on a page with the same scheme stays relative, as browsers treat it.
+func repairScheme(rawURL string, base *url.URL) string {
+	colon := strings.IndexByte(rawURL, ':')
+	if colon < 0 {
+		return rawURL
+	}
+	scheme := strings.ToLower(rawURL[:colon])
+	if scheme != "http" && scheme != "https" {
+		return rawURL
+	}
+	rest := rawURL[colon+1:]
+	if strings.HasPrefix(rest, "//") {
+		return rawURL
+	}
+	if strings.HasPrefix(rest, "/") || scheme != base.Scheme {
+		return scheme + "://" + strings.TrimLeft(rest, "/")
 	}
-	return baseStr + "/" + rawURL
+	return rest
 }
 
 func (f *FastPathTokenizer) GetStats() (pages uint64, avgLatencyUs uint64, totalLinks uint64) {
@@ -0,0 +1,111 @@
+package tokenizer
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"time"
+)
+
+// maxStreamedURL caps one href value on the streaming fast path; longer
+// values are dropped rather than buffered
+const maxStreamedURL = 32 * 1024
+
+// ExtractLinksStream is ExtractLinks over a reader. Only a read buffer and
+// the href value being scanned are held in memory, so a huge index page
+// never has to be buffered whole. It also returns how many bytes were read;
+// on a read error the links found before it are still returned.
+func (f *FastPathTokenizer) ExtractLinksStream(r io.Reader, baseURL *url.URL) (*FastPathResult, int64, error) {
+	start := time.Now()
+
+	br := bufio.NewReaderSize(r, 64*1024)
+	var (
+		urls      []string
+		linkCount int
+		read      int64
+		window    [5]byte // Last five bytes seen, lowercased
+		value     []byte
+		readErr   error
+		capped    bool
+	)
+
+	next := func() (byte, bool) {
+		b, err := br.ReadByte()
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			return 0, false
+		}
+		read++
+		return b, true
+	}
+
+	for {
+		b, ok := next()
+		if !ok {
+			break
+		}
+		copy(window[:], window[1:])
+		if 'A' <= b && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		window[4] = b
+		if window != [5]byte{'h', 'r', 'e', 'f', '='} {
+			continue
+		}
+		window = [5]byte{}
+
+		// Read the attribute value up to its quote, or a space or '>'
+		quote := byte(0)
+		if p, err := br.Peek(1); err == nil && (p[0] == '"' || p[0] == '\'') {
+			quote = p[0]
+			next()
+		}
+		value = value[:0]
+		tooLong := false
+		for {
+			c, ok := next()
+			if !ok {
+				break
+			}
+			if quote != 0 && c == quote || quote == 0 && (c == ' ' || c == '>') {
+				break
+			}
+			if len(value) < maxStreamedURL {
+				value = append(value, c)
+			} else {
+				tooLong = true
+			}
+		}
+
+		if len(value) > 0 && !tooLong && value[0] != '#' {
+			rawURL := string(value)
+			if f.maxLinks > 0 && linkCount >= f.maxLinks {
+				// Keep reading so the page's size is still counted
+				capped = capped || f.schemes.allows(rawURL)
+			} else if f.schemes.allows(rawURL) {
+				if absURL := makeAbsolute(rawURL, baseURL); absURL != "" {
+					urls = append(urls, absURL)
+					linkCount++
+				}
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	elapsedUs := uint64(time.Since(start).Microseconds())
+
+	f.pagesProcessed.Add(1)
+	f.totalLatencyUs.Add(elapsedUs)
+	f.linksExtracted.Add(uint64(linkCount))
+
+	return &FastPathResult{
+		URLs:         urls,
+		ProcessingUs: elapsedUs,
+		LinkCount:    linkCount,
+		Capped:       capped,
+	}, read, readErr
+}
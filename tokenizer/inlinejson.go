@@ -0,0 +1,91 @@
+package tokenizer
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Inline script scanning finds links that only exist inside <script> blocks,
+// typically JSON state such as {"url":"https:\/\/example.org\/a.pdf"}.
+// Only quoted strings are considered: absolute http(s) URLs, and site-relative
+// paths when they point to a document. Anything else in scripts is too noisy.
+
+var (
+	quotedAbsURL  = regexp.MustCompile(`"(https?:(?:\\?/){2}[^"\s<>]+)"|'(https?:(?:\\?/){2}[^'\s<>]+)'`)
+	quotedRelPath = regexp.MustCompile(`"((?:\\?/)[A-Za-z0-9_\-.~%][^"\s<>]*)"|'((?:\\?/)[A-Za-z0-9_\-.~%][^'\s<>]*)'`)
+	jsonEscapes   = strings.NewReplacer(`\/`, `/`, `\u002F`, `/`, `\u002f`, `/`, `\u0026`, `&`)
+)
+
+// scanInlineScripts returns the URLs and documents referenced by strings
+// inside the page's <script> elements
+func scanInlineScripts(htmlBytes []byte, baseURL *url.URL, docExtensions []string) (urls, docs []string) {
+	seen := make(map[string]bool)
+	add := func(raw string, docsOnly bool) {
+		u, err := baseURL.Parse(jsonEscapes.Replace(raw))
+		if err != nil || u.Host == "" || !strings.Contains(u.Host, ".") {
+			return
+		}
+		u.Fragment = ""
+		urlStr := u.String()
+		if seen[urlStr] {
+			return
+		}
+
+		isDoc := isDocument(urlStr, docExtensions)
+		if docsOnly && !isDoc {
+			return
+		}
+		seen[urlStr] = true
+		urls = append(urls, urlStr)
+		if isDoc {
+			docs = append(docs, urlStr)
+		}
+	}
+
+	for _, script := range scriptBodies(htmlBytes) {
+		for _, m := range quotedAbsURL.FindAllSubmatch(script, -1) {
+			add(string(firstGroup(m)), false)
+		}
+		for _, m := range quotedRelPath.FindAllSubmatch(script, -1) {
+			add(string(firstGroup(m)), true)
+		}
+	}
+	return urls, docs
+}
+
+// scriptBodies returns the contents of every <script>...</script> element
+func scriptBodies(htmlBytes []byte) [][]byte {
+	var bodies [][]byte
+	lower := bytes.ToLower(htmlBytes)
+
+	for i := 0; ; {
+		open := bytes.Index(lower[i:], []byte("<script"))
+		if open < 0 {
+			break
+		}
+		start := i + open
+		tagEnd := bytes.IndexByte(lower[start:], '>')
+		if tagEnd < 0 {
+			break
+		}
+		bodyStart := start + tagEnd + 1
+
+		end := bytes.Index(lower[bodyStart:], []byte("</script"))
+		if end < 0 {
+			break
+		}
+		bodies = append(bodies, htmlBytes[bodyStart:bodyStart+end])
+		i = bodyStart + end
+	}
+	return bodies
+}
+
+// firstGroup returns whichever alternative of a two-group match participated
+func firstGroup(m [][]byte) []byte {
+	if len(m[1]) > 0 {
+		return m[1]
+	}
+	return m[2]
+}
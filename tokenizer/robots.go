@@ -0,0 +1,90 @@
+package tokenizer
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// RobotsDirectives are the restrictions a page places on crawlers through
+// <meta name="robots"> or an X-Robots-Tag header
+type RobotsDirectives struct {
+	NoFollow bool // Don't follow the page's links
+	NoIndex  bool // Don't keep the page
+}
+
+// robotsMetaScanLimit bounds how far into a page RobotsMeta looks when
+// there's no </head>; the tag belongs in the head
+const robotsMetaScanLimit = 64 * 1024
+
+var (
+	metaTag     = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaName    = regexp.MustCompile(`(?is)\bname\s*=\s*["']?robots["'\s/>]`)
+	metaContent = regexp.MustCompile(`(?is)\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// ParseRobotsDirectives reads a robots meta content or X-Robots-Tag value
+// such as "noindex, nofollow" or "none". Values aimed at a named crawler
+// ("googlebot: nofollow") are ignored, since they aren't addressed to us.
+func ParseRobotsDirectives(value string) RobotsDirectives {
+	var d RobotsDirectives
+	if agent, rest, ok := strings.Cut(value, ":"); ok && !strings.ContainsAny(agent, ",") {
+		if !isRobotsDirective(agent) {
+			return d
+		}
+		value = agent + "," + rest // e.g. "unavailable_after: <date>"
+	}
+
+	for _, field := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool { return r == ',' || r == ' ' }) {
+		switch field {
+		case "nofollow":
+			d.NoFollow = true
+		case "noindex":
+			d.NoIndex = true
+		case "none":
+			d.NoFollow, d.NoIndex = true, true
+		}
+	}
+	return d
+}
+
+// isRobotsDirective tells a directive name from a user agent name
+func isRobotsDirective(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "all", "none", "noindex", "nofollow", "noarchive", "nosnippet", "notranslate",
+		"noimageindex", "unavailable_after", "max-snippet", "max-image-preview", "max-video-preview", "indexifembedded":
+		return true
+	}
+	return false
+}
+
+// Merge combines two sets of directives; a restriction in either applies
+func (d RobotsDirectives) Merge(other RobotsDirectives) RobotsDirectives {
+	return RobotsDirectives{
+		NoFollow: d.NoFollow || other.NoFollow,
+		NoIndex:  d.NoIndex || other.NoIndex,
+	}
+}
+
+// RobotsMeta finds the page's <meta name="robots"> directives by scanning
+// the head, without building a DOM. The slow path reports the same thing
+// in PageMetadata.Robots.
+func RobotsMeta(htmlBytes []byte) RobotsDirectives {
+	head := htmlBytes
+	if end := bytes.Index(bytes.ToLower(head[:min(len(head), robotsMetaScanLimit)]), []byte("</head")); end >= 0 {
+		head = head[:end]
+	} else if len(head) > robotsMetaScanLimit {
+		head = head[:robotsMetaScanLimit]
+	}
+
+	var d RobotsDirectives
+	for _, tag := range metaTag.FindAll(head, -1) {
+		if !metaName.Match(tag) {
+			continue
+		}
+		if m := metaContent.FindSubmatch(tag); m != nil {
+			d = d.Merge(ParseRobotsDirectives(string(firstGroup(m))))
+		}
+	}
+	return d
+}
@@ -0,0 +1,62 @@
+package tokenizer
+
+import "strings"
+
+// DefaultSchemes are the link schemes the tokenizers keep unless
+// SetAllowedSchemes says otherwise
+var DefaultSchemes = []string{"http", "https"}
+
+// schemeSet is a set of lowercased URL schemes; nil means DefaultSchemes
+type schemeSet map[string]bool
+
+// newSchemeSet builds the set for SetAllowedSchemes; empty means the default
+func newSchemeSet(schemes []string) schemeSet {
+	if len(schemes) == 0 {
+		return nil
+	}
+	set := make(schemeSet, len(schemes))
+	for _, s := range schemes {
+		set[strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s), ":"))] = true
+	}
+	return set
+}
+
+// allows reports whether a raw href should be kept. Relative links have no
+// scheme of their own and inherit the page's, so they're always kept; links
+// with any other scheme (data:, blob:, tel:, javascript:, ...) are dropped
+// before they're parsed.
+func (s schemeSet) allows(href string) bool {
+	scheme := rawScheme(href)
+	if scheme == "" {
+		return true
+	}
+	if s == nil {
+		return scheme == "http" || scheme == "https"
+	}
+	return s[scheme]
+}
+
+// rawScheme returns the lowercased scheme of href, or "" when href is
+// relative. It follows RFC 3986: a letter, then letters, digits, '+', '-'
+// or '.', then ':'.
+func rawScheme(href string) string {
+	href = strings.TrimSpace(href)
+	for i := 0; i < len(href); i++ {
+		c := href[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9', c == '+', c == '-', c == '.':
+			if i == 0 {
+				return ""
+			}
+		case c == ':':
+			if i == 0 {
+				return ""
+			}
+			return strings.ToLower(href[:i])
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package tokenizer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// maxSitemapSize bounds decompressed sitemap bodies (the protocol limit is 50MB)
+const maxSitemapSize = 50 * 1024 * 1024
+
+// ProcessSitemap parses a sitemap (<urlset>) or sitemap index (<sitemapindex>)
+// and returns the page URLs and the child sitemaps it lists. Gzipped bodies
+// are decompressed. Relative <loc> values are resolved against baseURL.
+// Content that is not a sitemap yields nothing.
+func (c *Coordinator) ProcessSitemap(body []byte, baseURL *url.URL) (urls []string, childSitemaps []string) {
+	var r io.Reader = bytes.NewReader(body)
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	decoder := xml.NewDecoder(io.LimitReader(r, maxSitemapSize))
+	decoder.Strict = false
+
+	var parent string // "url" or "sitemap" while inside one
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "url", "sitemap":
+				parent = t.Name.Local
+			case "loc":
+				var loc string
+				if err := decoder.DecodeElement(&loc, &t); err != nil {
+					continue
+				}
+				u, err := baseURL.Parse(strings.TrimSpace(loc))
+				if err != nil || u.Host == "" {
+					continue
+				}
+				switch parent {
+				case "url":
+					urls = append(urls, u.String())
+				case "sitemap":
+					childSitemaps = append(childSitemaps, u.String())
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == parent {
+				parent = ""
+			}
+		}
+	}
+
+	c.statsMu.RLock()
+	c.sitemapCount.Add(1)
+	c.statsMu.RUnlock()
+	return urls, childSitemaps
+}
@@ -0,0 +1,109 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jeb/url_crawler/utils"
+)
+
+// SizeRule sends pages whose body is at least Min and below Max bytes to
+// Path. Zero Max means no upper bound.
+type SizeRule struct {
+	Min, Max int
+	Path     PathDecision
+}
+
+// matches reports whether a body of size bytes falls in the rule's band
+func (r SizeRule) matches(size int) bool {
+	return size >= r.Min && (r.Max == 0 || size < r.Max)
+}
+
+// String returns the rule in the form ParseSizeRules reads, e.g. "40KB-200KB=slow"
+func (r SizeRule) String() string {
+	band := "*"
+	if r.Min > 0 || r.Max > 0 {
+		band = "-"
+		if r.Min > 0 {
+			band = utils.FormatBytes(int64(r.Min)) + band
+		}
+		if r.Max > 0 {
+			band += utils.FormatBytes(int64(r.Max))
+		}
+	}
+	return band + "=" + r.Path.String()
+}
+
+// ParseSizeRules reads a comma-separated rule list such as
+// "40KB-200KB=slow,*=fast". A band is MIN-MAX with either end optional
+// ("-40KB", "1MB-"), or * for any size; the path is fast, slow or skip.
+func ParseSizeRules(s string) ([]SizeRule, error) {
+	var rules []SizeRule
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		band, path, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("size rule %q: want MIN-MAX=path", field)
+		}
+
+		var rule SizeRule
+		switch strings.ToLower(strings.TrimSpace(path)) {
+		case "fast":
+			rule.Path = FastPath
+		case "slow":
+			rule.Path = SlowPath
+		case "skip":
+			rule.Path = SkipPath
+		default:
+			return nil, fmt.Errorf("size rule %q: path must be fast, slow or skip", field)
+		}
+
+		if band = strings.TrimSpace(band); band != "*" {
+			lo, hi, ok := strings.Cut(band, "-")
+			if !ok {
+				return nil, fmt.Errorf("size rule %q: want MIN-MAX=path", field)
+			}
+			if lo = strings.TrimSpace(lo); lo != "" {
+				n, err := utils.ParseBytes(lo)
+				if err != nil {
+					return nil, fmt.Errorf("size rule %q: %w", field, err)
+				}
+				rule.Min = int(n)
+			}
+			if hi = strings.TrimSpace(hi); hi != "" {
+				n, err := utils.ParseBytes(hi)
+				if err != nil {
+					return nil, fmt.Errorf("size rule %q: %w", field, err)
+				}
+				rule.Max = int(n)
+			}
+			if rule.Max > 0 && rule.Max <= rule.Min {
+				return nil, fmt.Errorf("size rule %q: empty size band", field)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SetSizeRules replaces the two size thresholds with rules checked in
+// order; the first whose band holds the page's size decides its path.
+// Pages matching no rule fall through to the URL heuristics, with no size
+// checks, so end the list with a "*" rule to route everything by size.
+// nil restores the thresholds.
+func (c *Coordinator) SetSizeRules(rules []SizeRule) {
+	c.sizeRules = rules
+}
+
+// decideBySize applies the size rules; ok is false when none matched
+func (c *Coordinator) decideBySize(bodySize int) (decision PathDecision, reason string, ok bool) {
+	for _, rule := range c.sizeRules {
+		if rule.matches(bodySize) {
+			return rule.Path, "size rule " + rule.String(), true
+		}
+	}
+	return 0, "", false
+}
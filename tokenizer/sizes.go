@@ -0,0 +1,106 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// sizeBucketBounds are the upper bounds of the page size histogram buckets;
+// the last bucket holds everything larger
+var sizeBucketBounds = [...]int{
+	10 * 1024, 25 * 1024, 50 * 1024, 100 * 1024, 250 * 1024,
+	500 * 1024, 1024 * 1024, 5 * 1024 * 1024,
+}
+
+// SizeBucket counts processed pages of up to UpperBytes per path.
+// UpperBytes is 0 for the final, unbounded bucket.
+type SizeBucket struct {
+	UpperBytes int
+	Fast       uint64
+	Slow       uint64
+}
+
+// sizeHistogram counts page sizes per path
+type sizeHistogram struct {
+	fast [len(sizeBucketBounds) + 1]atomic.Uint64
+	slow [len(sizeBucketBounds) + 1]atomic.Uint64
+}
+
+func (h *sizeHistogram) record(decision PathDecision, size int) {
+	i := len(sizeBucketBounds)
+	for j, bound := range sizeBucketBounds {
+		if size < bound {
+			i = j
+			break
+		}
+	}
+
+	switch decision {
+	case FastPath:
+		h.fast[i].Add(1)
+	case SlowPath:
+		h.slow[i].Add(1)
+	}
+}
+
+func (h *sizeHistogram) reset() {
+	for i := range h.fast {
+		h.fast[i].Store(0)
+		h.slow[i].Store(0)
+	}
+}
+
+// GetSizeHistogram returns the sizes of the pages routed so far, per path
+func (c *Coordinator) GetSizeHistogram() []SizeBucket {
+	buckets := make([]SizeBucket, len(sizeBucketBounds)+1)
+	for i := range buckets {
+		if i < len(sizeBucketBounds) {
+			buckets[i].UpperBytes = sizeBucketBounds[i]
+		}
+		buckets[i].Fast = c.sizes.fast[i].Load()
+		buckets[i].Slow = c.sizes.slow[i].Load()
+	}
+	return buckets
+}
+
+// SizeRecommendation compares the measured page sizes with the fast-path
+// size limit and suggests a change when the limit looks mistuned, e.g.
+// "70% of pages are <50KB; consider lowering the fast-path limit".
+// It returns "" when too few pages have been seen.
+func (c *Coordinator) SizeRecommendation() string {
+	buckets := c.GetSizeHistogram()
+
+	var total uint64
+	for _, b := range buckets {
+		total += b.Fast + b.Slow
+	}
+	if total < 100 {
+		return ""
+	}
+
+	// Smallest bucket bound covering 70% of pages
+	var cumulative uint64
+	p70 := 0
+	for _, b := range buckets {
+		cumulative += b.Fast + b.Slow
+		if cumulative*10 >= total*7 {
+			p70 = b.UpperBytes
+			break
+		}
+	}
+
+	if p70 == 0 {
+		return fmt.Sprintf("70%% of pages are over %dKB; consider raising the fast-path limit (now %dKB)",
+			sizeBucketBounds[len(sizeBucketBounds)-1]/1024, c.fastPathSizeLimit/1024)
+	}
+
+	summary := fmt.Sprintf("70%% of pages are <%dKB", p70/1024)
+	switch {
+	case p70 > c.fastPathSizeLimit:
+		return fmt.Sprintf("%s; consider raising the fast-path limit (now %dKB) to %dKB", summary, c.fastPathSizeLimit/1024, p70/1024)
+	case p70*4 <= c.fastPathSizeLimit:
+		return fmt.Sprintf("%s; the fast-path limit (%dKB) could be lowered to route more mid-size pages to the slow path", summary, c.fastPathSizeLimit/1024)
+	default:
+		return summary + fmt.Sprintf("; the fast-path limit (%dKB) fits", c.fastPathSizeLimit/1024)
+	}
+}
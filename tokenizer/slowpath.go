@@ -3,6 +3,7 @@ package tokenizer
 import (
 	"bytes"
 	"net/url"
+	"path"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -19,8 +20,27 @@ type SlowPathTokenizer struct {
 	totalLatencyUs atomic.Uint64
 	linksExtracted atomic.Uint64
 	docsDetected   atomic.Uint64
+
+	// Document link context
+	contextLength int
+	contextScope  ContextScope
+
+	schemes  schemeSet // Link schemes to keep (nil: DefaultSchemes)
+	maxLinks int       // Links kept per page (0: unlimited)
 }
 
+// ContextScope selects which text around a document link becomes its context
+type ContextScope int
+
+const (
+	ContextParent   ContextScope = iota // Text of the link's immediate parent
+	ContextBlock                        // Text of the nearest block-level ancestor
+	ContextSiblings                     // Parent text plus the neighbouring elements' text
+)
+
+// blockElements are the ancestors ContextBlock stops at
+const blockElements = "p, li, td, th, dd, dt, div, section, article, aside, blockquote, figure, header, footer, main, nav, form, table"
+
 // SlowPathResult contains extracted URLs plus document metadata
 type SlowPathResult struct {
 	URLs         []string
@@ -28,6 +48,7 @@ type SlowPathResult struct {
 	ProcessingUs uint64
 	LinkCount    int
 	DocCount     int
+	Capped       bool // Links past the per-page limit were dropped
 	PageMetadata PageMetadata
 }
 
@@ -43,14 +64,38 @@ type DocumentInfo struct {
 type PageMetadata struct {
 	Title       string
 	Description string
-	LinkDensity float64 // links per KB of HTML
-	HasNav      bool    // Contains navigation elements
-	Depth       int     // From context
+	LinkDensity float64          // links per KB of HTML
+	HasNav      bool             // Contains navigation elements
+	Robots      RobotsDirectives // From <meta name="robots">
+	Depth       int              // From context
+	Next        string           // Absolute URL of the next page of a paginated listing (rel=next)
+	Prev        string           // Absolute URL of the previous page (rel=prev or rel=previous)
 }
 
 // NewSlowPathTokenizer creates a new slow-path tokenizer
 func NewSlowPathTokenizer() *SlowPathTokenizer {
-	return &SlowPathTokenizer{}
+	return &SlowPathTokenizer{
+		contextLength: 200,
+		contextScope:  ContextParent,
+	}
+}
+
+// SetContextWindow sets how much text (in bytes) and which surrounding
+// elements are captured as a document link's context
+func (s *SlowPathTokenizer) SetContextWindow(length int, scope ContextScope) {
+	s.contextLength = length
+	s.contextScope = scope
+}
+
+// SetAllowedSchemes sets which link schemes are kept; relative links are
+// always kept
+func (s *SlowPathTokenizer) SetAllowedSchemes(schemes []string) {
+	s.schemes = newSchemeSet(schemes)
+}
+
+// SetMaxLinks stops taking links from a page after n; zero means no limit
+func (s *SlowPathTokenizer) SetMaxLinks(n int) {
+	s.maxLinks = max(n, 0)
 }
 
 // AnalyzeDocument performs comprehensive HTML analysis with full parsing
@@ -77,23 +122,34 @@ func (s *SlowPathTokenizer) AnalyzeDocument(htmlBytes []byte, baseURL *url.URL,
 	result.PageMetadata.Title = doc.Find("title").First().Text()
 	result.PageMetadata.Description = doc.Find("meta[name='description']").AttrOr("content", "")
 	result.PageMetadata.HasNav = doc.Find("nav").Length() > 0
+	doc.Find("meta[name]").Each(func(i int, sel *goquery.Selection) {
+		if strings.EqualFold(sel.AttrOr("name", ""), "robots") {
+			result.PageMetadata.Robots = result.PageMetadata.Robots.Merge(ParseRobotsDirectives(sel.AttrOr("content", "")))
+		}
+	})
+	s.findPagination(doc, baseURL, &result.PageMetadata)
 
 	// Process all links
-	doc.Find("a[href]").Each(func(i int, sel *goquery.Selection) {
+	doc.Find("a[href]").EachWithBreak(func(i int, sel *goquery.Selection) bool {
 		href, exists := sel.Attr("href")
 		if !exists || href == "" || href == "#" {
-			return
+			return true
+		}
+
+		// Skip javascript:, mailto:, data: and other unwanted schemes
+		if !s.schemes.allows(href) {
+			return true
 		}
 
-		// Skip javascript/mailto
-		if strings.HasPrefix(href, "javascript:") || strings.HasPrefix(href, "mailto:") {
-			return
+		if s.maxLinks > 0 && result.LinkCount >= s.maxLinks {
+			result.Capped = true
+			return false
 		}
 
 		// Make absolute
-		absURL, err := baseURL.Parse(href)
+		absURL, err := baseURL.Parse(repairScheme(href, baseURL))
 		if err != nil {
-			return
+			return true
 		}
 
 		urlStr := absURL.String()
@@ -106,11 +162,12 @@ func (s *SlowPathTokenizer) AnalyzeDocument(htmlBytes []byte, baseURL *url.URL,
 				URL:       urlStr,
 				Extension: getExtension(urlStr),
 				Title:     sel.Text(),
-				Context:   getContext(sel),
+				Context:   s.getContext(sel),
 			}
 			result.Documents = append(result.Documents, doc)
 			result.DocCount++
 		}
+		return true
 	})
 
 	// Calculate link density
@@ -131,6 +188,31 @@ func (s *SlowPathTokenizer) AnalyzeDocument(htmlBytes []byte, baseURL *url.URL,
 	return result
 }
 
+// findPagination fills in meta.Next and meta.Prev from the first <link>,
+// <a> or <area> whose rel names them
+func (s *SlowPathTokenizer) findPagination(doc *goquery.Document, baseURL *url.URL, meta *PageMetadata) {
+	doc.Find("link[rel][href], a[rel][href], area[rel][href]").EachWithBreak(func(i int, sel *goquery.Selection) bool {
+		href := strings.TrimSpace(sel.AttrOr("href", ""))
+		if href == "" || !s.schemes.allows(href) {
+			return true
+		}
+		for _, rel := range strings.Fields(strings.ToLower(sel.AttrOr("rel", ""))) {
+			target := &meta.Next
+			if rel == "prev" || rel == "previous" {
+				target = &meta.Prev
+			} else if rel != "next" {
+				continue
+			}
+			if *target == "" {
+				if abs, err := baseURL.Parse(repairScheme(href, baseURL)); err == nil {
+					*target = abs.String()
+				}
+			}
+		}
+		return meta.Next == "" || meta.Prev == ""
+	})
+}
+
 // isDocument checks if URL points to a document
 func isDocument(urlStr string, extensions []string) bool {
 	urlLower := strings.ToLower(urlStr)
@@ -142,32 +224,50 @@ func isDocument(urlStr string, extensions []string) bool {
 	return false
 }
 
-// getExtension extracts file extension from URL
+// getExtension extracts the file extension from the URL's path
 func getExtension(urlStr string) string {
-	parts := strings.Split(urlStr, ".")
-	if len(parts) > 1 {
-		ext := parts[len(parts)-1]
-		// Remove query params
-		if idx := strings.Index(ext, "?"); idx != -1 {
-			ext = ext[:idx]
-		}
-		return "." + ext
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return ""
 	}
-	return ""
+	return path.Ext(u.Path)
 }
 
 // getContext extracts surrounding text context for a link
-func getContext(sel *goquery.Selection) string {
-	// Get parent element text (simplified)
-	parent := sel.Parent()
-	if parent.Length() > 0 {
-		text := parent.Text()
-		if len(text) > 200 {
-			text = text[:200] + "..."
+func (s *SlowPathTokenizer) getContext(sel *goquery.Selection) string {
+	var text string
+
+	switch s.contextScope {
+	case ContextBlock:
+		block := sel.ParentsFiltered(blockElements).First()
+		if block.Length() == 0 {
+			block = sel.Parent()
+		}
+		text = block.Text()
+	case ContextSiblings:
+		parent := sel.Parent()
+		if parent.Length() == 0 {
+			return ""
 		}
-		return strings.TrimSpace(text)
+		parts := []string{
+			strings.TrimSpace(parent.Prev().Text()),
+			strings.TrimSpace(parent.Text()),
+			strings.TrimSpace(parent.Next().Text()),
+		}
+		text = strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	default:
+		// Get parent element text (simplified)
+		parent := sel.Parent()
+		if parent.Length() == 0 {
+			return ""
+		}
+		text = parent.Text()
+	}
+
+	if s.contextLength > 0 && len(text) > s.contextLength {
+		text = text[:s.contextLength] + "..."
 	}
-	return ""
+	return strings.TrimSpace(text)
 }
 
 // GetStats returns slow-path statistics
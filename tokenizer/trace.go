@@ -0,0 +1,84 @@
+package tokenizer
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DecisionRecord describes one routing decision
+type DecisionRecord struct {
+	URL      string
+	Size     int
+	Decision PathDecision
+	Reason   string // The heuristic that matched
+	Time     time.Time
+}
+
+// DecisionTraceFunc receives each routing decision as it is made
+type DecisionTraceFunc func(u *url.URL, size int, decision PathDecision, reason string)
+
+// decisionTrace keeps the most recent decisions and forwards them to a callback
+type decisionTrace struct {
+	mu       sync.Mutex
+	records  []DecisionRecord
+	next     int
+	full     bool
+	callback DecisionTraceFunc
+}
+
+// EnableDecisionTrace records the last size routing decisions and, if fn is
+// non-nil, calls it for every decision. Call before crawling starts; while
+// tracing is off Decide does no extra work.
+func (c *Coordinator) EnableDecisionTrace(size int, fn DecisionTraceFunc) {
+	if size <= 0 && fn == nil {
+		c.trace = nil
+		return
+	}
+	c.trace = &decisionTrace{
+		records:  make([]DecisionRecord, max(size, 0)),
+		callback: fn,
+	}
+}
+
+// DecisionTrace returns the recorded decisions, oldest first
+func (c *Coordinator) DecisionTrace() []DecisionRecord {
+	if c.trace == nil {
+		return nil
+	}
+	return c.trace.snapshot()
+}
+
+func (t *decisionTrace) record(u *url.URL, size int, decision PathDecision, reason string) {
+	if len(t.records) > 0 {
+		t.mu.Lock()
+		t.records[t.next] = DecisionRecord{
+			URL:      u.String(),
+			Size:     size,
+			Decision: decision,
+			Reason:   reason,
+			Time:     time.Now(),
+		}
+		t.next = (t.next + 1) % len(t.records)
+		if t.next == 0 {
+			t.full = true
+		}
+		t.mu.Unlock()
+	}
+
+	if t.callback != nil {
+		t.callback(u, size, decision, reason)
+	}
+}
+
+func (t *decisionTrace) snapshot() []DecisionRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]DecisionRecord(nil), t.records[:t.next]...)
+	}
+	out := make([]DecisionRecord, 0, len(t.records))
+	out = append(out, t.records[t.next:]...)
+	return append(out, t.records[:t.next]...)
+}
@@ -1,22 +1,188 @@
 package utils
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
 )
 
-// NormalizeParsedURL normalizes a parsed URL
+// NormalizeParsedURL normalizes a parsed URL. Internationalized hosts
+// take their punycode form, so both spellings give the same key.
 func NormalizeParsedURL(u *url.URL) string {
 	u.Fragment = ""
 	u.RawQuery = ""
+	SetASCIIHost(u)
 	return strings.ToLower(u.String())
 }
 
+// ASCIIHost lowercases host and converts internationalized labels to
+// punycode, so münchen.de and xn--mnchen-3ya.de compare equal. A host
+// IDNA rejects is only lowercased.
+func ASCIIHost(host string) string {
+	if !isASCII(host) {
+		if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+			return ascii
+		}
+	}
+	return strings.ToLower(host)
+}
+
+// SetASCIIHost rewrites an internationalized host in u to punycode, as
+// ASCIIHost, keeping the port. It reports whether u changed.
+func SetASCIIHost(u *url.URL) bool {
+	host := u.Hostname()
+	if isASCII(host) {
+		return false
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return false
+	}
+	if port := u.Port(); port != "" {
+		ascii = net.JoinHostPort(ascii, port)
+	}
+	u.Host = ascii
+	return true
+}
+
+// ASCIIURL returns rawURL with its host in punycode, or rawURL unchanged
+// if it has no internationalized host or cannot be parsed
+func ASCIIURL(rawURL string) string {
+	if isASCII(rawURL) {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || !SetASCIIHost(u) {
+		return rawURL
+	}
+	return u.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateStartURL trims and normalizes a user-supplied start URL.
+// Scheme-less input such as "example.com/docs" gets https://, non-http(s)
+// schemes are forced to https, and hosts that cannot be valid are rejected.
+// Single-label hosts (intranet names, service names) and user:password are
+// kept. file:// URLs are passed through for local crawls.
+func ValidateStartURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("empty URL")
+	}
+	if strings.HasPrefix(strings.ToLower(raw), "file://") {
+		return validateFileURL(raw)
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + strings.TrimPrefix(raw, "//")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		u.Scheme = "https"
+	}
+
+	if err := validateHost(u.Hostname()); err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("invalid URL %q: bad port %q", raw, port)
+		}
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	SetASCIIHost(u)
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// validateFileURL accepts file:///path start URLs for local crawls
+func validateFileURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Host != "" && !strings.EqualFold(u.Host, "localhost") {
+		return "", fmt.Errorf("invalid URL %q: file URLs must be local", raw)
+	}
+	if u.Path == "" {
+		return "", fmt.Errorf("invalid URL %q: missing path", raw)
+	}
+
+	u.Scheme = "file"
+	u.Host = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// validateHost rejects malformed hostnames: empty or overlong labels, or
+// characters other than letters, digits and hyphens
+func validateHost(host string) error {
+	if host == "" {
+		return errors.New("missing host")
+	}
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return nil
+	}
+	if len(host) > 253 {
+		return errors.New("host too long")
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("host %q has an invalid label", host)
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("host %q has an invalid label", host)
+		}
+		for _, r := range label {
+			if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return fmt.Errorf("host %q contains %q", host, r)
+			}
+		}
+	}
+	return nil
+}
+
+// HostOf returns the hostname of a raw URL as ASCIIHost gives it, or "" if
+// it cannot be parsed
+func HostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return ASCIIHost(u.Hostname())
+}
+
 // IsDocumentURL checks if a URL points to a document
 func IsDocumentURL(docURL string, extensions []string) bool {
 	lowerURL := strings.ToLower(docURL)
@@ -42,11 +208,14 @@ func ExtractFilename(docURL string, headers http.Header) string {
 		}
 	}
 
-	segments := strings.Split(docURL, "/")
-	filename := segments[len(segments)-1]
-
-	if idx := strings.Index(filename, "?"); idx != -1 {
-		filename = filename[:idx]
+	// Take the last segment of the path only, so a bare host like
+	// example.com never becomes the file name
+	var filename string
+	if u, err := url.Parse(docURL); err == nil {
+		filename = path.Base(u.Path)
+		if filename == "/" || filename == "." {
+			filename = ""
+		}
 	}
 
 	if filename == "" || !strings.Contains(filename, ".") {
@@ -56,16 +225,91 @@ func ExtractFilename(docURL string, headers http.Header) string {
 	return SanitizeFilename(filename)
 }
 
-// SanitizeFilename removes invalid characters from a filename
+// maxFilenameBytes keeps names well under the 255-byte limit of most filesystems
+const maxFilenameBytes = 200
+
+// filenameReplacer swaps characters that are invalid in file names on
+// common filesystems for "_"
+var filenameReplacer = strings.NewReplacer(
+	"\\", "_", "/", "_", ":", "_", "*", "_", "?", "_",
+	"\"", "_", "<", "_", ">", "_", "|", "_", "\x00", "_",
+)
+
+// SanitizeFilename removes invalid characters from a filename and
+// shortens it to 200 bytes, keeping the extension (.tar.gz included).
+// Either change can make distinct names equal, so a changed name also gets
+// a short hash of the original before its extension.
 func SanitizeFilename(name string) string {
-	for _, ch := range []string{"\\", "/", ":", "*", "?", "\"", "<", ">", "|", "\x00"} {
-		name = strings.ReplaceAll(name, ch, "_")
+	clean := filenameReplacer.Replace(name)
+	if clean == name && len(name) <= maxFilenameBytes {
+		return name
+	}
+
+	ext := compoundExt(clean)
+	base := strings.TrimSuffix(clean, ext)
+	sum := sha256.Sum256([]byte(name))
+	tag := "_" + hex.EncodeToString(sum[:4])
+	if keep := maxFilenameBytes - len(tag) - len(ext); len(base) > keep {
+		base = truncateUTF8(base, keep)
+	}
+	return base + tag + ext
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// compoundExt returns name's extension, taking in a short inner one as in
+// .tar.gz or .pdf.zip. Anything over 10 bytes is not treated as one.
+func compoundExt(name string) string {
+	ext := filepath.Ext(name)
+	if len(ext) > 10 {
+		return ""
+	}
+	rest := strings.TrimSuffix(name, ext)
+	inner := filepath.Ext(rest)
+	if inner == rest || len(inner) < 2 || len(inner) > 5 {
+		return ext
+	}
+	letter := false
+	for _, r := range inner[1:] {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return ext
+		}
+		letter = letter || unicode.IsLetter(r)
+	}
+	if !letter {
+		return ext
+	}
+	return inner + ext
+}
+
+// stdinScanner is shared so buffered input is never lost between prompts
+var stdinScanner = bufio.NewScanner(os.Stdin)
+
+// ReadLine reads a full line from stdin, keeping embedded spaces.
+// It returns "" at EOF.
+func ReadLine() string {
+	if !stdinScanner.Scan() {
+		return ""
 	}
-	if len(name) > 200 {
-		ext := filepath.Ext(name)
-		name = name[:200-len(ext)] + ext
+	return strings.TrimRight(stdinScanner.Text(), "\r")
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
 	}
-	return name
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
 // FormatBytes formats bytes into a human-readable string
@@ -82,6 +326,25 @@ func FormatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// ParseBytes parses a size such as 512, 10KB, 1.5M or 2GiB. Units are
+// powers of 1024, as in FormatBytes.
+func ParseBytes(input string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(input))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+	if i := strings.IndexAny(s, "KMGTPE"); i >= 0 && i == len(s)-1 {
+		multiplier = 1 << (10 * (strings.IndexByte("KMGTPE", s[i]) + 1))
+		s = s[:i]
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", input)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
 // FormatMemory formats memory statistics into a human-readable string
 func FormatMemory(m runtime.MemStats) string {
 	return fmt.Sprintf("Alloc: %dMB, Sys: %dMB",
@@ -94,3 +357,34 @@ func GetMemStats() runtime.MemStats {
 	runtime.ReadMemStats(&m)
 	return m
 }
+
+// mapEntryOverhead approximates the per-entry cost of a map[string]T beyond
+// the key bytes: the string header, a small value and bucket bookkeeping
+const mapEntryOverhead = 64
+
+// MapSize is the estimated memory held by one URL map
+type MapSize struct {
+	Name    string
+	Entries int
+	Bytes   int64
+}
+
+// EstimateMapSize estimates the memory used by m from its length and the
+// average length of up to 1000 of its keys. The caller must hold whatever
+// lock guards m.
+func EstimateMapSize[V any](name string, m map[string]V) MapSize {
+	size := MapSize{Name: name, Entries: len(m)}
+
+	sampled, keyBytes := 0, 0
+	for k := range m {
+		keyBytes += len(k)
+		if sampled++; sampled == 1000 {
+			break
+		}
+	}
+	if sampled > 0 {
+		avgKey := int64(keyBytes / sampled)
+		size.Bytes = int64(len(m)) * (avgKey + mapEntryOverhead)
+	}
+	return size
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestValidateStartURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"no scheme", "example.com/docs", "https://example.com/docs", false},
+		{"scheme-relative", "//example.com/docs", "https://example.com/docs", false},
+		{"surrounding whitespace", "  \thttp://example.com/a \n", "http://example.com/a", false},
+		{"host case folded", "HTTPS://Example.COM/Path", "https://example.com/Path", false},
+		{"fragment dropped", "https://example.com/a#top", "https://example.com/a", false},
+		{"user info kept", "https://user:pw@Example.com/a", "https://user:pw@example.com/a", false},
+		{"ip address", "http://192.0.2.1:8080/", "http://192.0.2.1:8080/", false},
+		{"localhost", "http://localhost:3000", "http://localhost:3000", false},
+		{"empty", "   ", "", true},
+		{"single label", "https://intranet/", "https://intranet/", false},
+		{"single label with port", "http://wiki:8080/", "http://wiki:8080/", false},
+		{"service name without scheme", "docs-svc/index.html", "https://docs-svc/index.html", false},
+		{"empty label", "https://example..com/", "", true},
+		{"leading hyphen", "https://-example.com/", "", true},
+		{"trailing hyphen", "https://example-.com/", "", true},
+		{"label too long", "https://" + strings.Repeat("a", 64) + ".com/", "", true},
+		{"underscore", "https://exa_mple.com/", "", true},
+		{"port zero", "https://example.com:0/", "", true},
+		{"port too large", "https://example.com:65536/", "", true},
+		{"port not a number", "https://example.com:http/", "", true},
+		{"file url", "file:///var/www/index.html", "file:///var/www/index.html", false},
+		{"file url on localhost", "file://localhost/var/www/", "file:///var/www/", false},
+		{"file url query dropped", "file:///var/www/index.html?x=1#y", "file:///var/www/index.html", false},
+		{"remote file url", "file://server/share/index.html", "", true},
+		{"file url without path", "file://", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateStartURL(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ValidateStartURL(%q) = %q, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateStartURL(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ValidateStartURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameUnicodeCut(t *testing.T) {
+	name := strings.Repeat("文書", 100) + ".pdf" // 600 bytes of 3-byte runes
+	got := SanitizeFilename(name)
+
+	if len(got) > maxFilenameBytes {
+		t.Fatalf("len = %d, want at most %d", len(got), maxFilenameBytes)
+	}
+	if !utf8.ValidString(got) {
+		t.Fatalf("%q was cut inside a rune", got)
+	}
+	if !strings.HasSuffix(got, ".pdf") {
+		t.Fatalf("%q lost its extension", got)
+	}
+}
+
+func TestSanitizeFilenameCompoundExtensions(t *testing.T) {
+	for _, ext := range []string{".tar.gz", ".pdf.zip"} {
+		name := strings.Repeat("x", 300) + ext
+		got := SanitizeFilename(name)
+		if len(got) > maxFilenameBytes {
+			t.Errorf("%s: len = %d, want at most %d", ext, len(got), maxFilenameBytes)
+		}
+		if !strings.HasSuffix(got, ext) {
+			t.Errorf("%s: got %q", ext, got)
+		}
+	}
+
+	tests := map[string]string{
+		"archive.tar.gz":  ".tar.gz",
+		"report.pdf.zip":  ".pdf.zip",
+		"report.pdf":      ".pdf",
+		"v1.2.zip":        ".zip", // Digits only: a version, not an extension
+		"notes.final.txt": ".txt",
+	}
+	for name, want := range tests {
+		if got := compoundExt(name); got != want {
+			t.Errorf("compoundExt(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSanitizeFilenameHashSeparatesCollisions(t *testing.T) {
+	a := SanitizeFilename("report:2024.pdf")
+	b := SanitizeFilename("report?2024.pdf")
+	if a == b {
+		t.Fatalf("%q and %q both became %q", "report:2024.pdf", "report?2024.pdf", a)
+	}
+	for _, got := range []string{a, b} {
+		if !strings.HasPrefix(got, "report_2024_") || !strings.HasSuffix(got, ".pdf") {
+			t.Errorf("got %q, want report_2024_<hash>.pdf", got)
+		}
+	}
+
+	if got := SanitizeFilename("plain.pdf"); got != "plain.pdf" {
+		t.Errorf("a clean name changed: %q", got)
+	}
+}